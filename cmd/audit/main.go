@@ -0,0 +1,56 @@
+// Command audit tests a draw event log against the configured rates and
+// prints per-bin binomial and chi-square p-values.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/xtding233/gacha-backend/internal/audit"
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func main() {
+	baseDir := flag.String("config", ".", "base directory containing games/default.yaml")
+	eventsPath := flag.String("events", "", "draw event log written by the server (-events)")
+	rarity := flag.Int("rarity", 0, "tier to audit; 0 audits the top tier")
+	width := flag.Int("width", 1, "pity counts per bin")
+	flag.Parse()
+	if *eventsPath == "" {
+		log.Fatal("-events is required")
+	}
+
+	events, err := eventlog.OpenFileLog(*eventsPath)
+	if err != nil {
+		log.Fatalf("failed to open event log: %v", err)
+	}
+	defer events.Close()
+	params := eventlog.ResolverParams(game.NewResolver(game.NewLoader(*baseDir)))
+	groups, err := audit.AuditLog(context.Background(), events, params, audit.Options{Rarity: *rarity, BinWidth: *width})
+	if err != nil {
+		log.Fatalf("audit failed: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+	for _, g := range groups {
+		r := g.Report
+		fmt.Fprintf(w, "%s/%s version %q: %d★, %d pulls\t\n", g.Game, g.Pool, g.Version, r.Rarity, r.Pulls)
+		printLayer(w, "count", r.Pity)
+		printLayer(w, "off_streak", r.Off)
+		fmt.Fprintf(w, "forced UP: %d hits, %d broken\t\n\n", r.Guaranteed, r.GuaranteeBroken)
+	}
+}
+
+func printLayer(w *tabwriter.Writer, name string, l audit.Layer) {
+	fmt.Fprintf(w, "%s\tpulls\thits\texpected\tchi²\tp\t\n", name)
+	for _, b := range l.Bins {
+		fmt.Fprintf(w, "%d-%d\t%d\t%d\t%.2f\t%.2f\t%.4f\t\n", b.From, b.To, b.Pulls, b.Hits, b.Expected, b.ChiSq, b.PValue)
+	}
+	fmt.Fprintf(w, "total\t\t\t\t%.2f (df %d)\t%.4f\t\n", l.ChiSq, l.DF, l.PValue)
+}
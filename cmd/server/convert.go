@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gachav1 "github.com/xtding233/gacha-backend/gen/gacha/v1"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+// ---- proto <-> engine conversions ----
+// proto3 scalars have no presence, so zero values mean "use the config value".
+
+var softModeNames = map[gachav1.SoftPityMode]string{
+	gachav1.SoftPityMode_SOFT_PITY_MODE_TARGET_RAMP:        string(gacha.SoftTargetRamp),
+	gachav1.SoftPityMode_SOFT_PITY_MODE_PER_DRAW_INCREMENT: string(gacha.SoftPerDrawIncrement),
+}
+
+var easingNames = map[gachav1.Easing]string{
+	gachav1.Easing_EASING_LINEAR:            string(gacha.EaseLinear),
+	gachav1.Easing_EASING_EASE_OUT_QUAD:     string(gacha.EaseOutQuad),
+	gachav1.Easing_EASING_EASE_IN_OUT_CUBIC: string(gacha.EaseInOutCubic),
+}
+
+var statisticNames = map[gachav1.Statistic]gacha.Quantity{
+	gachav1.Statistic_STATISTIC_MEAN:     gacha.QuantityMean,
+	gachav1.Statistic_STATISTIC_VARIANCE: gacha.QuantityVar,
+	gachav1.Statistic_STATISTIC_STD_DEV:  gacha.QuantityStdDev,
+	gachav1.Statistic_STATISTIC_P50:      gacha.QuantityP50,
+	gachav1.Statistic_STATISTIC_P90:      gacha.QuantityP90,
+	gachav1.Statistic_STATISTIC_P99:      gacha.QuantityP99,
+}
+
+func softModeToProto(mode string) gachav1.SoftPityMode {
+	for k, v := range softModeNames {
+		if v == mode {
+			return k
+		}
+	}
+	return gachav1.SoftPityMode_SOFT_PITY_MODE_UNSPECIFIED
+}
+
+func easingToProto(e string) gachav1.Easing {
+	for k, v := range easingNames {
+		if v == e {
+			return k
+		}
+	}
+	return gachav1.Easing_EASING_UNSPECIFIED
+}
+
+// overrideSet collects request-level overrides shared by most RPCs.
+type overrideSet struct {
+	pBase   float64
+	pity    int32
+	soft    *gachav1.SoftPityOverrides
+	banner  *gachav1.BannerOverrides
+	cushion int32
+	spark   int32 // spark points already held
+}
+
+func (s overrideSet) toOverrides() game.Overrides {
+	var o game.Overrides
+	if s.pBase != 0 {
+		v := s.pBase
+		o.PBase = &v
+	}
+	if s.pity != 0 {
+		v := int(s.pity)
+		o.Pity = &v
+	}
+	if s.cushion != 0 {
+		v := int(s.cushion)
+		o.Cushion = &v
+	}
+	if s.spark != 0 {
+		v := int(s.spark)
+		o.SparkPoints = &v
+	}
+	if soft := s.soft; soft != nil {
+		if name, ok := softModeNames[soft.GetMode()]; ok {
+			o.SoftMode = &name
+		}
+		if soft.GetStartAt() != 0 {
+			v := int(soft.GetStartAt())
+			o.StartAt = &v
+		}
+		if soft.GetStartPct() != 0 {
+			v := soft.GetStartPct()
+			o.StartPct = &v
+		}
+		if soft.GetTarget() != 0 {
+			v := soft.GetTarget()
+			o.Target = &v
+		}
+		if soft.GetIncrement() != 0 {
+			v := soft.GetIncrement()
+			o.Increment = &v
+		}
+		if name, ok := easingNames[soft.GetEasing()]; ok {
+			o.Easing = &name
+		}
+	}
+	if b := s.banner; b != nil {
+		if len(b.GetOffProbs()) > 0 {
+			v := append([]float64(nil), b.GetOffProbs()...)
+			o.OffProbs = &v
+		}
+		if b.GetMaxOff() > 0 {
+			v := int(b.GetMaxOff())
+			o.MaxOff = &v
+		}
+	}
+	return o
+}
+
+func goalFromProto(g gachav1.TrialGoal) (gacha.TrialGoal, bool) {
+	switch g {
+	case gachav1.TrialGoal_TRIAL_GOAL_UNSPECIFIED, gachav1.TrialGoal_TRIAL_GOAL_FIRST_UP:
+		return gacha.GoalFirstUP, true
+	case gachav1.TrialGoal_TRIAL_GOAL_FIRST_HIT:
+		return gacha.GoalFirstHit, true
+	case gachav1.TrialGoal_TRIAL_GOAL_FIXED_BUDGET:
+		return gacha.GoalFixedBudget, true
+	case gachav1.TrialGoal_TRIAL_GOAL_NTH_UP:
+		return gacha.GoalNthUP, true
+	}
+	return "", false
+}
+
+// toStatus maps engine/config errors to gRPC status codes.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	var verr *game.ValidationError
+	switch {
+	case errors.As(err, &verr),
+		errors.Is(err, gacha.ErrInvalidProb),
+		errors.Is(err, gacha.ErrSoftPityConfig),
+		errors.Is(err, gacha.ErrTierConfig),
+		errors.Is(err, gacha.ErrPathConfig),
+		errors.Is(err, gacha.ErrSparkConfig),
+		errors.Is(err, gacha.ErrRefundConfig),
+		errors.Is(err, gacha.ErrSelection),
+		errors.Is(err, gacha.ErrExactUnsupported),
+		errors.Is(err, gacha.ErrNoLegs),
+		errors.Is(err, state.ErrInvalidKey),
+		errors.Is(err, game.ErrInvalidID):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,879 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gachav1 "github.com/xtding233/gacha-backend/gen/gacha/v1"
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/history"
+	"github.com/xtding233/gacha-backend/internal/luck"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+// Request size limits.
+const (
+	maxDrawN   = 10_000      // draws per DrawN* request
+	maxTrials  = 1_000_000   // Monte Carlo trials per Simulate or LuckPercentile request
+	maxBudgetN = 10_000      // draws per FIXED_BUDGET trial
+	maxCopies  = 100         // UP copies per NTH_UP trial or COMBINED leg
+	maxDraws   = 100_000_000 // trials × budget_n per FIXED_BUDGET request
+	maxWaits   = 10_000_000  // trials × UP copies of all legs per NTH_UP or COMBINED request
+	maxLegs    = 10          // legs per COMBINED request
+	maxHistory = 100_000     // pulls per LuckPercentile request
+	maxQuants  = 100         // extra quantiles per Simulate request
+	maxResamp  = 10_000      // bootstrap resamples per Simulate request
+)
+
+// GachaServer implements gachav1.GachaServiceServer
+type GachaServer struct {
+	gachav1.UnimplementedGachaServiceServer
+
+	loader   *game.Loader
+	resolver game.Resolver
+	store    state.Store        // per-player pity state; nil disables player_id
+	rng      gacha.RandomSource // nil => DefaultRNG()
+	events   eventlog.Log       // append-only draw log; nil disables logging
+}
+
+// NewGachaServer creates a GachaServer backed by the given loader and player state store.
+func NewGachaServer(l *game.Loader, store state.Store) *GachaServer {
+	return &GachaServer{loader: l, resolver: game.NewResolver(l), store: store}
+}
+
+// resolve validates the ref and resolves effective params with overrides.
+func (s *GachaServer) resolve(ref *gachav1.GameRef, o overrideSet) (game.RawConfig, game.EngineParams, error) {
+	if ref.GetGame() == "" {
+		return game.RawConfig{}, game.EngineParams{}, status.Error(codes.InvalidArgument, "ref.game is required")
+	}
+	raw, ep, err := s.resolver.Resolve(ref.GetGame(), ref.GetPool(), o.toOverrides())
+	if err != nil {
+		return game.RawConfig{}, game.EngineParams{}, toStatus(err)
+	}
+	return raw, ep, nil
+}
+
+func checkN(n int32) error {
+	if n <= 0 || n > maxDrawN {
+		return status.Errorf(codes.InvalidArgument, "n must be in [1, %d]", maxDrawN)
+	}
+	return nil
+}
+
+func (s *GachaServer) Resolve(ctx context.Context, req *gachav1.ResolveRequest) (*gachav1.ResolveResponse, error) {
+	raw, ep, err := s.resolve(req.GetRef(), overrideSet{
+		pBase:   req.GetPBase(),
+		pity:    req.GetPity(),
+		soft:    req.GetSoft(),
+		banner:  req.GetBanner(),
+		cushion: req.GetCushion(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &gachav1.ResolveResponse{
+		PBase:            ep.PBase,
+		Pity:             int32(ep.Pity),
+		SoftMode:         softModeToProto(ep.SoftMode),
+		Easing:           easingToProto(ep.Easing),
+		OffProbs:         ep.OffProbs,
+		MaxOff:           int32(ep.MaxOff),
+		Cushion:          int32(ep.Cushion),
+		EffectiveVersion: ep.Version,
+		Notes:            raw.Notes,
+		PityGroup:        ep.PityGroup,
+	}
+	if ep.StartAt != nil {
+		resp.StartAt = int32(*ep.StartAt)
+	}
+	if ep.StartPct != nil {
+		resp.StartPct = *ep.StartPct
+	}
+	if ep.Target != nil {
+		resp.Target = *ep.Target
+	}
+	if ep.Increment != nil {
+		resp.Increment = *ep.Increment
+	}
+	return resp, nil
+}
+
+func (s *GachaServer) DrawN(ctx context.Context, req *gachav1.DrawNRequest) (*gachav1.DrawNResponse, error) {
+	if err := checkN(req.GetN()); err != nil {
+		return nil, err
+	}
+	_, ep, err := s.resolve(req.GetRef(), overrideSet{pBase: req.GetPBase()})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]bool, req.GetN())
+	for i := range hits {
+		hit, err := gacha.Draw(ep.PBase, s.rng)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		hits[i] = hit
+	}
+	return &gachav1.DrawNResponse{Hits: hits}, nil
+}
+
+func (s *GachaServer) DrawNPity(ctx context.Context, req *gachav1.DrawNPityRequest) (*gachav1.DrawNPityResponse, error) {
+	if err := checkN(req.GetN()); err != nil {
+		return nil, err
+	}
+	_, ep, err := s.resolve(req.GetRef(), overrideSet{
+		pBase:   req.GetPBase(),
+		pity:    req.GetPity(),
+		soft:    req.GetSoft(),
+		cushion: req.GetCushion(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	soft, err := gacha.NewSoftFromParams(game.ToSimParams(ep), s.rng)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	hits := make([]bool, req.GetN())
+	for i := range hits {
+		hit, err := soft.Draw(ep.PBase)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		hits[i] = hit
+	}
+	return &gachav1.DrawNPityResponse{Hits: hits, Count: int32(soft.Count)}, nil
+}
+
+func (s *GachaServer) DrawNBanner(ctx context.Context, req *gachav1.DrawNBannerRequest) (*gachav1.DrawNBannerResponse, error) {
+	if err := checkN(req.GetN()); err != nil {
+		return nil, err
+	}
+	o := overrideSet{
+		pBase:   req.GetPBase(),
+		pity:    req.GetPity(),
+		soft:    req.GetSoft(),
+		banner:  req.GetBanner(),
+		cushion: req.GetCushion(),
+	}
+	if req.GetPlayerId() != "" && o.toOverrides() != (game.Overrides{}) {
+		// the batch's state is saved as the player's own; it must come from the pool config
+		return nil, status.Error(codes.InvalidArgument, "overrides cannot be combined with player_id")
+	}
+	_, ep, err := s.resolve(req.GetRef(), o)
+	if err != nil {
+		return nil, err
+	}
+	clientSeed := req.GetClientSeed()
+	if clientSeed != "" && req.GetPlayerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_seed requires player_id")
+	}
+	var events []eventlog.Event
+	build := func(rng gacha.RandomSource) (*gacha.TieredSystem, error) {
+		var rec *gacha.RecordingRNG
+		if s.events != nil {
+			rec = gacha.NewRecordingRNG(rng)
+			rng = rec
+		}
+		ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), rng)
+		if err != nil {
+			return nil, err
+		}
+		if !req.GetMulti() {
+			ts.MultiFloor = 0
+		}
+		if rec != nil {
+			ts.Trace = func(tr gacha.PullTrace) { events = append(events, eventlog.FromTrace(tr, rec.Take())) }
+		}
+		return ts, nil
+	}
+	// logDraws appends the batch's pulls to the event log, if one is configured.
+	logDraws := func(player, choice string) error {
+		if s.events == nil {
+			return nil
+		}
+		var selected map[int]string
+		if choice != "" {
+			selected = map[int]string{ep.Selector.Rarity: choice}
+		}
+		for i := range events {
+			ev := &events[i]
+			ev.Player, ev.Game, ev.Pool, ev.Version = player, req.GetRef().GetGame(), req.GetRef().GetPool(), ep.Version
+			ev.Overrides, ev.Selected = o.toOverrides(), selected
+		}
+		return s.events.Append(ctx, events...)
+	}
+	ts, err := build(s.rng)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if ts.Tiers[0].Banner == nil {
+		return nil, status.Error(codes.FailedPrecondition, "banner.off_probs is not configured for this pool")
+	}
+	spark, err := gacha.NewSparkFromParams(game.ToSimParams(ep))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	batch := 1
+	if req.GetMulti() {
+		if ep.Multi == nil {
+			return nil, status.Error(codes.FailedPrecondition, "multi is not configured for this pool")
+		}
+		batch = ep.Multi.Size
+		if int(req.GetN())%batch != 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "n must be a multiple of multi.size (%d)", batch)
+		}
+	}
+	results := make([]*gachav1.BannerOutcome, req.GetN())
+	drawAll := func() error {
+		outs, err := ts.DrawBatch(len(results), batch)
+		if err != nil {
+			return err
+		}
+		for i, out := range outs {
+			spark.Earn()
+			results[i] = &gachav1.BannerOutcome{
+				Hit:      out.Rarity == ts.Tiers[0].Rarity,
+				IsUp:     out.IsUp,
+				Rarity:   int32(out.Rarity),
+				ItemId:   out.Item,
+				OnTarget: out.OnTarget,
+			}
+		}
+		return nil
+	}
+	var proof *gachav1.FairProof
+	if player := req.GetPlayerId(); player != "" {
+		if s.store == nil {
+			return nil, status.Error(codes.FailedPrecondition, "player state store is not configured")
+		}
+		var choice string
+		err = s.store.Update(ctx, playerKey(req.GetRef(), ep, player), func(st *state.PlayerState) error {
+			rotate(st, req.GetRef(), ep)
+			if clientSeed != "" {
+				if st.Fair == nil {
+					return status.Error(codes.FailedPrecondition, "no committed server seed; call GetFairCommitment first")
+				}
+				var err error
+				if ts, err = build(gacha.NewFairRNG(st.Fair.ServerSeed, clientSeed, st.Fair.Nonce)); err != nil {
+					return err
+				}
+				proof = fairProof(*st, clientSeed, batch, ep.Version)
+			}
+			st.Apply(ts)
+			var err error
+			if choice, err = selectFor(ts, ep, st.Selected, true); err != nil {
+				return err
+			}
+			if spark != nil {
+				spark.Points = st.SparkPoints
+			}
+			if err := drawAll(); err != nil {
+				return err
+			}
+			st.Capture(ts)
+			if spark != nil {
+				st.SparkPoints = spark.Points
+			}
+			if proof != nil {
+				if err := st.Reveal(); err != nil {
+					return err
+				}
+				proof.NextCommitment = gacha.Commitment(st.Fair.ServerSeed)
+			}
+			return nil
+		})
+		// only draws whose state was saved are logged, so replay follows the store
+		if err == nil {
+			err = logDraws(player, choice)
+		}
+	} else {
+		var choice string
+		if choice, err = selectFor(ts, ep, nil, false); err != nil {
+			return nil, toStatus(err)
+		}
+		if err = drawAll(); err == nil {
+			err = logDraws("", choice)
+		}
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	top := ts.Tiers[0]
+	// batch state reported is the top tier's
+	resp := &gachav1.DrawNBannerResponse{
+		Results:        results,
+		Count:          int32(top.Soft.Count),
+		GuaranteedNext: top.Banner.GuaranteedNext,
+		OffStreak:      int32(top.Banner.OffStreak),
+	}
+	if top.Path != nil {
+		resp.FatePoints = int32(top.Path.FatePoints)
+	}
+	if spark != nil {
+		resp.SparkPoints = int32(spark.Points)
+	}
+	resp.Fair = proof
+	return resp, nil
+}
+
+// fairProof discloses the seeds, the pre-batch state, the multi-pull size and
+// the config version of a fair batch about to be drawn from st's committed
+// seed; see fair.Replay.
+func fairProof(st state.PlayerState, clientSeed string, batch int, version string) *gachav1.FairProof {
+	proof := &gachav1.FairProof{
+		Commitment: gacha.Commitment(st.Fair.ServerSeed),
+		ServerSeed: st.Fair.ServerSeed,
+		ClientSeed: clientSeed,
+		Nonce:      st.Fair.Nonce,
+		Batch:      int32(batch),
+		Version:    version,
+	}
+	for r, t := range st.Tiers {
+		proof.Start = append(proof.Start, &gachav1.TierSnapshot{
+			Rarity:         int32(r),
+			Count:          int32(t.Count),
+			OffStreak:      int32(t.OffStreak),
+			GuaranteedNext: t.GuaranteedNext,
+			FatePoints:     int32(t.FatePoints),
+			Radiance:       int32(t.Radiance),
+		})
+	}
+	slices.SortFunc(proof.Start, func(a, b *gachav1.TierSnapshot) int { return int(b.Rarity - a.Rarity) })
+	if len(st.Selected) > 0 {
+		proof.Selected = make(map[int32]string, len(st.Selected))
+		for r, id := range st.Selected {
+			proof.Selected[int32(r)] = id
+		}
+	}
+	return proof
+}
+
+func (s *GachaServer) GetFairCommitment(ctx context.Context, req *gachav1.GetFairCommitmentRequest) (*gachav1.GetFairCommitmentResponse, error) {
+	if req.GetPlayerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+	if s.store == nil {
+		return nil, status.Error(codes.FailedPrecondition, "player state store is not configured")
+	}
+	_, ep, err := s.resolve(req.GetRef(), overrideSet{})
+	if err != nil {
+		return nil, err
+	}
+	resp := &gachav1.GetFairCommitmentResponse{}
+	err = s.store.Update(ctx, playerKey(req.GetRef(), ep, req.GetPlayerId()), func(st *state.PlayerState) error {
+		seed, err := st.Commit()
+		if err != nil {
+			return err
+		}
+		resp.Commitment = gacha.Commitment(seed.ServerSeed)
+		resp.Nonce = seed.Nonce
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// playerKey returns the state key of player on ref: its pity group, or the pool itself.
+func playerKey(ref *gachav1.GameRef, ep game.EngineParams, player string) state.Key {
+	family := ep.PityGroup
+	if family == "" {
+		family = ref.GetPool()
+	}
+	return state.Key{Player: player, Game: ref.GetGame(), Family: family}
+}
+
+// rotate moves st to ref's pool with the pool's carry-over rules.
+func rotate(st *state.PlayerState, ref *gachav1.GameRef, ep game.EngineParams) {
+	st.Rotate(ref.GetPool(), ep.CarryGuarantee, ep.CarryOffStreak, ep.Spark != nil && ep.Spark.Carry)
+}
+
+// selectFor applies a selector banner's choice to ts: the player's selection,
+// else the pool default. A player without either must choose first; anonymous
+// draws then keep the whole featured roster. It returns the choice applied.
+func selectFor(ts *gacha.TieredSystem, ep game.EngineParams, selected map[int]string, player bool) (string, error) {
+	sel := ep.Selector
+	if sel == nil {
+		return "", nil
+	}
+	choice := selected[sel.Rarity]
+	if choice == "" {
+		choice = sel.Default
+	}
+	if choice == "" {
+		if player {
+			return "", status.Error(codes.FailedPrecondition, "no featured item selected; call SetSelection first")
+		}
+		return "", nil
+	}
+	return choice, ts.Select(sel.Rarity, choice)
+}
+
+func (s *GachaServer) SetSelection(ctx context.Context, req *gachav1.SetSelectionRequest) (*gachav1.SetSelectionResponse, error) {
+	if req.GetPlayerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+	if s.store == nil {
+		return nil, status.Error(codes.FailedPrecondition, "player state store is not configured")
+	}
+	_, ep, err := s.resolve(req.GetRef(), overrideSet{})
+	if err != nil {
+		return nil, err
+	}
+	sel := ep.Selector
+	if sel == nil {
+		return nil, status.Error(codes.FailedPrecondition, "selector is not configured for this pool")
+	}
+	if !slices.Contains(sel.Choices, req.GetItemId()) {
+		return nil, status.Errorf(codes.InvalidArgument, "item %q is not a selectable featured item", req.GetItemId())
+	}
+	resp := &gachav1.SetSelectionResponse{ItemId: req.GetItemId()}
+	err = s.store.Update(ctx, playerKey(req.GetRef(), ep, req.GetPlayerId()), func(st *state.PlayerState) error {
+		rotate(st, req.GetRef(), ep)
+		resp.PreviousItemId = st.Selected[sel.Rarity]
+		resp.Switched = st.Select(sel.Rarity, req.GetItemId(), sel.KeepPity, sel.KeepFate)
+		t := st.Tiers[sel.Rarity]
+		resp.Count = int32(t.Count)
+		resp.GuaranteedNext = t.GuaranteedNext
+		resp.FatePoints = int32(t.FatePoints)
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp.PityKept = !resp.Switched || sel.KeepPity
+	resp.FateKept = !resp.Switched || sel.KeepFate
+	return resp, nil
+}
+
+func (s *GachaServer) Simulate(ctx context.Context, req *gachav1.SimulateRequest) (*gachav1.SimulateResponse, error) {
+	if !req.GetExact() && (req.GetTrials() <= 0 || req.GetTrials() > maxTrials) {
+		return nil, status.Errorf(codes.InvalidArgument, "trials must be in [1, %d]", maxTrials)
+	}
+	if err := checkOutput(req); err != nil {
+		return nil, err
+	}
+	if err := checkUncertainty(req); err != nil {
+		return nil, err
+	}
+	if req.GetGoal() == gachav1.TrialGoal_TRIAL_GOAL_COMBINED {
+		return s.simulateCombined(ctx, req)
+	}
+	goal, ok := goalFromProto(req.GetGoal())
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown goal %v", req.GetGoal())
+	}
+	var budget *gacha.SimBudget
+	switch goal {
+	case gacha.GoalFixedBudget:
+		if req.GetBudgetN() <= 0 || req.GetBudgetN() > maxBudgetN {
+			return nil, status.Errorf(codes.InvalidArgument, "budget_n must be in [1, %d] for FIXED_BUDGET", maxBudgetN)
+		}
+		if err := checkWork(req, int64(req.GetBudgetN()), "budget_n", maxDraws); err != nil {
+			return nil, err
+		}
+		budget = &gacha.SimBudget{NumDraws: int(req.GetBudgetN())}
+	case gacha.GoalNthUP:
+		if err := checkCopies(req.GetCopies()); err != nil {
+			return nil, err
+		}
+		if err := checkWork(req, int64(req.GetCopies()), "copies", maxWaits); err != nil {
+			return nil, err
+		}
+		budget = &gacha.SimBudget{Copies: int(req.GetCopies())}
+	}
+	_, ep, err := s.resolve(req.GetRef(), overrideSet{
+		pBase:   req.GetPBase(),
+		pity:    req.GetPity(),
+		soft:    req.GetSoft(),
+		banner:  req.GetBanner(),
+		cushion: req.GetCushion(),
+		spark:   req.GetSparkPoints(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sim := game.ToSimParams(ep)
+	sim.TargetRarity = int(req.GetTargetRarity())
+	if err := setReconvert(&sim, req.GetReconvertRefunds()); err != nil {
+		return nil, err
+	}
+	if err := setMulti(&sim, req.GetMulti()); err != nil {
+		return nil, err
+	}
+	if req.GetExact() {
+		// a lower tier never changes the top tier's odds (see gacha.TieredSystem.Probs),
+		// so the chain drops them; it refuses a target below the top tier
+		sim.Tiers = nil
+		d, err := gacha.RunExact(sim, goal, budget)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		resp := &gachav1.SimulateResponse{
+			Mean:             d.Mean,
+			Variance:         d.Var,
+			StdDev:           d.StdDev,
+			P50:              d.P50,
+			P90:              d.P90,
+			P99:              d.P99,
+			EffectiveVersion: ep.Version,
+			Pmf:              d.PMF,
+		}
+		setOutput(resp, req, d, func(qs ...float64) []float64 {
+			out := make([]float64, len(qs))
+			for i, q := range qs {
+				out[i] = d.Quantile(q)
+			}
+			return out
+		})
+		return resp, nil
+	}
+	seed := req.GetSeed()
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	opt := mcOptions(req, seed)
+	st, err := gacha.RunMonteCarloParallel(ctx, sim, goal, int(req.GetTrials()), budget, opt)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gachav1.SimulateResponse{
+		Mean:             st.Mean,
+		Variance:         st.Var,
+		StdDev:           st.StdDev,
+		P50:              st.P50,
+		P90:              st.P90,
+		P99:              st.P99,
+		EffectiveVersion: ep.Version,
+		Seed:             seed,
+	}
+	setOutput(resp, req, st.Empirical(), st.Quantiles)
+	setUncertainty(resp, req, st, opt)
+	return resp, nil
+}
+
+// checkOutput validates the distribution output a Simulate request asks for.
+func checkOutput(req *gachav1.SimulateRequest) error {
+	if req.GetBucketWidth() < 0 {
+		return status.Error(codes.InvalidArgument, "bucket_width must be >= 0")
+	}
+	if len(req.GetQuantiles()) > maxQuants {
+		return status.Errorf(codes.InvalidArgument, "at most %d quantiles", maxQuants)
+	}
+	for _, q := range req.GetQuantiles() {
+		if !(q >= 0 && q <= 1) {
+			return status.Errorf(codes.InvalidArgument, "quantile %v is not in [0, 1]", q)
+		}
+	}
+	return nil
+}
+
+// checkUncertainty validates the uncertainty and adaptive fields of req.
+func checkUncertainty(req *gachav1.SimulateRequest) error {
+	adaptive := req.GetAdaptive() != gachav1.Statistic_STATISTIC_UNSPECIFIED
+	if req.GetExact() && (req.GetUncertainty() || adaptive) {
+		return status.Error(codes.InvalidArgument, "exact results have no sampling error")
+	}
+	if c := req.GetConfidence(); !(c >= 0 && c < 1) {
+		return status.Error(codes.InvalidArgument, "confidence must be in [0, 1); 0 means 0.95")
+	}
+	if req.GetResamples() < 0 || req.GetResamples() > maxResamp {
+		return status.Errorf(codes.InvalidArgument, "resamples must be in [0, %d]", maxResamp)
+	}
+	if !adaptive {
+		return nil
+	}
+	if _, ok := statisticNames[req.GetAdaptive()]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown statistic %v", req.GetAdaptive())
+	}
+	if !(req.GetTolerance() > 0) {
+		return status.Error(codes.InvalidArgument, "tolerance must be > 0 in adaptive mode")
+	}
+	if m := req.GetMaxTrials(); m != 0 && (m < req.GetTrials() || m > maxTrials) {
+		return status.Errorf(codes.InvalidArgument, "max_trials must be in [trials, %d]", maxTrials)
+	}
+	return nil
+}
+
+// mcOptions builds the Monte Carlo options of req under seed.
+func mcOptions(req *gachav1.SimulateRequest, seed uint64) gacha.MCOptions {
+	opt := gacha.MCOptions{Seed: seed}
+	if q, ok := statisticNames[req.GetAdaptive()]; ok {
+		opt.Adaptive = &gacha.Adaptive{
+			Quantity:  q,
+			Tolerance: req.GetTolerance(),
+			MaxTrials: int(req.GetMaxTrials()),
+			Level:     req.GetConfidence(),
+			Resamples: int(req.GetResamples()),
+		}
+		if opt.Adaptive.MaxTrials == 0 {
+			opt.Adaptive.MaxTrials = maxTrials
+		}
+	}
+	return opt
+}
+
+// setUncertainty reports the trials run and, when requested, the bootstrap
+// uncertainty of st; in adaptive mode it also tells whether the run converged.
+func setUncertainty(resp *gachav1.SimulateResponse, req *gachav1.SimulateRequest, st gacha.Stats, opt gacha.MCOptions) {
+	resp.Trials = int32(len(st.Samples))
+	if !req.GetUncertainty() && opt.Adaptive == nil {
+		return
+	}
+	u := st.Bootstrap(int(req.GetResamples()), req.GetConfidence(), opt.Seed)
+	estimate := func(iv gacha.Interval) *gachav1.Estimate {
+		return &gachav1.Estimate{StdErr: iv.StdErr, Low: iv.Low, High: iv.High}
+	}
+	resp.Uncertainty = &gachav1.Uncertainty{
+		Mean:       estimate(u.Mean),
+		Variance:   estimate(u.Var),
+		StdDev:     estimate(u.StdDev),
+		P50:        estimate(u.P50),
+		P90:        estimate(u.P90),
+		P99:        estimate(u.P99),
+		Confidence: u.Level,
+		Resamples:  int32(u.Resamples),
+	}
+	if ad := opt.Adaptive; ad != nil {
+		iv, _ := u.Of(ad.Quantity)
+		resp.Converged = iv.HalfWidth() <= ad.Tolerance
+	}
+}
+
+// setOutput fills the histogram, CDF and quantiles req asks for from d;
+// quantiles answers the requested quantiles in the mode's own convention.
+func setOutput(resp *gachav1.SimulateResponse, req *gachav1.SimulateRequest, d gacha.Distribution, quantiles func(qs ...float64) []float64) {
+	if w := int(req.GetBucketWidth()); w > 0 {
+		for _, b := range d.Histogram(w) {
+			resp.Histogram = append(resp.Histogram, &gachav1.HistogramBucket{
+				From: int32(b.From),
+				To:   int32(b.To),
+				Prob: b.Prob,
+				Cum:  b.Cum,
+			})
+		}
+	}
+	if req.GetCdf() {
+		resp.Cdf = d.CDF
+	}
+	if qs := req.GetQuantiles(); len(qs) > 0 {
+		for i, v := range quantiles(qs...) {
+			resp.Quantiles = append(resp.Quantiles, &gachav1.QuantileValue{Q: qs[i], Value: v})
+		}
+	}
+}
+
+func checkCopies(n int32) error {
+	if n <= 0 || n > maxCopies {
+		return status.Errorf(codes.InvalidArgument, "copies must be in [1, %d]", maxCopies)
+	}
+	return nil
+}
+
+// checkWork caps the work of a Monte Carlo request: its most trials (max_trials
+// in adaptive mode) times the units of work, e.g. draws, each trial asks for.
+func checkWork(req *gachav1.SimulateRequest, perTrial int64, what string, limit int64) error {
+	trials := int64(req.GetTrials())
+	if req.GetAdaptive() != gachav1.Statistic_STATISTIC_UNSPECIFIED {
+		m := int64(req.GetMaxTrials())
+		if m == 0 {
+			m = maxTrials
+		}
+		trials = max(trials, m)
+	}
+	if trials*perTrial > limit {
+		return status.Errorf(codes.InvalidArgument, "trials × %s must be at most %d", what, limit)
+	}
+	return nil
+}
+
+// setReconvert keeps the pool's refunds only when the request spends them on
+// draws; otherwise they cannot change any goal metric.
+func setReconvert(sim *gacha.SimParams, reconvert bool) error {
+	if !reconvert {
+		sim.Refund = nil
+		return nil
+	}
+	if sim.Refund == nil || sim.Refund.PerDraw <= 0 {
+		return status.Error(codes.FailedPrecondition, "refunds.amounts and refunds.per_draw are not configured for this pool")
+	}
+	sim.Refund.Reconvert = true
+	return nil
+}
+
+// setMulti keeps the pool's multi-pull only when the request pulls in multis.
+func setMulti(sim *gacha.SimParams, multi bool) error {
+	if !multi {
+		sim.Multi = nil
+		return nil
+	}
+	if sim.Multi == nil {
+		return status.Error(codes.FailedPrecondition, "multi is not configured for this pool")
+	}
+	return nil
+}
+
+// simulateCombined runs a COMBINED goal: every leg is resolved on its own
+// ref and the trial metric is the total number of draws across all legs.
+func (s *GachaServer) simulateCombined(ctx context.Context, req *gachav1.SimulateRequest) (*gachav1.SimulateResponse, error) {
+	if req.GetExact() {
+		return nil, status.Error(codes.InvalidArgument, "exact mode does not support COMBINED")
+	}
+	if len(req.GetLegs()) > maxLegs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d legs", maxLegs)
+	}
+	var copies int64
+	for _, l := range req.GetLegs() {
+		if err := checkCopies(l.GetCopies()); err != nil {
+			return nil, err
+		}
+		copies += int64(l.GetCopies())
+	}
+	if err := checkWork(req, copies, "copies of all legs", maxWaits); err != nil {
+		return nil, err
+	}
+	legs := make([]gacha.GoalLeg, 0, len(req.GetLegs()))
+	versions := make([]string, 0, len(req.GetLegs()))
+	for _, l := range req.GetLegs() {
+		_, ep, err := s.resolve(l.GetRef(), overrideSet{cushion: l.GetCushion(), spark: l.GetSparkPoints()})
+		if err != nil {
+			return nil, err
+		}
+		sim := game.ToSimParams(ep)
+		sim.TargetRarity = int(req.GetTargetRarity())
+		if err := setReconvert(&sim, req.GetReconvertRefunds()); err != nil {
+			return nil, err
+		}
+		if err := setMulti(&sim, req.GetMulti()); err != nil {
+			return nil, err
+		}
+		legs = append(legs, gacha.GoalLeg{Params: sim, Copies: int(l.GetCopies())})
+		versions = append(versions, ep.Version)
+	}
+	seed := req.GetSeed()
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	opt := mcOptions(req, seed)
+	st, err := gacha.RunMonteCarloCombined(ctx, legs, int(req.GetTrials()), opt)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gachav1.SimulateResponse{
+		Mean:             st.Mean,
+		Variance:         st.Var,
+		StdDev:           st.StdDev,
+		P50:              st.P50,
+		P90:              st.P90,
+		P99:              st.P99,
+		EffectiveVersion: strings.Join(versions, ","),
+		Seed:             seed,
+	}
+	setOutput(resp, req, st.Empirical(), st.Quantiles)
+	setUncertainty(resp, req, st, opt)
+	return resp, nil
+}
+
+// LuckPercentile rebuilds the pity state before every pull of a history the
+// way history imports do and ranks each wait against the engine's distribution.
+func (s *GachaServer) LuckPercentile(ctx context.Context, req *gachav1.LuckPercentileRequest) (*gachav1.LuckPercentileResponse, error) {
+	if req.GetRef().GetGame() == "" {
+		return nil, status.Error(codes.InvalidArgument, "ref.game is required")
+	}
+	if len(req.GetPulls()) > maxHistory {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d pulls", maxHistory)
+	}
+	if req.GetTrials() < 0 || req.GetTrials() > maxTrials {
+		return nil, status.Errorf(codes.InvalidArgument, "trials must be in [0, %d]", maxTrials)
+	}
+	var goal gacha.TrialGoal
+	switch req.GetGoal() {
+	case gachav1.TrialGoal_TRIAL_GOAL_UNSPECIFIED, gachav1.TrialGoal_TRIAL_GOAL_FIRST_UP:
+		goal = gacha.GoalFirstUP
+	case gachav1.TrialGoal_TRIAL_GOAL_FIRST_HIT:
+		goal = gacha.GoalFirstHit
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "goal must be FIRST_UP or FIRST_HIT, got %v", req.GetGoal())
+	}
+	// records carry the pool id as their banner type; they are in order already
+	recs := make([]history.Record, len(req.GetPulls()))
+	pools := make(map[string]string)
+	for i, p := range req.GetPulls() {
+		pool := p.GetPool()
+		if pool == "" {
+			pool = req.GetRef().GetPool()
+		}
+		recs[i] = history.Record{Banner: pool, Item: p.GetItemId(), Rarity: int(p.GetRarity())}
+		pools[pool] = pool
+	}
+	pulls, rep, err := history.Import(s.resolver, recs, history.Options{Game: req.GetRef().GetGame(), Pools: pools, Fresh: req.GetFresh()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	seed := req.GetSeed()
+	if seed == 0 {
+		seed = randomSeed()
+	}
+	lr, err := luck.Rank(ctx, s.resolver, pulls, luck.Options{
+		Rarity: int(req.GetTargetRarity()),
+		Goal:   goal,
+		Trials: int(req.GetTrials()),
+		Budget: maxTrials,
+		Seed:   seed,
+	})
+	if errors.Is(err, luck.ErrNoRoster) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &gachav1.LuckPercentileResponse{
+		Completed:    int32(lr.Completed),
+		Draws:        int32(lr.Draws),
+		Expected:     lr.Expected,
+		Better:       lr.Better,
+		Worse:        lr.Worse,
+		Luck:         lr.Luck,
+		MeanLuck:     lr.MeanLuck,
+		Inconsistent: int32(rep.Inconsistent),
+		Seed:         seed,
+	}
+	for _, n := range rep.Unmapped {
+		resp.Unmapped += int32(n)
+	}
+	for _, w := range lr.Waits {
+		resp.Waits = append(resp.Waits, &gachav1.LuckWait{
+			Pool:   w.Pool,
+			End:    int32(w.End),
+			Draws:  int32(w.Draws),
+			Open:   w.Open,
+			Exact:  w.Exact,
+			Mean:   w.Mean,
+			Better: w.Better,
+			Worse:  w.Worse,
+			Luck:   w.Luck,
+		})
+	}
+	return resp, nil
+}
+
+// randomSeed draws a fresh master seed for Monte Carlo runs.
+func randomSeed() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return uint64(time.Now().UnixNano())
+	}
+	return binary.BigEndian.Uint64(b[:])
+}
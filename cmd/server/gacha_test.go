@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gachav1 "github.com/xtding233/gacha-backend/gen/gacha/v1"
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/fair"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+// newTestServer serves configs written under games/ in a temp dir.
+func newTestServer(t *testing.T, files map[string]string) *GachaServer {
+	t.Helper()
+	dir := t.TempDir()
+	for path, text := range files {
+		path = filepath.Join(dir, "games", path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewGachaServer(game.NewLoader(dir), nil)
+}
+
+func TestDrawNBannerReportsOnTarget(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"default.yaml": `
+draw:
+  pity: 10
+  p_base: 0.3
+banner:
+  off_probs: [0.25]
+  max_off: 1
+`,
+		"genshin/pools/weapon.yaml": `
+banner:
+  path:
+    target: jade_cutter
+items:
+  5:
+    featured: [{id: mistsplitter}, {id: jade_cutter}]
+    standard: [{id: wolfs_gravestone}]
+`,
+	})
+	s.rng = gacha.NewSeededRNG(4)
+	resp, err := s.DrawNBanner(context.Background(), &gachav1.DrawNBannerRequest{
+		Ref: &gachav1.GameRef{Game: "genshin", Pool: "weapon"},
+		N:   500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	charted := 0
+	for i, r := range resp.GetResults() {
+		if r.GetOnTarget() != (r.GetItemId() == "jade_cutter") {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+		if r.GetOnTarget() {
+			charted++
+		}
+	}
+	if charted == 0 {
+		t.Fatal("no charted item in 500 pulls")
+	}
+}
+
+// failingStore runs updates but never saves them.
+type failingStore struct{ *state.MemoryStore }
+
+func (f failingStore) Update(ctx context.Context, key state.Key, fn func(*state.PlayerState) error) error {
+	var st state.PlayerState
+	if err := fn(&st); err != nil {
+		return err
+	}
+	return errors.New("disk full")
+}
+
+func TestDrawNBannerPlayerState(t *testing.T) {
+	s := newTestServer(t, map[string]string{"default.yaml": `
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+`})
+	store := state.NewMemoryStore()
+	s.store = store
+	ctx := context.Background()
+	ref := &gachav1.GameRef{Game: "g", Pool: "char"}
+
+	// overrides would be saved as the player's real state
+	for name, req := range map[string]*gachav1.DrawNBannerRequest{
+		"p_base":  {PBase: 1},
+		"pity":    {Pity: 1},
+		"cushion": {Cushion: 89},
+		"soft":    {Soft: &gachav1.SoftPityOverrides{StartAt: 1}},
+		"banner":  {Banner: &gachav1.BannerOverrides{OffProbs: []float64{0.01}}},
+	} {
+		req.Ref, req.N, req.PlayerId = ref, 10, "p1"
+		if _, err := s.DrawNBanner(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%s override with player_id: %v", name, err)
+		}
+	}
+	key := state.Key{Player: "p1", Game: "g", Family: "char"}
+	if st, _ := store.Load(ctx, key); st.Tiers != nil {
+		t.Fatalf("rejected batches saved state: %+v", st)
+	}
+
+	// draws are logged only once their state is saved
+	log := eventlog.NewMemoryLog()
+	s.events = log
+	s.store = failingStore{store}
+	if _, err := s.DrawNBanner(ctx, &gachav1.DrawNBannerRequest{Ref: ref, N: 10, PlayerId: "p1"}); status.Code(err) != codes.Internal {
+		t.Fatalf("failed save: %v", err)
+	}
+	s.store = store
+	if _, err := s.DrawNBanner(ctx, &gachav1.DrawNBannerRequest{Ref: ref, N: 10, PlayerId: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	var logged []eventlog.Event
+	if err := log.Scan(ctx, func(ev eventlog.Event) error { logged = append(logged, ev); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := store.Load(ctx, key)
+	if len(logged) != 10 || logged[9].Post[0].Count != st.Tiers[5].Count {
+		t.Fatalf("logged %d pulls for state %+v", len(logged), st)
+	}
+}
+
+func TestLuckPercentileRejectsUndrawnRarity(t *testing.T) {
+	s := newTestServer(t, map[string]string{"default.yaml": `
+draw:
+  pity: 20
+  p_base: 0.05
+`})
+	_, err := s.LuckPercentile(context.Background(), &gachav1.LuckPercentileRequest{
+		Ref:   &gachav1.GameRef{Game: "g", Pool: "char"},
+		Pulls: []*gachav1.LuckPull{{ItemId: "x", Rarity: 5}, {ItemId: "y", Rarity: 7}},
+		Fresh: true,
+		Goal:  gachav1.TrialGoal_TRIAL_GOAL_FIRST_HIT,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestSimulateLimitsAndStatus(t *testing.T) {
+	s := newTestServer(t, map[string]string{"default.yaml": `
+draw:
+  pity: 20
+  p_base: 0.05
+banner:
+  off_probs: [0.5]
+`})
+	ref := &gachav1.GameRef{Game: "g", Pool: "char"}
+	budget := gachav1.TrialGoal_TRIAL_GOAL_FIXED_BUDGET
+	nth := gachav1.TrialGoal_TRIAL_GOAL_NTH_UP
+	mean := gachav1.Statistic_STATISTIC_MEAN
+	for name, c := range map[string]struct {
+		req  *gachav1.SimulateRequest
+		code codes.Code
+	}{
+		"no trials":    {&gachav1.SimulateRequest{}, codes.InvalidArgument},
+		"too many":     {&gachav1.SimulateRequest{Trials: maxTrials + 1}, codes.InvalidArgument},
+		"budget draws": {&gachav1.SimulateRequest{Trials: maxTrials, Goal: budget, BudgetN: maxBudgetN}, codes.InvalidArgument},
+		"budget ok":    {&gachav1.SimulateRequest{Trials: 100, Goal: budget, BudgetN: 50}, codes.OK},
+		"copies":       {&gachav1.SimulateRequest{Trials: maxTrials, Goal: nth, Copies: maxCopies}, codes.InvalidArgument},
+		"adaptive cap": {&gachav1.SimulateRequest{Trials: 100, Goal: nth, Copies: maxCopies, Adaptive: mean, Tolerance: 0.1}, codes.InvalidArgument},
+		"copies ok":    {&gachav1.SimulateRequest{Trials: 100, Goal: nth, Copies: 2}, codes.OK},
+		"leg copies": {&gachav1.SimulateRequest{Trials: maxWaits / 150, Goal: gachav1.TrialGoal_TRIAL_GOAL_COMBINED, Legs: []*gachav1.SimulateLeg{
+			{Ref: ref, Copies: 100}, {Ref: ref, Copies: 100},
+		}}, codes.InvalidArgument},
+		"unknown goal":    {&gachav1.SimulateRequest{Trials: 10, Goal: 99}, codes.InvalidArgument},
+		"bad override":    {&gachav1.SimulateRequest{Trials: 10, PBase: 1.5}, codes.InvalidArgument},
+		"no game":         {&gachav1.SimulateRequest{Trials: 10, Ref: &gachav1.GameRef{}}, codes.InvalidArgument},
+		"bad id":          {&gachav1.SimulateRequest{Trials: 10, Ref: &gachav1.GameRef{Game: ".."}}, codes.InvalidArgument},
+		"lower exact":     {&gachav1.SimulateRequest{Exact: true, TargetRarity: 4}, codes.InvalidArgument},
+		"no refunds":      {&gachav1.SimulateRequest{Trials: 10, ReconvertRefunds: true}, codes.FailedPrecondition},
+		"exact uncertain": {&gachav1.SimulateRequest{Exact: true, Uncertainty: true}, codes.InvalidArgument},
+	} {
+		if c.req.Ref == nil {
+			c.req.Ref = ref
+		}
+		if _, err := s.Simulate(context.Background(), c.req); status.Code(err) != c.code {
+			t.Errorf("%s: got %v, want %v", name, err, c.code)
+		}
+	}
+
+	for err, code := range map[error]codes.Code{
+		context.Canceled:    codes.Canceled,
+		gacha.ErrTierConfig: codes.InvalidArgument,
+		&game.ValidationError{Errors: []string{"x"}}: codes.InvalidArgument,
+		status.Error(codes.NotFound, "x"):            codes.NotFound,
+		errors.New("disk full"):                      codes.Internal,
+	} {
+		if got := status.Code(toStatus(err)); got != code {
+			t.Errorf("toStatus(%v) = %v, want %v", err, got, code)
+		}
+	}
+}
+
+// proofOf reads a FairProof and its batch's results the way an offline
+// verifier would.
+func proofOf(resp *gachav1.DrawNBannerResponse) (fair.Proof, []gacha.TierOutcome) {
+	p := resp.GetFair()
+	proof := fair.Proof{
+		Commitment: p.GetCommitment(),
+		ServerSeed: p.GetServerSeed(),
+		ClientSeed: p.GetClientSeed(),
+		Nonce:      p.GetNonce(),
+		Batch:      int(p.GetBatch()),
+		Version:    p.GetVersion(),
+		Start:      state.PlayerState{Tiers: map[int]state.TierState{}, Selected: map[int]string{}},
+	}
+	for _, t := range p.GetStart() {
+		proof.Start.Tiers[int(t.GetRarity())] = state.TierState{
+			Count:          int(t.GetCount()),
+			OffStreak:      int(t.GetOffStreak()),
+			GuaranteedNext: t.GetGuaranteedNext(),
+			FatePoints:     int(t.GetFatePoints()),
+			Radiance:       int(t.GetRadiance()),
+		}
+	}
+	for r, id := range p.GetSelected() {
+		proof.Start.Selected[int(r)] = id
+	}
+	var results []gacha.TierOutcome
+	for _, r := range resp.GetResults() {
+		results = append(results, gacha.TierOutcome{Rarity: int(r.GetRarity()), IsUp: r.GetIsUp(), Item: r.GetItemId()})
+	}
+	return proof, results
+}
+
+func TestDrawNBannerFairProofVerifies(t *testing.T) {
+	s := newTestServer(t, map[string]string{"default.yaml": `
+version: "7"
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+tiers:
+  - rarity: 4
+    p_base: 0.051
+    pity: 10
+multi:
+  min_rarity: 4
+items:
+  5:
+    featured: [{id: acheron}]
+    standard: [{id: bronya}, {id: welt}]
+`})
+	s.store = state.NewMemoryStore()
+	ctx := context.Background()
+	ref := &gachav1.GameRef{Game: "g", Pool: "char"}
+	_, ep, err := s.resolver.Resolve("g", "char", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first batch starts from the pool config, later ones from saved state
+	for i, multi := range []bool{true, false, true} {
+		c, err := s.GetFairCommitment(ctx, &gachav1.GetFairCommitmentRequest{Ref: ref, PlayerId: "p1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := s.DrawNBanner(ctx, &gachav1.DrawNBannerRequest{Ref: ref, N: 20, PlayerId: "p1", Multi: multi, ClientSeed: "lucky"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, results := proofOf(resp)
+		if proof.Commitment != c.GetCommitment() || proof.Nonce != c.GetNonce() || proof.Version != "7" {
+			t.Fatalf("batch %d: proof %+v against commitment %+v", i, proof, c)
+		}
+		if err := fair.Verify(ep, proof, results); err != nil {
+			t.Fatalf("batch %d (multi=%v) should verify: %v", i, multi, err)
+		}
+
+		tampered := append([]gacha.TierOutcome(nil), results...)
+		tampered[3].IsUp = !tampered[3].IsUp
+		if err := fair.Verify(ep, proof, tampered); !errors.Is(err, fair.ErrMismatch) {
+			t.Fatalf("tampered batch should fail, got %v", err)
+		}
+		other := ep
+		other.Version = "8"
+		if err := fair.Verify(other, proof, results); !errors.Is(err, fair.ErrVersion) {
+			t.Fatalf("another config version should fail, got %v", err)
+		}
+		proof.Commitment = gacha.Commitment("other")
+		if err := fair.Verify(ep, proof, results); !errors.Is(err, fair.ErrCommitment) {
+			t.Fatalf("wrong seed should fail the commitment, got %v", err)
+		}
+	}
+}
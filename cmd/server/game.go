@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gopkg.in/yaml.v3"
+
+	gamev1 "github.com/xtding233/gacha-backend/gen/game/v1"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// GameServer implements gamev1.GameServiceServer
+type GameServer struct {
+	gamev1.UnimplementedGameServiceServer
+
+	loader   *game.Loader
+	resolver game.Resolver
+}
+
+// NewGameServer creates a GameServer backed by the given loader.
+func NewGameServer(l *game.Loader) *GameServer {
+	return &GameServer{loader: l, resolver: game.NewResolver(l)}
+}
+
+func (s *GameServer) ListGames(_ *emptypb.Empty, stream gamev1.GameService_ListGamesServer) error {
+	paths := s.loader.Paths()
+	games, err := paths.Games()
+	if err != nil {
+		return status.Errorf(codes.Internal, "list games: %v", err)
+	}
+	for _, g := range games {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		pools, err := paths.Pools(g)
+		if err != nil {
+			return status.Errorf(codes.Internal, "list pools for %s: %v", g, err)
+		}
+		cfg, err := s.loader.LoadMerged(g, "")
+		if err != nil {
+			return toStatus(err)
+		}
+		name := cfg.DisplayName
+		if name == "" {
+			name = g
+		}
+		meta := &gamev1.GameMeta{Game: g, DisplayName: name, Pools: pools, Version: cfg.Version}
+		if err := stream.Send(meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GameServer) GetRawConfig(ctx context.Context, ref *gamev1.GameRef) (*gamev1.RawConfig, error) {
+	if ref.GetGame() == "" {
+		return nil, status.Error(codes.InvalidArgument, "game is required")
+	}
+	cfg, err := s.loader.LoadMerged(ref.GetGame(), ref.GetPool())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	text, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal config: %v", err)
+	}
+	return &gamev1.RawConfig{Text: string(text), Version: cfg.Version}, nil
+}
+
+func (s *GameServer) GetEffectiveConfig(ctx context.Context, ref *gamev1.GameRef) (*gamev1.EffectiveConfig, error) {
+	if ref.GetGame() == "" {
+		return nil, status.Error(codes.InvalidArgument, "game is required")
+	}
+	raw, ep, err := s.resolver.Resolve(ref.GetGame(), ref.GetPool(), game.Overrides{})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &gamev1.EffectiveConfig{
+		PBase:    ep.PBase,
+		Pity:     int32(ep.Pity),
+		SoftMode: ep.SoftMode,
+		Easing:   ep.Easing,
+		OffProbs: ep.OffProbs,
+		MaxOff:   int32(ep.MaxOff),
+		Cushion:  int32(ep.Cushion),
+		Version:  ep.Version,
+		Notes:    raw.Notes,
+	}
+	if ep.StartAt != nil {
+		out.StartAt = int32(*ep.StartAt)
+	}
+	if ep.StartPct != nil {
+		out.StartPct = *ep.StartPct
+	}
+	if ep.Target != nil {
+		out.Target = *ep.Target
+	}
+	if ep.Increment != nil {
+		out.Increment = *ep.Increment
+	}
+	return out, nil
+}
+
+// ValidateConfig reports parse errors and every ValidateRaw finding as separate entries.
+// An invalid candidate is a normal response (ok=false), not an RPC error.
+func (s *GameServer) ValidateConfig(ctx context.Context, req *gamev1.RawConfig) (*gamev1.ValidationResult, error) {
+	cfg, err := game.ParseRaw([]byte(req.GetText()))
+	if err != nil {
+		return &gamev1.ValidationResult{Ok: false, Errors: []string{"parse: " + err.Error()}}, nil
+	}
+	if err := game.ValidateRaw(cfg); err != nil {
+		var verr *game.ValidationError
+		if errors.As(err, &verr) {
+			return &gamev1.ValidationResult{Ok: false, Errors: verr.Errors}, nil
+		}
+		return &gamev1.ValidationResult{Ok: false, Errors: []string{err.Error()}}, nil
+	}
+	return &gamev1.ValidationResult{Ok: true}, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net"
 
@@ -9,24 +10,39 @@ import (
 	// generated stubs
 	gachav1 "github.com/xtding233/gacha-backend/gen/gacha/v1"
 	gamev1 "github.com/xtding233/gacha-backend/gen/game/v1"
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
 )
 
-// ---- Minimal server implementations ----
-
-// GachaServer implements gachav1.GachaServiceServer
-type GachaServer struct {
-	gachav1.UnimplementedGachaServiceServer
-	// add fields: loader, resolver, engine, etc.
-}
+func main() {
+	addr := flag.String("addr", ":50051", "listen address")
+	baseDir := flag.String("config", ".", "base directory containing games/default.yaml")
+	stateDir := flag.String("state", "", "directory for per-player pity state; empty keeps state in memory")
+	eventsPath := flag.String("events", "", "append-only draw event log (JSON Lines); empty disables logging")
+	flag.Parse()
+
+	loader := game.NewLoader(*baseDir)
+	var store state.Store = state.NewMemoryStore()
+	if *stateDir != "" {
+		fs, err := state.NewFileStore(*stateDir)
+		if err != nil {
+			log.Fatalf("failed to open state dir: %v", err)
+		}
+		store = fs
+	}
 
-// GameServer implements gamev1.GameServiceServer
-type GameServer struct {
-	gamev1.UnimplementedGameServiceServer
-	// add fields: loader, resolver, etc.
-}
+	gachaServer := NewGachaServer(loader, store)
+	if *eventsPath != "" {
+		events, err := eventlog.OpenFileLog(*eventsPath)
+		if err != nil {
+			log.Fatalf("failed to open event log: %v", err)
+		}
+		defer events.Close()
+		gachaServer.events = events
+	}
 
-func main() {
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", *addr)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
@@ -34,10 +50,10 @@ func main() {
 	grpcServer := grpc.NewServer()
 
 	// Register services
-	gachav1.RegisterGachaServiceServer(grpcServer, &GachaServer{})
-	gamev1.RegisterGameServiceServer(grpcServer, &GameServer{})
+	gachav1.RegisterGachaServiceServer(grpcServer, gachaServer)
+	gamev1.RegisterGameServiceServer(grpcServer, NewGameServer(loader))
 
-	log.Println("gRPC server listening on :50051")
+	log.Printf("gRPC server listening on %s", *addr)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
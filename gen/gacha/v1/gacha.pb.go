@@ -134,6 +134,8 @@ const (
 	TrialGoal_TRIAL_GOAL_FIRST_HIT    TrialGoal = 1 // draws until first high-rarity hit
 	TrialGoal_TRIAL_GOAL_FIRST_UP     TrialGoal = 2 // draws until first UP (banner-aware)
 	TrialGoal_TRIAL_GOAL_FIXED_BUDGET TrialGoal = 3 // count hits/UPs within a fixed N
+	TrialGoal_TRIAL_GOAL_NTH_UP       TrialGoal = 4 // draws until `copies` UPs (e.g., C6 = 7 copies)
+	TrialGoal_TRIAL_GOAL_COMBINED     TrialGoal = 5 // total draws to complete every leg in `legs`
 )
 
 // Enum value maps for TrialGoal.
@@ -143,12 +145,16 @@ var (
 		1: "TRIAL_GOAL_FIRST_HIT",
 		2: "TRIAL_GOAL_FIRST_UP",
 		3: "TRIAL_GOAL_FIXED_BUDGET",
+		4: "TRIAL_GOAL_NTH_UP",
+		5: "TRIAL_GOAL_COMBINED",
 	}
 	TrialGoal_value = map[string]int32{
 		"TRIAL_GOAL_UNSPECIFIED":  0,
 		"TRIAL_GOAL_FIRST_HIT":    1,
 		"TRIAL_GOAL_FIRST_UP":     2,
 		"TRIAL_GOAL_FIXED_BUDGET": 3,
+		"TRIAL_GOAL_NTH_UP":       4,
+		"TRIAL_GOAL_COMBINED":     5,
 	}
 )
 
@@ -179,6 +185,68 @@ func (TrialGoal) EnumDescriptor() ([]byte, []int) {
 	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{2}
 }
 
+// A statistic of a simulation result.
+type Statistic int32
+
+const (
+	Statistic_STATISTIC_UNSPECIFIED Statistic = 0
+	Statistic_STATISTIC_MEAN        Statistic = 1
+	Statistic_STATISTIC_VARIANCE    Statistic = 2
+	Statistic_STATISTIC_STD_DEV     Statistic = 3
+	Statistic_STATISTIC_P50         Statistic = 4
+	Statistic_STATISTIC_P90         Statistic = 5
+	Statistic_STATISTIC_P99         Statistic = 6
+)
+
+// Enum value maps for Statistic.
+var (
+	Statistic_name = map[int32]string{
+		0: "STATISTIC_UNSPECIFIED",
+		1: "STATISTIC_MEAN",
+		2: "STATISTIC_VARIANCE",
+		3: "STATISTIC_STD_DEV",
+		4: "STATISTIC_P50",
+		5: "STATISTIC_P90",
+		6: "STATISTIC_P99",
+	}
+	Statistic_value = map[string]int32{
+		"STATISTIC_UNSPECIFIED": 0,
+		"STATISTIC_MEAN":        1,
+		"STATISTIC_VARIANCE":    2,
+		"STATISTIC_STD_DEV":     3,
+		"STATISTIC_P50":         4,
+		"STATISTIC_P90":         5,
+		"STATISTIC_P99":         6,
+	}
+)
+
+func (x Statistic) Enum() *Statistic {
+	p := new(Statistic)
+	*p = x
+	return p
+}
+
+func (x Statistic) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Statistic) Descriptor() protoreflect.EnumDescriptor {
+	return file_gacha_v1_gacha_proto_enumTypes[3].Descriptor()
+}
+
+func (Statistic) Type() protoreflect.EnumType {
+	return &file_gacha_v1_gacha_proto_enumTypes[3]
+}
+
+func (x Statistic) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Statistic.Descriptor instead.
+func (Statistic) EnumDescriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{3}
+}
+
 // Identifies a game/pool to load config for.
 type GameRef struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -474,6 +542,8 @@ type ResolveResponse struct {
 	MaxOff   int32     `protobuf:"varint,21,opt,name=max_off,json=maxOff,proto3" json:"max_off,omitempty"`
 	// Carry-over count
 	Cushion int32 `protobuf:"varint,30,opt,name=cushion,proto3" json:"cushion,omitempty"`
+	// Pools in the same pity group share player pity/guarantee state.
+	PityGroup string `protobuf:"bytes,31,opt,name=pity_group,json=pityGroup,proto3" json:"pity_group,omitempty"`
 	// Config tracing
 	EffectiveVersion string `protobuf:"bytes,40,opt,name=effective_version,json=effectiveVersion,proto3" json:"effective_version,omitempty"`
 	Notes            string `protobuf:"bytes,41,opt,name=notes,proto3" json:"notes,omitempty"`
@@ -588,6 +658,13 @@ func (x *ResolveResponse) GetCushion() int32 {
 	return 0
 }
 
+func (x *ResolveResponse) GetPityGroup() string {
+	if x != nil {
+		return x.PityGroup
+	}
+	return ""
+}
+
 func (x *ResolveResponse) GetEffectiveVersion() string {
 	if x != nil {
 		return x.EffectiveVersion
@@ -849,8 +926,11 @@ func (x *DrawNPityResponse) GetCount() int32 {
 // Banner outcome per draw.
 type BannerOutcome struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Hit           bool                   `protobuf:"varint,1,opt,name=hit,proto3" json:"hit,omitempty"`               // high-rarity occurred
-	IsUp          bool                   `protobuf:"varint,2,opt,name=is_up,json=isUp,proto3" json:"is_up,omitempty"` // true if featured (UP) when hit==true
+	Hit           bool                   `protobuf:"varint,1,opt,name=hit,proto3" json:"hit,omitempty"`                           // top-rarity occurred
+	IsUp          bool                   `protobuf:"varint,2,opt,name=is_up,json=isUp,proto3" json:"is_up,omitempty"`             // true if the drawn item is featured (UP) for its rarity
+	Rarity        int32                  `protobuf:"varint,3,opt,name=rarity,proto3" json:"rarity,omitempty"`                     // rarity drawn, e.g. 5/4/3 when the pool has lower tiers
+	ItemId        string                 `protobuf:"bytes,4,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`        // unit pulled from the pool roster; empty if the pool has no items
+	OnTarget      bool                   `protobuf:"varint,5,opt,name=on_target,json=onTarget,proto3" json:"on_target,omitempty"` // charted item of an epitomized path (weapon banners)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -899,17 +979,48 @@ func (x *BannerOutcome) GetIsUp() bool {
 	return false
 }
 
+func (x *BannerOutcome) GetRarity() int32 {
+	if x != nil {
+		return x.Rarity
+	}
+	return 0
+}
+
+func (x *BannerOutcome) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *BannerOutcome) GetOnTarget() bool {
+	if x != nil {
+		return x.OnTarget
+	}
+	return false
+}
+
 // N-draw with soft/hard pity + banner multi-off logic.
 type DrawNBannerRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Ref   *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
 	N     int32                  `protobuf:"varint,2,opt,name=n,proto3" json:"n,omitempty"` // required
 	// Optional overrides:
-	PBase         float64            `protobuf:"fixed64,3,opt,name=p_base,json=pBase,proto3" json:"p_base,omitempty"`
-	Pity          int32              `protobuf:"varint,4,opt,name=pity,proto3" json:"pity,omitempty"`
-	Soft          *SoftPityOverrides `protobuf:"bytes,5,opt,name=soft,proto3" json:"soft,omitempty"`
-	Cushion       int32              `protobuf:"varint,6,opt,name=cushion,proto3" json:"cushion,omitempty"`
-	Banner        *BannerOverrides   `protobuf:"bytes,7,opt,name=banner,proto3" json:"banner,omitempty"`
+	PBase   float64            `protobuf:"fixed64,3,opt,name=p_base,json=pBase,proto3" json:"p_base,omitempty"`
+	Pity    int32              `protobuf:"varint,4,opt,name=pity,proto3" json:"pity,omitempty"`
+	Soft    *SoftPityOverrides `protobuf:"bytes,5,opt,name=soft,proto3" json:"soft,omitempty"`
+	Cushion int32              `protobuf:"varint,6,opt,name=cushion,proto3" json:"cushion,omitempty"`
+	Banner  *BannerOverrides   `protobuf:"bytes,7,opt,name=banner,proto3" json:"banner,omitempty"`
+	// Optional player id. When set, pity/guarantee state is loaded from the
+	// player's saved state and saved back after the batch; the overrides above
+	// must be unset.
+	PlayerId string `protobuf:"bytes,8,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	// Pull in multi-pulls of the pool's multi.size, each guaranteeing multi.min_rarity.
+	// n must be a multiple of multi.size.
+	Multi bool `protobuf:"varint,9,opt,name=multi,proto3" json:"multi,omitempty"`
+	// Provably fair mode: draw from the player's committed server seed (see
+	// GetFairCommitment) mixed with this seed. Requires player_id.
+	ClientSeed    string `protobuf:"bytes,10,opt,name=client_seed,json=clientSeed,proto3" json:"client_seed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -993,12 +1104,36 @@ func (x *DrawNBannerRequest) GetBanner() *BannerOverrides {
 	return nil
 }
 
+func (x *DrawNBannerRequest) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *DrawNBannerRequest) GetMulti() bool {
+	if x != nil {
+		return x.Multi
+	}
+	return false
+}
+
+func (x *DrawNBannerRequest) GetClientSeed() string {
+	if x != nil {
+		return x.ClientSeed
+	}
+	return ""
+}
+
 type DrawNBannerResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Results        []*BannerOutcome       `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`                                      // length n
 	Count          int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`                                         // pity counter after the batch
 	GuaranteedNext bool                   `protobuf:"varint,3,opt,name=guaranteed_next,json=guaranteedNext,proto3" json:"guaranteed_next,omitempty"` // next hit must be UP
 	OffStreak      int32                  `protobuf:"varint,4,opt,name=off_streak,json=offStreak,proto3" json:"off_streak,omitempty"`                // consecutive offs after the batch
+	FatePoints     int32                  `protobuf:"varint,5,opt,name=fate_points,json=fatePoints,proto3" json:"fate_points,omitempty"`             // epitomized path points after the batch; 0 without a path
+	SparkPoints    int32                  `protobuf:"varint,6,opt,name=spark_points,json=sparkPoints,proto3" json:"spark_points,omitempty"`          // spark points after the batch; 0 without a spark
+	Fair           *FairProof             `protobuf:"bytes,7,opt,name=fair,proto3" json:"fair,omitempty"`                                            // set in provably fair mode
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -1061,38 +1196,54 @@ func (x *DrawNBannerResponse) GetOffStreak() int32 {
 	return 0
 }
 
-// Monte Carlo simulation.
-type SimulateRequest struct {
-	state  protoimpl.MessageState `protogen:"open.v1"`
-	Ref    *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
-	Goal   TrialGoal              `protobuf:"varint,2,opt,name=goal,proto3,enum=gacha.v1.TrialGoal" json:"goal,omitempty"` // default FIRST_UP if unspecified
-	Trials int32                  `protobuf:"varint,3,opt,name=trials,proto3" json:"trials,omitempty"`                     // required, >0
-	// Optional overrides (same as draw banner; include cushion & banner)
-	PBase   float64            `protobuf:"fixed64,10,opt,name=p_base,json=pBase,proto3" json:"p_base,omitempty"`
-	Pity    int32              `protobuf:"varint,11,opt,name=pity,proto3" json:"pity,omitempty"`
-	Soft    *SoftPityOverrides `protobuf:"bytes,12,opt,name=soft,proto3" json:"soft,omitempty"`
-	Cushion int32              `protobuf:"varint,13,opt,name=cushion,proto3" json:"cushion,omitempty"`
-	Banner  *BannerOverrides   `protobuf:"bytes,14,opt,name=banner,proto3" json:"banner,omitempty"`
-	// Only used for FIXED_BUDGET
-	BudgetN       int32 `protobuf:"varint,20,opt,name=budget_n,json=budgetN,proto3" json:"budget_n,omitempty"` // number of draws per trial
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *DrawNBannerResponse) GetFatePoints() int32 {
+	if x != nil {
+		return x.FatePoints
+	}
+	return 0
 }
 
-func (x *SimulateRequest) Reset() {
-	*x = SimulateRequest{}
+func (x *DrawNBannerResponse) GetSparkPoints() int32 {
+	if x != nil {
+		return x.SparkPoints
+	}
+	return 0
+}
+
+func (x *DrawNBannerResponse) GetFair() *FairProof {
+	if x != nil {
+		return x.Fair
+	}
+	return nil
+}
+
+// Pity state of one tier, as stored between calls.
+type TierSnapshot struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Rarity         int32                  `protobuf:"varint,1,opt,name=rarity,proto3" json:"rarity,omitempty"`
+	Count          int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	OffStreak      int32                  `protobuf:"varint,3,opt,name=off_streak,json=offStreak,proto3" json:"off_streak,omitempty"`
+	GuaranteedNext bool                   `protobuf:"varint,4,opt,name=guaranteed_next,json=guaranteedNext,proto3" json:"guaranteed_next,omitempty"`
+	FatePoints     int32                  `protobuf:"varint,5,opt,name=fate_points,json=fatePoints,proto3" json:"fate_points,omitempty"`
+	Radiance       int32                  `protobuf:"varint,6,opt,name=radiance,proto3" json:"radiance,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TierSnapshot) Reset() {
+	*x = TierSnapshot{}
 	mi := &file_gacha_v1_gacha_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimulateRequest) String() string {
+func (x *TierSnapshot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimulateRequest) ProtoMessage() {}
+func (*TierSnapshot) ProtoMessage() {}
 
-func (x *SimulateRequest) ProtoReflect() protoreflect.Message {
+func (x *TierSnapshot) ProtoReflect() protoreflect.Message {
 	mi := &file_gacha_v1_gacha_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1104,103 +1255,191 @@ func (x *SimulateRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimulateRequest.ProtoReflect.Descriptor instead.
-func (*SimulateRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use TierSnapshot.ProtoReflect.Descriptor instead.
+func (*TierSnapshot) Descriptor() ([]byte, []int) {
 	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *SimulateRequest) GetRef() *GameRef {
+func (x *TierSnapshot) GetRarity() int32 {
 	if x != nil {
-		return x.Ref
+		return x.Rarity
 	}
-	return nil
+	return 0
 }
 
-func (x *SimulateRequest) GetGoal() TrialGoal {
+func (x *TierSnapshot) GetCount() int32 {
 	if x != nil {
-		return x.Goal
+		return x.Count
 	}
-	return TrialGoal_TRIAL_GOAL_UNSPECIFIED
+	return 0
 }
 
-func (x *SimulateRequest) GetTrials() int32 {
+func (x *TierSnapshot) GetOffStreak() int32 {
 	if x != nil {
-		return x.Trials
+		return x.OffStreak
 	}
 	return 0
 }
 
-func (x *SimulateRequest) GetPBase() float64 {
+func (x *TierSnapshot) GetGuaranteedNext() bool {
 	if x != nil {
-		return x.PBase
+		return x.GuaranteedNext
+	}
+	return false
+}
+
+func (x *TierSnapshot) GetFatePoints() int32 {
+	if x != nil {
+		return x.FatePoints
 	}
 	return 0
 }
 
-func (x *SimulateRequest) GetPity() int32 {
+func (x *TierSnapshot) GetRadiance() int32 {
 	if x != nil {
-		return x.Pity
+		return x.Radiance
 	}
 	return 0
 }
 
-func (x *SimulateRequest) GetSoft() *SoftPityOverrides {
+// Everything needed to recompute a provably fair DrawNBanner batch offline:
+// the i-th random value is HMAC-SHA256(server_seed, "client_seed:nonce:i")
+// and sha256(server_seed) must equal the commitment published before the call.
+// Fair batches take no request overrides, so the pool config at version and
+// the state in start decide every draw; tiers missing from start begin at the
+// pool's cushion.
+type FairProof struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Commitment     string                 `protobuf:"bytes,1,opt,name=commitment,proto3" json:"commitment,omitempty"`                   // sha256 of server_seed, published ahead
+	ServerSeed     string                 `protobuf:"bytes,2,opt,name=server_seed,json=serverSeed,proto3" json:"server_seed,omitempty"` // revealed seed used for this batch
+	ClientSeed     string                 `protobuf:"bytes,3,opt,name=client_seed,json=clientSeed,proto3" json:"client_seed,omitempty"`
+	Nonce          uint64                 `protobuf:"varint,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	NextCommitment string                 `protobuf:"bytes,5,opt,name=next_commitment,json=nextCommitment,proto3" json:"next_commitment,omitempty"`                                          // commitment of the seed the next batch uses
+	Start          []*TierSnapshot        `protobuf:"bytes,6,rep,name=start,proto3" json:"start,omitempty"`                                                                                  // state before the batch
+	Selected       map[int32]string       `protobuf:"bytes,7,rep,name=selected,proto3" json:"selected,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // selector choices before the batch
+	Batch          int32                  `protobuf:"varint,8,opt,name=batch,proto3" json:"batch,omitempty"`                                                                                 // multi-pull size the batch was drawn in; 1 for single pulls
+	Version        string                 `protobuf:"bytes,9,opt,name=version,proto3" json:"version,omitempty"`                                                                              // effective config version of the pool
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FairProof) Reset() {
+	*x = FairProof{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FairProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FairProof) ProtoMessage() {}
+
+func (x *FairProof) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[13]
 	if x != nil {
-		return x.Soft
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *SimulateRequest) GetCushion() int32 {
+// Deprecated: Use FairProof.ProtoReflect.Descriptor instead.
+func (*FairProof) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *FairProof) GetCommitment() string {
 	if x != nil {
-		return x.Cushion
+		return x.Commitment
+	}
+	return ""
+}
+
+func (x *FairProof) GetServerSeed() string {
+	if x != nil {
+		return x.ServerSeed
+	}
+	return ""
+}
+
+func (x *FairProof) GetClientSeed() string {
+	if x != nil {
+		return x.ClientSeed
+	}
+	return ""
+}
+
+func (x *FairProof) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
 	}
 	return 0
 }
 
-func (x *SimulateRequest) GetBanner() *BannerOverrides {
+func (x *FairProof) GetNextCommitment() string {
 	if x != nil {
-		return x.Banner
+		return x.NextCommitment
+	}
+	return ""
+}
+
+func (x *FairProof) GetStart() []*TierSnapshot {
+	if x != nil {
+		return x.Start
 	}
 	return nil
 }
 
-func (x *SimulateRequest) GetBudgetN() int32 {
+func (x *FairProof) GetSelected() map[int32]string {
 	if x != nil {
-		return x.BudgetN
+		return x.Selected
+	}
+	return nil
+}
+
+func (x *FairProof) GetBatch() int32 {
+	if x != nil {
+		return x.Batch
 	}
 	return 0
 }
 
-type SimulateResponse struct {
-	state    protoimpl.MessageState `protogen:"open.v1"`
-	Mean     float64                `protobuf:"fixed64,1,opt,name=mean,proto3" json:"mean,omitempty"`
-	Variance float64                `protobuf:"fixed64,2,opt,name=variance,proto3" json:"variance,omitempty"`
-	StdDev   float64                `protobuf:"fixed64,3,opt,name=std_dev,json=stdDev,proto3" json:"std_dev,omitempty"`
-	P50      float64                `protobuf:"fixed64,4,opt,name=p50,proto3" json:"p50,omitempty"`
-	P90      float64                `protobuf:"fixed64,5,opt,name=p90,proto3" json:"p90,omitempty"`
-	P99      float64                `protobuf:"fixed64,6,opt,name=p99,proto3" json:"p99,omitempty"`
-	// echo back version for tracing
-	EffectiveVersion string `protobuf:"bytes,10,opt,name=effective_version,json=effectiveVersion,proto3" json:"effective_version,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *FairProof) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
 }
 
-func (x *SimulateResponse) Reset() {
-	*x = SimulateResponse{}
-	mi := &file_gacha_v1_gacha_proto_msgTypes[13]
+// Fetch the commitment of the seed a player's next fair batch will use.
+type GetFairCommitmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	PlayerId      string                 `protobuf:"bytes,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"` // required
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFairCommitmentRequest) Reset() {
+	*x = GetFairCommitmentRequest{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimulateResponse) String() string {
+func (x *GetFairCommitmentRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimulateResponse) ProtoMessage() {}
+func (*GetFairCommitmentRequest) ProtoMessage() {}
 
-func (x *SimulateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_gacha_v1_gacha_proto_msgTypes[13]
+func (x *GetFairCommitmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1211,60 +1450,1412 @@ func (x *SimulateResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimulateResponse.ProtoReflect.Descriptor instead.
-func (*SimulateResponse) Descriptor() ([]byte, []int) {
-	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use GetFairCommitmentRequest.ProtoReflect.Descriptor instead.
+func (*GetFairCommitmentRequest) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *SimulateResponse) GetMean() float64 {
+func (x *GetFairCommitmentRequest) GetRef() *GameRef {
 	if x != nil {
-		return x.Mean
+		return x.Ref
 	}
-	return 0
+	return nil
 }
 
-func (x *SimulateResponse) GetVariance() float64 {
+func (x *GetFairCommitmentRequest) GetPlayerId() string {
 	if x != nil {
-		return x.Variance
+		return x.PlayerId
 	}
-	return 0
+	return ""
 }
 
-func (x *SimulateResponse) GetStdDev() float64 {
-	if x != nil {
-		return x.StdDev
-	}
-	return 0
+type GetFairCommitmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commitment    string                 `protobuf:"bytes,1,opt,name=commitment,proto3" json:"commitment,omitempty"` // sha256 of the hidden server seed
+	Nonce         uint64                 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`          // nonce of the next batch
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SimulateResponse) GetP50() float64 {
+func (x *GetFairCommitmentResponse) Reset() {
+	*x = GetFairCommitmentResponse{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFairCommitmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFairCommitmentResponse) ProtoMessage() {}
+
+func (x *GetFairCommitmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[15]
 	if x != nil {
-		return x.P50
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *SimulateResponse) GetP90() float64 {
+// Deprecated: Use GetFairCommitmentResponse.ProtoReflect.Descriptor instead.
+func (*GetFairCommitmentResponse) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetFairCommitmentResponse) GetCommitment() string {
 	if x != nil {
-		return x.P90
+		return x.Commitment
 	}
-	return 0
+	return ""
 }
 
-func (x *SimulateResponse) GetP99() float64 {
+func (x *GetFairCommitmentResponse) GetNonce() uint64 {
 	if x != nil {
-		return x.P99
+		return x.Nonce
+	}
+	return 0
+}
+
+// Choose the featured item of a selector banner for one player.
+type SetSelectionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	PlayerId      string                 `protobuf:"bytes,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"` // required
+	ItemId        string                 `protobuf:"bytes,3,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`       // one of the pool's selector choices
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetSelectionRequest) Reset() {
+	*x = SetSelectionRequest{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSelectionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSelectionRequest) ProtoMessage() {}
+
+func (x *SetSelectionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSelectionRequest.ProtoReflect.Descriptor instead.
+func (*SetSelectionRequest) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetSelectionRequest) GetRef() *GameRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *SetSelectionRequest) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *SetSelectionRequest) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+type SetSelectionResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ItemId         string                 `protobuf:"bytes,1,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"`                           // selection now in effect
+	PreviousItemId string                 `protobuf:"bytes,2,opt,name=previous_item_id,json=previousItemId,proto3" json:"previous_item_id,omitempty"` // empty if this is the first choice
+	Switched       bool                   `protobuf:"varint,3,opt,name=switched,proto3" json:"switched,omitempty"`                                    // a different earlier choice was replaced
+	PityKept       bool                   `protobuf:"varint,4,opt,name=pity_kept,json=pityKept,proto3" json:"pity_kept,omitempty"`                    // pity counter survived the switch
+	FateKept       bool                   `protobuf:"varint,5,opt,name=fate_kept,json=fateKept,proto3" json:"fate_kept,omitempty"`                    // guarantee/off streak/fate points survived the switch
+	Count          int32                  `protobuf:"varint,6,opt,name=count,proto3" json:"count,omitempty"`                                          // pity counter of the selector rarity after the call
+	GuaranteedNext bool                   `protobuf:"varint,7,opt,name=guaranteed_next,json=guaranteedNext,proto3" json:"guaranteed_next,omitempty"`
+	FatePoints     int32                  `protobuf:"varint,8,opt,name=fate_points,json=fatePoints,proto3" json:"fate_points,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetSelectionResponse) Reset() {
+	*x = SetSelectionResponse{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetSelectionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetSelectionResponse) ProtoMessage() {}
+
+func (x *SetSelectionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetSelectionResponse.ProtoReflect.Descriptor instead.
+func (*SetSelectionResponse) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetSelectionResponse) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *SetSelectionResponse) GetPreviousItemId() string {
+	if x != nil {
+		return x.PreviousItemId
+	}
+	return ""
+}
+
+func (x *SetSelectionResponse) GetSwitched() bool {
+	if x != nil {
+		return x.Switched
+	}
+	return false
+}
+
+func (x *SetSelectionResponse) GetPityKept() bool {
+	if x != nil {
+		return x.PityKept
+	}
+	return false
+}
+
+func (x *SetSelectionResponse) GetFateKept() bool {
+	if x != nil {
+		return x.FateKept
+	}
+	return false
+}
+
+func (x *SetSelectionResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SetSelectionResponse) GetGuaranteedNext() bool {
+	if x != nil {
+		return x.GuaranteedNext
+	}
+	return false
+}
+
+func (x *SetSelectionResponse) GetFatePoints() int32 {
+	if x != nil {
+		return x.FatePoints
+	}
+	return 0
+}
+
+// One part of a COMBINED goal: collect `copies` UPs on the referenced pool.
+// Legs are pulled one after another, each with its own pity state.
+type SimulateLeg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Copies        int32                  `protobuf:"varint,2,opt,name=copies,proto3" json:"copies,omitempty"`                              // >0
+	Cushion       int32                  `protobuf:"varint,3,opt,name=cushion,proto3" json:"cushion,omitempty"`                            // optional carry-over draws for this leg
+	SparkPoints   int32                  `protobuf:"varint,4,opt,name=spark_points,json=sparkPoints,proto3" json:"spark_points,omitempty"` // optional spark points held when the leg starts
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateLeg) Reset() {
+	*x = SimulateLeg{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateLeg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateLeg) ProtoMessage() {}
+
+func (x *SimulateLeg) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateLeg.ProtoReflect.Descriptor instead.
+func (*SimulateLeg) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SimulateLeg) GetRef() *GameRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *SimulateLeg) GetCopies() int32 {
+	if x != nil {
+		return x.Copies
+	}
+	return 0
+}
+
+func (x *SimulateLeg) GetCushion() int32 {
+	if x != nil {
+		return x.Cushion
+	}
+	return 0
+}
+
+func (x *SimulateLeg) GetSparkPoints() int32 {
+	if x != nil {
+		return x.SparkPoints
+	}
+	return 0
+}
+
+// Monte Carlo simulation.
+type SimulateRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ref    *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Goal   TrialGoal              `protobuf:"varint,2,opt,name=goal,proto3,enum=gacha.v1.TrialGoal" json:"goal,omitempty"` // default FIRST_UP if unspecified
+	Trials int32                  `protobuf:"varint,3,opt,name=trials,proto3" json:"trials,omitempty"`                     // required, >0
+	// Optional overrides (same as draw banner; include cushion & banner)
+	PBase   float64            `protobuf:"fixed64,10,opt,name=p_base,json=pBase,proto3" json:"p_base,omitempty"`
+	Pity    int32              `protobuf:"varint,11,opt,name=pity,proto3" json:"pity,omitempty"`
+	Soft    *SoftPityOverrides `protobuf:"bytes,12,opt,name=soft,proto3" json:"soft,omitempty"`
+	Cushion int32              `protobuf:"varint,13,opt,name=cushion,proto3" json:"cushion,omitempty"`
+	Banner  *BannerOverrides   `protobuf:"bytes,14,opt,name=banner,proto3" json:"banner,omitempty"`
+	// Only used for FIXED_BUDGET
+	BudgetN int32 `protobuf:"varint,20,opt,name=budget_n,json=budgetN,proto3" json:"budget_n,omitempty"` // number of draws per trial
+	// Rarity tier the goal measures (e.g., 4 for 4★); 0 means the top tier.
+	TargetRarity int32 `protobuf:"varint,21,opt,name=target_rarity,json=targetRarity,proto3" json:"target_rarity,omitempty"`
+	// Solve the Markov chain exactly instead of sampling; trials is ignored.
+	// Not available for pools with lower rarity tiers.
+	Exact bool `protobuf:"varint,22,opt,name=exact,proto3" json:"exact,omitempty"`
+	// Master seed for reproducible Monte Carlo; 0 picks a random seed.
+	// The same seed and parameters always give identical statistics.
+	Seed uint64 `protobuf:"varint,23,opt,name=seed,proto3" json:"seed,omitempty"`
+	// Only used for NTH_UP: number of UP copies to collect.
+	Copies int32 `protobuf:"varint,24,opt,name=copies,proto3" json:"copies,omitempty"`
+	// Only used for COMBINED; ref/overrides above are ignored in that mode.
+	Legs []*SimulateLeg `protobuf:"bytes,25,rep,name=legs,proto3" json:"legs,omitempty"`
+	// Spark points already held on the pool (ignored if the pool has no spark).
+	SparkPoints int32 `protobuf:"varint,26,opt,name=spark_points,json=sparkPoints,proto3" json:"spark_points,omitempty"`
+	// Spend duplicate refunds on extra draws; draw counts then include paid draws only.
+	// Requires refunds in the pool config.
+	ReconvertRefunds bool `protobuf:"varint,27,opt,name=reconvert_refunds,json=reconvertRefunds,proto3" json:"reconvert_refunds,omitempty"`
+	// Pull in the pool's multi-pulls; draw counts are rounded up to whole multis.
+	Multi bool `protobuf:"varint,28,opt,name=multi,proto3" json:"multi,omitempty"`
+	// Distribution output, in both modes:
+	// >0 returns a histogram with buckets of this many metric values.
+	BucketWidth int32 `protobuf:"varint,29,opt,name=bucket_width,json=bucketWidth,proto3" json:"bucket_width,omitempty"`
+	// Quantiles in [0,1] to report besides p50/p90/p99, e.g. 0.25 or 0.75.
+	Quantiles []float64 `protobuf:"fixed64,30,rep,packed,name=quantiles,proto3" json:"quantiles,omitempty"`
+	// Return cdf[k] = P(metric <= k), e.g. the chance of success within k draws.
+	Cdf bool `protobuf:"varint,31,opt,name=cdf,proto3" json:"cdf,omitempty"`
+	// Monte Carlo only: report standard errors and bootstrap confidence
+	// intervals of every statistic.
+	Uncertainty bool    `protobuf:"varint,32,opt,name=uncertainty,proto3" json:"uncertainty,omitempty"`
+	Confidence  float64 `protobuf:"fixed64,33,opt,name=confidence,proto3" json:"confidence,omitempty"` // interval level; 0 means 0.95
+	Resamples   int32   `protobuf:"varint,34,opt,name=resamples,proto3" json:"resamples,omitempty"`    // bootstrap resamples; 0 means 200
+	// Adaptive mode: after `trials`, keep doubling the trials until the
+	// interval half-width of `adaptive` is at most `tolerance`, or `max_trials`
+	// have run. Implies uncertainty.
+	Adaptive      Statistic `protobuf:"varint,35,opt,name=adaptive,proto3,enum=gacha.v1.Statistic" json:"adaptive,omitempty"`
+	Tolerance     float64   `protobuf:"fixed64,36,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	MaxTrials     int32     `protobuf:"varint,37,opt,name=max_trials,json=maxTrials,proto3" json:"max_trials,omitempty"` // 0 means the server limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateRequest) Reset() {
+	*x = SimulateRequest{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateRequest) ProtoMessage() {}
+
+func (x *SimulateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateRequest.ProtoReflect.Descriptor instead.
+func (*SimulateRequest) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SimulateRequest) GetRef() *GameRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *SimulateRequest) GetGoal() TrialGoal {
+	if x != nil {
+		return x.Goal
+	}
+	return TrialGoal_TRIAL_GOAL_UNSPECIFIED
+}
+
+func (x *SimulateRequest) GetTrials() int32 {
+	if x != nil {
+		return x.Trials
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetPBase() float64 {
+	if x != nil {
+		return x.PBase
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetPity() int32 {
+	if x != nil {
+		return x.Pity
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetSoft() *SoftPityOverrides {
+	if x != nil {
+		return x.Soft
+	}
+	return nil
+}
+
+func (x *SimulateRequest) GetCushion() int32 {
+	if x != nil {
+		return x.Cushion
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetBanner() *BannerOverrides {
+	if x != nil {
+		return x.Banner
+	}
+	return nil
+}
+
+func (x *SimulateRequest) GetBudgetN() int32 {
+	if x != nil {
+		return x.BudgetN
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetTargetRarity() int32 {
+	if x != nil {
+		return x.TargetRarity
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *SimulateRequest) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetCopies() int32 {
+	if x != nil {
+		return x.Copies
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetLegs() []*SimulateLeg {
+	if x != nil {
+		return x.Legs
+	}
+	return nil
+}
+
+func (x *SimulateRequest) GetSparkPoints() int32 {
+	if x != nil {
+		return x.SparkPoints
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetReconvertRefunds() bool {
+	if x != nil {
+		return x.ReconvertRefunds
+	}
+	return false
+}
+
+func (x *SimulateRequest) GetMulti() bool {
+	if x != nil {
+		return x.Multi
+	}
+	return false
+}
+
+func (x *SimulateRequest) GetBucketWidth() int32 {
+	if x != nil {
+		return x.BucketWidth
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetQuantiles() []float64 {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *SimulateRequest) GetCdf() bool {
+	if x != nil {
+		return x.Cdf
+	}
+	return false
+}
+
+func (x *SimulateRequest) GetUncertainty() bool {
+	if x != nil {
+		return x.Uncertainty
+	}
+	return false
+}
+
+func (x *SimulateRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetResamples() int32 {
+	if x != nil {
+		return x.Resamples
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetAdaptive() Statistic {
+	if x != nil {
+		return x.Adaptive
+	}
+	return Statistic_STATISTIC_UNSPECIFIED
+}
+
+func (x *SimulateRequest) GetTolerance() float64 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+func (x *SimulateRequest) GetMaxTrials() int32 {
+	if x != nil {
+		return x.MaxTrials
+	}
+	return 0
+}
+
+// Sampling uncertainty of one statistic.
+type Estimate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StdErr        float64                `protobuf:"fixed64,1,opt,name=std_err,json=stdErr,proto3" json:"std_err,omitempty"`
+	Low           float64                `protobuf:"fixed64,2,opt,name=low,proto3" json:"low,omitempty"` // confidence interval
+	High          float64                `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Estimate) Reset() {
+	*x = Estimate{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Estimate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Estimate) ProtoMessage() {}
+
+func (x *Estimate) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Estimate.ProtoReflect.Descriptor instead.
+func (*Estimate) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Estimate) GetStdErr() float64 {
+	if x != nil {
+		return x.StdErr
+	}
+	return 0
+}
+
+func (x *Estimate) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Estimate) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+type Uncertainty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mean          *Estimate              `protobuf:"bytes,1,opt,name=mean,proto3" json:"mean,omitempty"`
+	Variance      *Estimate              `protobuf:"bytes,2,opt,name=variance,proto3" json:"variance,omitempty"`
+	StdDev        *Estimate              `protobuf:"bytes,3,opt,name=std_dev,json=stdDev,proto3" json:"std_dev,omitempty"`
+	P50           *Estimate              `protobuf:"bytes,4,opt,name=p50,proto3" json:"p50,omitempty"`
+	P90           *Estimate              `protobuf:"bytes,5,opt,name=p90,proto3" json:"p90,omitempty"`
+	P99           *Estimate              `protobuf:"bytes,6,opt,name=p99,proto3" json:"p99,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,7,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Resamples     int32                  `protobuf:"varint,8,opt,name=resamples,proto3" json:"resamples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Uncertainty) Reset() {
+	*x = Uncertainty{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Uncertainty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Uncertainty) ProtoMessage() {}
+
+func (x *Uncertainty) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Uncertainty.ProtoReflect.Descriptor instead.
+func (*Uncertainty) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Uncertainty) GetMean() *Estimate {
+	if x != nil {
+		return x.Mean
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetVariance() *Estimate {
+	if x != nil {
+		return x.Variance
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetStdDev() *Estimate {
+	if x != nil {
+		return x.StdDev
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetP50() *Estimate {
+	if x != nil {
+		return x.P50
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetP90() *Estimate {
+	if x != nil {
+		return x.P90
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetP99() *Estimate {
+	if x != nil {
+		return x.P99
+	}
+	return nil
+}
+
+func (x *Uncertainty) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Uncertainty) GetResamples() int32 {
+	if x != nil {
+		return x.Resamples
+	}
+	return 0
+}
+
+// One histogram bar over metric values from..to inclusive.
+type HistogramBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          int32                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            int32                  `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	Prob          float64                `protobuf:"fixed64,3,opt,name=prob,proto3" json:"prob,omitempty"` // P(from <= metric <= to)
+	Cum           float64                `protobuf:"fixed64,4,opt,name=cum,proto3" json:"cum,omitempty"`   // P(metric <= to)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistogramBucket) Reset() {
+	*x = HistogramBucket{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistogramBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistogramBucket) ProtoMessage() {}
+
+func (x *HistogramBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistogramBucket.ProtoReflect.Descriptor instead.
+func (*HistogramBucket) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *HistogramBucket) GetFrom() int32 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *HistogramBucket) GetTo() int32 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *HistogramBucket) GetProb() float64 {
+	if x != nil {
+		return x.Prob
+	}
+	return 0
+}
+
+func (x *HistogramBucket) GetCum() float64 {
+	if x != nil {
+		return x.Cum
+	}
+	return 0
+}
+
+type QuantileValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Q             float64                `protobuf:"fixed64,1,opt,name=q,proto3" json:"q,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuantileValue) Reset() {
+	*x = QuantileValue{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuantileValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuantileValue) ProtoMessage() {}
+
+func (x *QuantileValue) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuantileValue.ProtoReflect.Descriptor instead.
+func (*QuantileValue) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *QuantileValue) GetQ() float64 {
+	if x != nil {
+		return x.Q
+	}
+	return 0
+}
+
+func (x *QuantileValue) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type SimulateResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Mean     float64                `protobuf:"fixed64,1,opt,name=mean,proto3" json:"mean,omitempty"`
+	Variance float64                `protobuf:"fixed64,2,opt,name=variance,proto3" json:"variance,omitempty"`
+	StdDev   float64                `protobuf:"fixed64,3,opt,name=std_dev,json=stdDev,proto3" json:"std_dev,omitempty"`
+	P50      float64                `protobuf:"fixed64,4,opt,name=p50,proto3" json:"p50,omitempty"`
+	P90      float64                `protobuf:"fixed64,5,opt,name=p90,proto3" json:"p90,omitempty"`
+	P99      float64                `protobuf:"fixed64,6,opt,name=p99,proto3" json:"p99,omitempty"`
+	// echo back version for tracing
+	EffectiveVersion string `protobuf:"bytes,10,opt,name=effective_version,json=effectiveVersion,proto3" json:"effective_version,omitempty"`
+	// Exact mode only: pmf[k] = P(metric == k).
+	Pmf []float64 `protobuf:"fixed64,11,rep,packed,name=pmf,proto3" json:"pmf,omitempty"`
+	// Monte Carlo only: seed used, to replay the run.
+	Seed uint64 `protobuf:"varint,12,opt,name=seed,proto3" json:"seed,omitempty"`
+	// Set when requested; see SimulateRequest. Monte Carlo results are the
+	// share of trials, and quantiles interpolate like p50/p90/p99.
+	Histogram []*HistogramBucket `protobuf:"bytes,13,rep,name=histogram,proto3" json:"histogram,omitempty"`
+	Cdf       []float64          `protobuf:"fixed64,14,rep,packed,name=cdf,proto3" json:"cdf,omitempty"`
+	Quantiles []*QuantileValue   `protobuf:"bytes,15,rep,name=quantiles,proto3" json:"quantiles,omitempty"`
+	// Monte Carlo only, when requested.
+	Uncertainty   *Uncertainty `protobuf:"bytes,16,opt,name=uncertainty,proto3" json:"uncertainty,omitempty"`
+	Trials        int32        `protobuf:"varint,17,opt,name=trials,proto3" json:"trials,omitempty"`       // trials run; more than requested in adaptive mode
+	Converged     bool         `protobuf:"varint,18,opt,name=converged,proto3" json:"converged,omitempty"` // adaptive mode: the tolerance was reached
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimulateResponse) Reset() {
+	*x = SimulateResponse{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimulateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimulateResponse) ProtoMessage() {}
+
+func (x *SimulateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimulateResponse.ProtoReflect.Descriptor instead.
+func (*SimulateResponse) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SimulateResponse) GetMean() float64 {
+	if x != nil {
+		return x.Mean
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetVariance() float64 {
+	if x != nil {
+		return x.Variance
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetStdDev() float64 {
+	if x != nil {
+		return x.StdDev
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetP50() float64 {
+	if x != nil {
+		return x.P50
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetP90() float64 {
+	if x != nil {
+		return x.P90
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetP99() float64 {
+	if x != nil {
+		return x.P99
 	}
 	return 0
 }
 
 func (x *SimulateResponse) GetEffectiveVersion() string {
 	if x != nil {
-		return x.EffectiveVersion
+		return x.EffectiveVersion
+	}
+	return ""
+}
+
+func (x *SimulateResponse) GetPmf() []float64 {
+	if x != nil {
+		return x.Pmf
+	}
+	return nil
+}
+
+func (x *SimulateResponse) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetHistogram() []*HistogramBucket {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *SimulateResponse) GetCdf() []float64 {
+	if x != nil {
+		return x.Cdf
+	}
+	return nil
+}
+
+func (x *SimulateResponse) GetQuantiles() []*QuantileValue {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *SimulateResponse) GetUncertainty() *Uncertainty {
+	if x != nil {
+		return x.Uncertainty
+	}
+	return nil
+}
+
+func (x *SimulateResponse) GetTrials() int32 {
+	if x != nil {
+		return x.Trials
+	}
+	return 0
+}
+
+func (x *SimulateResponse) GetConverged() bool {
+	if x != nil {
+		return x.Converged
+	}
+	return false
+}
+
+// One pull of a player's history, oldest first.
+type LuckPull struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pool          string                 `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`                   // pool pulled on; empty means ref.pool
+	ItemId        string                 `protobuf:"bytes,2,opt,name=item_id,json=itemId,proto3" json:"item_id,omitempty"` // classified as UP or off with the pool roster
+	Rarity        int32                  `protobuf:"varint,3,opt,name=rarity,proto3" json:"rarity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LuckPull) Reset() {
+	*x = LuckPull{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LuckPull) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LuckPull) ProtoMessage() {}
+
+func (x *LuckPull) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LuckPull.ProtoReflect.Descriptor instead.
+func (*LuckPull) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *LuckPull) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *LuckPull) GetItemId() string {
+	if x != nil {
+		return x.ItemId
+	}
+	return ""
+}
+
+func (x *LuckPull) GetRarity() int32 {
+	if x != nil {
+		return x.Rarity
+	}
+	return 0
+}
+
+// Rank a player's pull history against the engine's distribution of the same waits.
+type LuckPercentileRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Ref   *GameRef               `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	Pulls []*LuckPull            `protobuf:"bytes,2,rep,name=pulls,proto3" json:"pulls,omitempty"`
+	// The history starts at the account's first pull; otherwise each pity
+	// group's pulls up to its first success are skipped.
+	Fresh        bool      `protobuf:"varint,3,opt,name=fresh,proto3" json:"fresh,omitempty"`
+	Goal         TrialGoal `protobuf:"varint,4,opt,name=goal,proto3,enum=gacha.v1.TrialGoal" json:"goal,omitempty"`             // FIRST_UP (default) or FIRST_HIT
+	TargetRarity int32     `protobuf:"varint,5,opt,name=target_rarity,json=targetRarity,proto3" json:"target_rarity,omitempty"` // 0 means the top tier
+	// Monte Carlo trials per wait for pools the exact solver cannot handle;
+	// waits from the same state share them, and all sampled waits together get
+	// at most 1,000,000, split evenly.
+	Trials        int32  `protobuf:"varint,6,opt,name=trials,proto3" json:"trials,omitempty"`
+	Seed          uint64 `protobuf:"varint,7,opt,name=seed,proto3" json:"seed,omitempty"` // 0 picks a random seed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LuckPercentileRequest) Reset() {
+	*x = LuckPercentileRequest{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LuckPercentileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LuckPercentileRequest) ProtoMessage() {}
+
+func (x *LuckPercentileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LuckPercentileRequest.ProtoReflect.Descriptor instead.
+func (*LuckPercentileRequest) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *LuckPercentileRequest) GetRef() *GameRef {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *LuckPercentileRequest) GetPulls() []*LuckPull {
+	if x != nil {
+		return x.Pulls
+	}
+	return nil
+}
+
+func (x *LuckPercentileRequest) GetFresh() bool {
+	if x != nil {
+		return x.Fresh
+	}
+	return false
+}
+
+func (x *LuckPercentileRequest) GetGoal() TrialGoal {
+	if x != nil {
+		return x.Goal
+	}
+	return TrialGoal_TRIAL_GOAL_UNSPECIFIED
+}
+
+func (x *LuckPercentileRequest) GetTargetRarity() int32 {
+	if x != nil {
+		return x.TargetRarity
+	}
+	return 0
+}
+
+func (x *LuckPercentileRequest) GetTrials() int32 {
+	if x != nil {
+		return x.Trials
+	}
+	return 0
+}
+
+func (x *LuckPercentileRequest) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+// One wait: the pulls from one success (or the first pull) to the next.
+type LuckWait struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pool          string                 `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"` // pool the wait started on
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`  // index in pulls of the wait's last pull
+	Draws         int32                  `protobuf:"varint,3,opt,name=draws,proto3" json:"draws,omitempty"`
+	Open          bool                   `protobuf:"varint,4,opt,name=open,proto3" json:"open,omitempty"`      // no success yet; ranked as if the next pull succeeded
+	Exact         bool                   `protobuf:"varint,5,opt,name=exact,proto3" json:"exact,omitempty"`    // ranked against the exact distribution, not samples
+	Mean          float64                `protobuf:"fixed64,6,opt,name=mean,proto3" json:"mean,omitempty"`     // expected draws from the same start
+	Better        float64                `protobuf:"fixed64,7,opt,name=better,proto3" json:"better,omitempty"` // share of players needing more draws
+	Worse         float64                `protobuf:"fixed64,8,opt,name=worse,proto3" json:"worse,omitempty"`   // share of players needing fewer draws
+	Luck          float64                `protobuf:"fixed64,9,opt,name=luck,proto3" json:"luck,omitempty"`     // better plus half the ties; 0.5 is average
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LuckWait) Reset() {
+	*x = LuckWait{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LuckWait) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LuckWait) ProtoMessage() {}
+
+func (x *LuckWait) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LuckWait.ProtoReflect.Descriptor instead.
+func (*LuckWait) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *LuckWait) GetPool() string {
+	if x != nil {
+		return x.Pool
 	}
 	return ""
 }
 
+func (x *LuckWait) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *LuckWait) GetDraws() int32 {
+	if x != nil {
+		return x.Draws
+	}
+	return 0
+}
+
+func (x *LuckWait) GetOpen() bool {
+	if x != nil {
+		return x.Open
+	}
+	return false
+}
+
+func (x *LuckWait) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *LuckWait) GetMean() float64 {
+	if x != nil {
+		return x.Mean
+	}
+	return 0
+}
+
+func (x *LuckWait) GetBetter() float64 {
+	if x != nil {
+		return x.Better
+	}
+	return 0
+}
+
+func (x *LuckWait) GetWorse() float64 {
+	if x != nil {
+		return x.Worse
+	}
+	return 0
+}
+
+func (x *LuckWait) GetLuck() float64 {
+	if x != nil {
+		return x.Luck
+	}
+	return 0
+}
+
+type LuckPercentileResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Waits []*LuckWait            `protobuf:"bytes,1,rep,name=waits,proto3" json:"waits,omitempty"`
+	// Totals over completed waits, ranked against the sum of their distributions.
+	Completed     int32   `protobuf:"varint,2,opt,name=completed,proto3" json:"completed,omitempty"`
+	Draws         int32   `protobuf:"varint,3,opt,name=draws,proto3" json:"draws,omitempty"`
+	Expected      float64 `protobuf:"fixed64,4,opt,name=expected,proto3" json:"expected,omitempty"`
+	Better        float64 `protobuf:"fixed64,5,opt,name=better,proto3" json:"better,omitempty"`
+	Worse         float64 `protobuf:"fixed64,6,opt,name=worse,proto3" json:"worse,omitempty"`
+	Luck          float64 `protobuf:"fixed64,7,opt,name=luck,proto3" json:"luck,omitempty"`
+	MeanLuck      float64 `protobuf:"fixed64,8,opt,name=mean_luck,json=meanLuck,proto3" json:"mean_luck,omitempty"` // average luck of completed waits
+	Unmapped      int32   `protobuf:"varint,9,opt,name=unmapped,proto3" json:"unmapped,omitempty"`                  // pulls of items missing from the rosters, counted as off-banner
+	Inconsistent  int32   `protobuf:"varint,10,opt,name=inconsistent,proto3" json:"inconsistent,omitempty"`         // pulls the pool rules rule out
+	Seed          uint64  `protobuf:"varint,11,opt,name=seed,proto3" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LuckPercentileResponse) Reset() {
+	*x = LuckPercentileResponse{}
+	mi := &file_gacha_v1_gacha_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LuckPercentileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LuckPercentileResponse) ProtoMessage() {}
+
+func (x *LuckPercentileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gacha_v1_gacha_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LuckPercentileResponse.ProtoReflect.Descriptor instead.
+func (*LuckPercentileResponse) Descriptor() ([]byte, []int) {
+	return file_gacha_v1_gacha_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *LuckPercentileResponse) GetWaits() []*LuckWait {
+	if x != nil {
+		return x.Waits
+	}
+	return nil
+}
+
+func (x *LuckPercentileResponse) GetCompleted() int32 {
+	if x != nil {
+		return x.Completed
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetDraws() int32 {
+	if x != nil {
+		return x.Draws
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetExpected() float64 {
+	if x != nil {
+		return x.Expected
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetBetter() float64 {
+	if x != nil {
+		return x.Better
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetWorse() float64 {
+	if x != nil {
+		return x.Worse
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetLuck() float64 {
+	if x != nil {
+		return x.Luck
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetMeanLuck() float64 {
+	if x != nil {
+		return x.MeanLuck
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetUnmapped() int32 {
+	if x != nil {
+		return x.Unmapped
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetInconsistent() int32 {
+	if x != nil {
+		return x.Inconsistent
+	}
+	return 0
+}
+
+func (x *LuckPercentileResponse) GetSeed() uint64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
 var File_gacha_v1_gacha_proto protoreflect.FileDescriptor
 
 const file_gacha_v1_gacha_proto_rawDesc = "" +
@@ -1289,7 +2880,7 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\x04pity\x18\x03 \x01(\x05R\x04pity\x12/\n" +
 	"\x04soft\x18\x04 \x01(\v2\x1b.gacha.v1.SoftPityOverridesR\x04soft\x121\n" +
 	"\x06banner\x18\x05 \x01(\v2\x19.gacha.v1.BannerOverridesR\x06banner\x12\x18\n" +
-	"\acushion\x18\x06 \x01(\x05R\acushion\"\x9c\x03\n" +
+	"\acushion\x18\x06 \x01(\x05R\acushion\"\xbb\x03\n" +
 	"\x0fResolveResponse\x12\x15\n" +
 	"\x06p_base\x18\x01 \x01(\x01R\x05pBase\x12\x12\n" +
 	"\x04pity\x18\x02 \x01(\x05R\x04pity\x123\n" +
@@ -1301,7 +2892,9 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\tincrement\x18\b \x01(\x01R\tincrement\x12\x1b\n" +
 	"\toff_probs\x18\x14 \x03(\x01R\boffProbs\x12\x17\n" +
 	"\amax_off\x18\x15 \x01(\x05R\x06maxOff\x12\x18\n" +
-	"\acushion\x18\x1e \x01(\x05R\acushion\x12+\n" +
+	"\acushion\x18\x1e \x01(\x05R\acushion\x12\x1d\n" +
+	"\n" +
+	"pity_group\x18\x1f \x01(\tR\tpityGroup\x12+\n" +
 	"\x11effective_version\x18( \x01(\tR\x10effectiveVersion\x12\x14\n" +
 	"\x05notes\x18) \x01(\tR\x05notes\"X\n" +
 	"\fDrawNRequest\x12#\n" +
@@ -1319,10 +2912,13 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\acushion\x18\x06 \x01(\x05R\acushion\"=\n" +
 	"\x11DrawNPityResponse\x12\x12\n" +
 	"\x04hits\x18\x01 \x03(\bR\x04hits\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x05R\x05count\"6\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\x84\x01\n" +
 	"\rBannerOutcome\x12\x10\n" +
 	"\x03hit\x18\x01 \x01(\bR\x03hit\x12\x13\n" +
-	"\x05is_up\x18\x02 \x01(\bR\x04isUp\"\xf0\x01\n" +
+	"\x05is_up\x18\x02 \x01(\bR\x04isUp\x12\x16\n" +
+	"\x06rarity\x18\x03 \x01(\x05R\x06rarity\x12\x17\n" +
+	"\aitem_id\x18\x04 \x01(\tR\x06itemId\x12\x1b\n" +
+	"\ton_target\x18\x05 \x01(\bR\bonTarget\"\xc4\x02\n" +
 	"\x12DrawNBannerRequest\x12#\n" +
 	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12\f\n" +
 	"\x01n\x18\x02 \x01(\x05R\x01n\x12\x15\n" +
@@ -1330,13 +2926,75 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\x04pity\x18\x04 \x01(\x05R\x04pity\x12/\n" +
 	"\x04soft\x18\x05 \x01(\v2\x1b.gacha.v1.SoftPityOverridesR\x04soft\x12\x18\n" +
 	"\acushion\x18\x06 \x01(\x05R\acushion\x121\n" +
-	"\x06banner\x18\a \x01(\v2\x19.gacha.v1.BannerOverridesR\x06banner\"\xa6\x01\n" +
+	"\x06banner\x18\a \x01(\v2\x19.gacha.v1.BannerOverridesR\x06banner\x12\x1b\n" +
+	"\tplayer_id\x18\b \x01(\tR\bplayerId\x12\x14\n" +
+	"\x05multi\x18\t \x01(\bR\x05multi\x12\x1f\n" +
+	"\vclient_seed\x18\n" +
+	" \x01(\tR\n" +
+	"clientSeed\"\x93\x02\n" +
 	"\x13DrawNBannerResponse\x121\n" +
 	"\aresults\x18\x01 \x03(\v2\x17.gacha.v1.BannerOutcomeR\aresults\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x05R\x05count\x12'\n" +
 	"\x0fguaranteed_next\x18\x03 \x01(\bR\x0eguaranteedNext\x12\x1d\n" +
 	"\n" +
-	"off_streak\x18\x04 \x01(\x05R\toffStreak\"\xbb\x02\n" +
+	"off_streak\x18\x04 \x01(\x05R\toffStreak\x12\x1f\n" +
+	"\vfate_points\x18\x05 \x01(\x05R\n" +
+	"fatePoints\x12!\n" +
+	"\fspark_points\x18\x06 \x01(\x05R\vsparkPoints\x12'\n" +
+	"\x04fair\x18\a \x01(\v2\x13.gacha.v1.FairProofR\x04fair\"\xc1\x01\n" +
+	"\fTierSnapshot\x12\x16\n" +
+	"\x06rarity\x18\x01 \x01(\x05R\x06rarity\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x1d\n" +
+	"\n" +
+	"off_streak\x18\x03 \x01(\x05R\toffStreak\x12'\n" +
+	"\x0fguaranteed_next\x18\x04 \x01(\bR\x0eguaranteedNext\x12\x1f\n" +
+	"\vfate_points\x18\x05 \x01(\x05R\n" +
+	"fatePoints\x12\x1a\n" +
+	"\bradiance\x18\x06 \x01(\x05R\bradiance\"\x86\x03\n" +
+	"\tFairProof\x12\x1e\n" +
+	"\n" +
+	"commitment\x18\x01 \x01(\tR\n" +
+	"commitment\x12\x1f\n" +
+	"\vserver_seed\x18\x02 \x01(\tR\n" +
+	"serverSeed\x12\x1f\n" +
+	"\vclient_seed\x18\x03 \x01(\tR\n" +
+	"clientSeed\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\x04R\x05nonce\x12'\n" +
+	"\x0fnext_commitment\x18\x05 \x01(\tR\x0enextCommitment\x12,\n" +
+	"\x05start\x18\x06 \x03(\v2\x16.gacha.v1.TierSnapshotR\x05start\x12=\n" +
+	"\bselected\x18\a \x03(\v2!.gacha.v1.FairProof.SelectedEntryR\bselected\x12\x14\n" +
+	"\x05batch\x18\b \x01(\x05R\x05batch\x12\x18\n" +
+	"\aversion\x18\t \x01(\tR\aversion\x1a;\n" +
+	"\rSelectedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\\\n" +
+	"\x18GetFairCommitmentRequest\x12#\n" +
+	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12\x1b\n" +
+	"\tplayer_id\x18\x02 \x01(\tR\bplayerId\"Q\n" +
+	"\x19GetFairCommitmentResponse\x12\x1e\n" +
+	"\n" +
+	"commitment\x18\x01 \x01(\tR\n" +
+	"commitment\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\x04R\x05nonce\"p\n" +
+	"\x13SetSelectionRequest\x12#\n" +
+	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12\x1b\n" +
+	"\tplayer_id\x18\x02 \x01(\tR\bplayerId\x12\x17\n" +
+	"\aitem_id\x18\x03 \x01(\tR\x06itemId\"\x8f\x02\n" +
+	"\x14SetSelectionResponse\x12\x17\n" +
+	"\aitem_id\x18\x01 \x01(\tR\x06itemId\x12(\n" +
+	"\x10previous_item_id\x18\x02 \x01(\tR\x0epreviousItemId\x12\x1a\n" +
+	"\bswitched\x18\x03 \x01(\bR\bswitched\x12\x1b\n" +
+	"\tpity_kept\x18\x04 \x01(\bR\bpityKept\x12\x1b\n" +
+	"\tfate_kept\x18\x05 \x01(\bR\bfateKept\x12\x14\n" +
+	"\x05count\x18\x06 \x01(\x05R\x05count\x12'\n" +
+	"\x0fguaranteed_next\x18\a \x01(\bR\x0eguaranteedNext\x12\x1f\n" +
+	"\vfate_points\x18\b \x01(\x05R\n" +
+	"fatePoints\"\x87\x01\n" +
+	"\vSimulateLeg\x12#\n" +
+	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12\x16\n" +
+	"\x06copies\x18\x02 \x01(\x05R\x06copies\x12\x18\n" +
+	"\acushion\x18\x03 \x01(\x05R\acushion\x12!\n" +
+	"\fspark_points\x18\x04 \x01(\x05R\vsparkPoints\"\xd4\x06\n" +
 	"\x0fSimulateRequest\x12#\n" +
 	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12'\n" +
 	"\x04goal\x18\x02 \x01(\x0e2\x13.gacha.v1.TrialGoalR\x04goal\x12\x16\n" +
@@ -1347,7 +3005,50 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\x04soft\x18\f \x01(\v2\x1b.gacha.v1.SoftPityOverridesR\x04soft\x12\x18\n" +
 	"\acushion\x18\r \x01(\x05R\acushion\x121\n" +
 	"\x06banner\x18\x0e \x01(\v2\x19.gacha.v1.BannerOverridesR\x06banner\x12\x19\n" +
-	"\bbudget_n\x18\x14 \x01(\x05R\abudgetN\"\xbe\x01\n" +
+	"\bbudget_n\x18\x14 \x01(\x05R\abudgetN\x12#\n" +
+	"\rtarget_rarity\x18\x15 \x01(\x05R\ftargetRarity\x12\x14\n" +
+	"\x05exact\x18\x16 \x01(\bR\x05exact\x12\x12\n" +
+	"\x04seed\x18\x17 \x01(\x04R\x04seed\x12\x16\n" +
+	"\x06copies\x18\x18 \x01(\x05R\x06copies\x12)\n" +
+	"\x04legs\x18\x19 \x03(\v2\x15.gacha.v1.SimulateLegR\x04legs\x12!\n" +
+	"\fspark_points\x18\x1a \x01(\x05R\vsparkPoints\x12+\n" +
+	"\x11reconvert_refunds\x18\x1b \x01(\bR\x10reconvertRefunds\x12\x14\n" +
+	"\x05multi\x18\x1c \x01(\bR\x05multi\x12!\n" +
+	"\fbucket_width\x18\x1d \x01(\x05R\vbucketWidth\x12\x1c\n" +
+	"\tquantiles\x18\x1e \x03(\x01R\tquantiles\x12\x10\n" +
+	"\x03cdf\x18\x1f \x01(\bR\x03cdf\x12 \n" +
+	"\vuncertainty\x18  \x01(\bR\vuncertainty\x12\x1e\n" +
+	"\n" +
+	"confidence\x18! \x01(\x01R\n" +
+	"confidence\x12\x1c\n" +
+	"\tresamples\x18\" \x01(\x05R\tresamples\x12/\n" +
+	"\badaptive\x18# \x01(\x0e2\x13.gacha.v1.StatisticR\badaptive\x12\x1c\n" +
+	"\ttolerance\x18$ \x01(\x01R\ttolerance\x12\x1d\n" +
+	"\n" +
+	"max_trials\x18% \x01(\x05R\tmaxTrials\"I\n" +
+	"\bEstimate\x12\x17\n" +
+	"\astd_err\x18\x01 \x01(\x01R\x06stdErr\x12\x10\n" +
+	"\x03low\x18\x02 \x01(\x01R\x03low\x12\x12\n" +
+	"\x04high\x18\x03 \x01(\x01R\x04high\"\xc2\x02\n" +
+	"\vUncertainty\x12&\n" +
+	"\x04mean\x18\x01 \x01(\v2\x12.gacha.v1.EstimateR\x04mean\x12.\n" +
+	"\bvariance\x18\x02 \x01(\v2\x12.gacha.v1.EstimateR\bvariance\x12+\n" +
+	"\astd_dev\x18\x03 \x01(\v2\x12.gacha.v1.EstimateR\x06stdDev\x12$\n" +
+	"\x03p50\x18\x04 \x01(\v2\x12.gacha.v1.EstimateR\x03p50\x12$\n" +
+	"\x03p90\x18\x05 \x01(\v2\x12.gacha.v1.EstimateR\x03p90\x12$\n" +
+	"\x03p99\x18\x06 \x01(\v2\x12.gacha.v1.EstimateR\x03p99\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\a \x01(\x01R\n" +
+	"confidence\x12\x1c\n" +
+	"\tresamples\x18\b \x01(\x05R\tresamples\"[\n" +
+	"\x0fHistogramBucket\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x05R\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\x05R\x02to\x12\x12\n" +
+	"\x04prob\x18\x03 \x01(\x01R\x04prob\x12\x10\n" +
+	"\x03cum\x18\x04 \x01(\x01R\x03cum\"3\n" +
+	"\rQuantileValue\x12\f\n" +
+	"\x01q\x18\x01 \x01(\x01R\x01q\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"\xd5\x03\n" +
 	"\x10SimulateResponse\x12\x12\n" +
 	"\x04mean\x18\x01 \x01(\x01R\x04mean\x12\x1a\n" +
 	"\bvariance\x18\x02 \x01(\x01R\bvariance\x12\x17\n" +
@@ -1356,7 +3057,50 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\x03p90\x18\x05 \x01(\x01R\x03p90\x12\x10\n" +
 	"\x03p99\x18\x06 \x01(\x01R\x03p99\x12+\n" +
 	"\x11effective_version\x18\n" +
-	" \x01(\tR\x10effectiveVersion*u\n" +
+	" \x01(\tR\x10effectiveVersion\x12\x10\n" +
+	"\x03pmf\x18\v \x03(\x01R\x03pmf\x12\x12\n" +
+	"\x04seed\x18\f \x01(\x04R\x04seed\x127\n" +
+	"\thistogram\x18\r \x03(\v2\x19.gacha.v1.HistogramBucketR\thistogram\x12\x10\n" +
+	"\x03cdf\x18\x0e \x03(\x01R\x03cdf\x125\n" +
+	"\tquantiles\x18\x0f \x03(\v2\x17.gacha.v1.QuantileValueR\tquantiles\x127\n" +
+	"\vuncertainty\x18\x10 \x01(\v2\x15.gacha.v1.UncertaintyR\vuncertainty\x12\x16\n" +
+	"\x06trials\x18\x11 \x01(\x05R\x06trials\x12\x1c\n" +
+	"\tconverged\x18\x12 \x01(\bR\tconverged\"O\n" +
+	"\bLuckPull\x12\x12\n" +
+	"\x04pool\x18\x01 \x01(\tR\x04pool\x12\x17\n" +
+	"\aitem_id\x18\x02 \x01(\tR\x06itemId\x12\x16\n" +
+	"\x06rarity\x18\x03 \x01(\x05R\x06rarity\"\xf6\x01\n" +
+	"\x15LuckPercentileRequest\x12#\n" +
+	"\x03ref\x18\x01 \x01(\v2\x11.gacha.v1.GameRefR\x03ref\x12(\n" +
+	"\x05pulls\x18\x02 \x03(\v2\x12.gacha.v1.LuckPullR\x05pulls\x12\x14\n" +
+	"\x05fresh\x18\x03 \x01(\bR\x05fresh\x12'\n" +
+	"\x04goal\x18\x04 \x01(\x0e2\x13.gacha.v1.TrialGoalR\x04goal\x12#\n" +
+	"\rtarget_rarity\x18\x05 \x01(\x05R\ftargetRarity\x12\x16\n" +
+	"\x06trials\x18\x06 \x01(\x05R\x06trials\x12\x12\n" +
+	"\x04seed\x18\a \x01(\x04R\x04seed\"\xc6\x01\n" +
+	"\bLuckWait\x12\x12\n" +
+	"\x04pool\x18\x01 \x01(\tR\x04pool\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\x12\x14\n" +
+	"\x05draws\x18\x03 \x01(\x05R\x05draws\x12\x12\n" +
+	"\x04open\x18\x04 \x01(\bR\x04open\x12\x14\n" +
+	"\x05exact\x18\x05 \x01(\bR\x05exact\x12\x12\n" +
+	"\x04mean\x18\x06 \x01(\x01R\x04mean\x12\x16\n" +
+	"\x06better\x18\a \x01(\x01R\x06better\x12\x14\n" +
+	"\x05worse\x18\b \x01(\x01R\x05worse\x12\x12\n" +
+	"\x04luck\x18\t \x01(\x01R\x04luck\"\xc5\x02\n" +
+	"\x16LuckPercentileResponse\x12(\n" +
+	"\x05waits\x18\x01 \x03(\v2\x12.gacha.v1.LuckWaitR\x05waits\x12\x1c\n" +
+	"\tcompleted\x18\x02 \x01(\x05R\tcompleted\x12\x14\n" +
+	"\x05draws\x18\x03 \x01(\x05R\x05draws\x12\x1a\n" +
+	"\bexpected\x18\x04 \x01(\x01R\bexpected\x12\x16\n" +
+	"\x06better\x18\x05 \x01(\x01R\x06better\x12\x14\n" +
+	"\x05worse\x18\x06 \x01(\x01R\x05worse\x12\x12\n" +
+	"\x04luck\x18\a \x01(\x01R\x04luck\x12\x1b\n" +
+	"\tmean_luck\x18\b \x01(\x01R\bmeanLuck\x12\x1a\n" +
+	"\bunmapped\x18\t \x01(\x05R\bunmapped\x12\"\n" +
+	"\finconsistent\x18\n" +
+	" \x01(\x05R\finconsistent\x12\x12\n" +
+	"\x04seed\x18\v \x01(\x04R\x04seed*u\n" +
 	"\fSoftPityMode\x12\x1e\n" +
 	"\x1aSOFT_PITY_MODE_UNSPECIFIED\x10\x00\x12\x1e\n" +
 	"\x1aSOFT_PITY_MODE_TARGET_RAMP\x10\x01\x12%\n" +
@@ -1365,18 +3109,31 @@ const file_gacha_v1_gacha_proto_rawDesc = "" +
 	"\x12EASING_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rEASING_LINEAR\x10\x01\x12\x18\n" +
 	"\x14EASING_EASE_OUT_QUAD\x10\x02\x12\x1c\n" +
-	"\x18EASING_EASE_IN_OUT_CUBIC\x10\x03*w\n" +
+	"\x18EASING_EASE_IN_OUT_CUBIC\x10\x03*\xa7\x01\n" +
 	"\tTrialGoal\x12\x1a\n" +
 	"\x16TRIAL_GOAL_UNSPECIFIED\x10\x00\x12\x18\n" +
 	"\x14TRIAL_GOAL_FIRST_HIT\x10\x01\x12\x17\n" +
 	"\x13TRIAL_GOAL_FIRST_UP\x10\x02\x12\x1b\n" +
-	"\x17TRIAL_GOAL_FIXED_BUDGET\x10\x032\xdd\x02\n" +
+	"\x17TRIAL_GOAL_FIXED_BUDGET\x10\x03\x12\x15\n" +
+	"\x11TRIAL_GOAL_NTH_UP\x10\x04\x12\x17\n" +
+	"\x13TRIAL_GOAL_COMBINED\x10\x05*\xa2\x01\n" +
+	"\tStatistic\x12\x19\n" +
+	"\x15STATISTIC_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSTATISTIC_MEAN\x10\x01\x12\x16\n" +
+	"\x12STATISTIC_VARIANCE\x10\x02\x12\x15\n" +
+	"\x11STATISTIC_STD_DEV\x10\x03\x12\x11\n" +
+	"\rSTATISTIC_P50\x10\x04\x12\x11\n" +
+	"\rSTATISTIC_P90\x10\x05\x12\x11\n" +
+	"\rSTATISTIC_P99\x10\x062\xdf\x04\n" +
 	"\fGachaService\x12>\n" +
 	"\aResolve\x12\x18.gacha.v1.ResolveRequest\x1a\x19.gacha.v1.ResolveResponse\x128\n" +
 	"\x05DrawN\x12\x16.gacha.v1.DrawNRequest\x1a\x17.gacha.v1.DrawNResponse\x12D\n" +
 	"\tDrawNPity\x12\x1a.gacha.v1.DrawNPityRequest\x1a\x1b.gacha.v1.DrawNPityResponse\x12J\n" +
 	"\vDrawNBanner\x12\x1c.gacha.v1.DrawNBannerRequest\x1a\x1d.gacha.v1.DrawNBannerResponse\x12A\n" +
-	"\bSimulate\x12\x19.gacha.v1.SimulateRequest\x1a\x1a.gacha.v1.SimulateResponseB9Z7github.com/xtding233/gacha-backend/gen/gacha/v1;gachav1b\x06proto3"
+	"\bSimulate\x12\x19.gacha.v1.SimulateRequest\x1a\x1a.gacha.v1.SimulateResponse\x12M\n" +
+	"\fSetSelection\x12\x1d.gacha.v1.SetSelectionRequest\x1a\x1e.gacha.v1.SetSelectionResponse\x12\\\n" +
+	"\x11GetFairCommitment\x12\".gacha.v1.GetFairCommitmentRequest\x1a#.gacha.v1.GetFairCommitmentResponse\x12S\n" +
+	"\x0eLuckPercentile\x12\x1f.gacha.v1.LuckPercentileRequest\x1a .gacha.v1.LuckPercentileResponseB9Z7github.com/xtding233/gacha-backend/gen/gacha/v1;gachav1b\x06proto3"
 
 var (
 	file_gacha_v1_gacha_proto_rawDescOnce sync.Once
@@ -1390,61 +3147,105 @@ func file_gacha_v1_gacha_proto_rawDescGZIP() []byte {
 	return file_gacha_v1_gacha_proto_rawDescData
 }
 
-var file_gacha_v1_gacha_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_gacha_v1_gacha_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_gacha_v1_gacha_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_gacha_v1_gacha_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_gacha_v1_gacha_proto_goTypes = []any{
-	(SoftPityMode)(0),           // 0: gacha.v1.SoftPityMode
-	(Easing)(0),                 // 1: gacha.v1.Easing
-	(TrialGoal)(0),              // 2: gacha.v1.TrialGoal
-	(*GameRef)(nil),             // 3: gacha.v1.GameRef
-	(*SoftPityOverrides)(nil),   // 4: gacha.v1.SoftPityOverrides
-	(*BannerOverrides)(nil),     // 5: gacha.v1.BannerOverrides
-	(*ResolveRequest)(nil),      // 6: gacha.v1.ResolveRequest
-	(*ResolveResponse)(nil),     // 7: gacha.v1.ResolveResponse
-	(*DrawNRequest)(nil),        // 8: gacha.v1.DrawNRequest
-	(*DrawNResponse)(nil),       // 9: gacha.v1.DrawNResponse
-	(*DrawNPityRequest)(nil),    // 10: gacha.v1.DrawNPityRequest
-	(*DrawNPityResponse)(nil),   // 11: gacha.v1.DrawNPityResponse
-	(*BannerOutcome)(nil),       // 12: gacha.v1.BannerOutcome
-	(*DrawNBannerRequest)(nil),  // 13: gacha.v1.DrawNBannerRequest
-	(*DrawNBannerResponse)(nil), // 14: gacha.v1.DrawNBannerResponse
-	(*SimulateRequest)(nil),     // 15: gacha.v1.SimulateRequest
-	(*SimulateResponse)(nil),    // 16: gacha.v1.SimulateResponse
+	(SoftPityMode)(0),                 // 0: gacha.v1.SoftPityMode
+	(Easing)(0),                       // 1: gacha.v1.Easing
+	(TrialGoal)(0),                    // 2: gacha.v1.TrialGoal
+	(Statistic)(0),                    // 3: gacha.v1.Statistic
+	(*GameRef)(nil),                   // 4: gacha.v1.GameRef
+	(*SoftPityOverrides)(nil),         // 5: gacha.v1.SoftPityOverrides
+	(*BannerOverrides)(nil),           // 6: gacha.v1.BannerOverrides
+	(*ResolveRequest)(nil),            // 7: gacha.v1.ResolveRequest
+	(*ResolveResponse)(nil),           // 8: gacha.v1.ResolveResponse
+	(*DrawNRequest)(nil),              // 9: gacha.v1.DrawNRequest
+	(*DrawNResponse)(nil),             // 10: gacha.v1.DrawNResponse
+	(*DrawNPityRequest)(nil),          // 11: gacha.v1.DrawNPityRequest
+	(*DrawNPityResponse)(nil),         // 12: gacha.v1.DrawNPityResponse
+	(*BannerOutcome)(nil),             // 13: gacha.v1.BannerOutcome
+	(*DrawNBannerRequest)(nil),        // 14: gacha.v1.DrawNBannerRequest
+	(*DrawNBannerResponse)(nil),       // 15: gacha.v1.DrawNBannerResponse
+	(*TierSnapshot)(nil),              // 16: gacha.v1.TierSnapshot
+	(*FairProof)(nil),                 // 17: gacha.v1.FairProof
+	(*GetFairCommitmentRequest)(nil),  // 18: gacha.v1.GetFairCommitmentRequest
+	(*GetFairCommitmentResponse)(nil), // 19: gacha.v1.GetFairCommitmentResponse
+	(*SetSelectionRequest)(nil),       // 20: gacha.v1.SetSelectionRequest
+	(*SetSelectionResponse)(nil),      // 21: gacha.v1.SetSelectionResponse
+	(*SimulateLeg)(nil),               // 22: gacha.v1.SimulateLeg
+	(*SimulateRequest)(nil),           // 23: gacha.v1.SimulateRequest
+	(*Estimate)(nil),                  // 24: gacha.v1.Estimate
+	(*Uncertainty)(nil),               // 25: gacha.v1.Uncertainty
+	(*HistogramBucket)(nil),           // 26: gacha.v1.HistogramBucket
+	(*QuantileValue)(nil),             // 27: gacha.v1.QuantileValue
+	(*SimulateResponse)(nil),          // 28: gacha.v1.SimulateResponse
+	(*LuckPull)(nil),                  // 29: gacha.v1.LuckPull
+	(*LuckPercentileRequest)(nil),     // 30: gacha.v1.LuckPercentileRequest
+	(*LuckWait)(nil),                  // 31: gacha.v1.LuckWait
+	(*LuckPercentileResponse)(nil),    // 32: gacha.v1.LuckPercentileResponse
+	nil,                               // 33: gacha.v1.FairProof.SelectedEntry
 }
 var file_gacha_v1_gacha_proto_depIdxs = []int32{
 	0,  // 0: gacha.v1.SoftPityOverrides.mode:type_name -> gacha.v1.SoftPityMode
 	1,  // 1: gacha.v1.SoftPityOverrides.easing:type_name -> gacha.v1.Easing
-	3,  // 2: gacha.v1.ResolveRequest.ref:type_name -> gacha.v1.GameRef
-	4,  // 3: gacha.v1.ResolveRequest.soft:type_name -> gacha.v1.SoftPityOverrides
-	5,  // 4: gacha.v1.ResolveRequest.banner:type_name -> gacha.v1.BannerOverrides
+	4,  // 2: gacha.v1.ResolveRequest.ref:type_name -> gacha.v1.GameRef
+	5,  // 3: gacha.v1.ResolveRequest.soft:type_name -> gacha.v1.SoftPityOverrides
+	6,  // 4: gacha.v1.ResolveRequest.banner:type_name -> gacha.v1.BannerOverrides
 	0,  // 5: gacha.v1.ResolveResponse.soft_mode:type_name -> gacha.v1.SoftPityMode
 	1,  // 6: gacha.v1.ResolveResponse.easing:type_name -> gacha.v1.Easing
-	3,  // 7: gacha.v1.DrawNRequest.ref:type_name -> gacha.v1.GameRef
-	3,  // 8: gacha.v1.DrawNPityRequest.ref:type_name -> gacha.v1.GameRef
-	4,  // 9: gacha.v1.DrawNPityRequest.soft:type_name -> gacha.v1.SoftPityOverrides
-	3,  // 10: gacha.v1.DrawNBannerRequest.ref:type_name -> gacha.v1.GameRef
-	4,  // 11: gacha.v1.DrawNBannerRequest.soft:type_name -> gacha.v1.SoftPityOverrides
-	5,  // 12: gacha.v1.DrawNBannerRequest.banner:type_name -> gacha.v1.BannerOverrides
-	12, // 13: gacha.v1.DrawNBannerResponse.results:type_name -> gacha.v1.BannerOutcome
-	3,  // 14: gacha.v1.SimulateRequest.ref:type_name -> gacha.v1.GameRef
-	2,  // 15: gacha.v1.SimulateRequest.goal:type_name -> gacha.v1.TrialGoal
-	4,  // 16: gacha.v1.SimulateRequest.soft:type_name -> gacha.v1.SoftPityOverrides
-	5,  // 17: gacha.v1.SimulateRequest.banner:type_name -> gacha.v1.BannerOverrides
-	6,  // 18: gacha.v1.GachaService.Resolve:input_type -> gacha.v1.ResolveRequest
-	8,  // 19: gacha.v1.GachaService.DrawN:input_type -> gacha.v1.DrawNRequest
-	10, // 20: gacha.v1.GachaService.DrawNPity:input_type -> gacha.v1.DrawNPityRequest
-	13, // 21: gacha.v1.GachaService.DrawNBanner:input_type -> gacha.v1.DrawNBannerRequest
-	15, // 22: gacha.v1.GachaService.Simulate:input_type -> gacha.v1.SimulateRequest
-	7,  // 23: gacha.v1.GachaService.Resolve:output_type -> gacha.v1.ResolveResponse
-	9,  // 24: gacha.v1.GachaService.DrawN:output_type -> gacha.v1.DrawNResponse
-	11, // 25: gacha.v1.GachaService.DrawNPity:output_type -> gacha.v1.DrawNPityResponse
-	14, // 26: gacha.v1.GachaService.DrawNBanner:output_type -> gacha.v1.DrawNBannerResponse
-	16, // 27: gacha.v1.GachaService.Simulate:output_type -> gacha.v1.SimulateResponse
-	23, // [23:28] is the sub-list for method output_type
-	18, // [18:23] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	4,  // 7: gacha.v1.DrawNRequest.ref:type_name -> gacha.v1.GameRef
+	4,  // 8: gacha.v1.DrawNPityRequest.ref:type_name -> gacha.v1.GameRef
+	5,  // 9: gacha.v1.DrawNPityRequest.soft:type_name -> gacha.v1.SoftPityOverrides
+	4,  // 10: gacha.v1.DrawNBannerRequest.ref:type_name -> gacha.v1.GameRef
+	5,  // 11: gacha.v1.DrawNBannerRequest.soft:type_name -> gacha.v1.SoftPityOverrides
+	6,  // 12: gacha.v1.DrawNBannerRequest.banner:type_name -> gacha.v1.BannerOverrides
+	13, // 13: gacha.v1.DrawNBannerResponse.results:type_name -> gacha.v1.BannerOutcome
+	17, // 14: gacha.v1.DrawNBannerResponse.fair:type_name -> gacha.v1.FairProof
+	16, // 15: gacha.v1.FairProof.start:type_name -> gacha.v1.TierSnapshot
+	33, // 16: gacha.v1.FairProof.selected:type_name -> gacha.v1.FairProof.SelectedEntry
+	4,  // 17: gacha.v1.GetFairCommitmentRequest.ref:type_name -> gacha.v1.GameRef
+	4,  // 18: gacha.v1.SetSelectionRequest.ref:type_name -> gacha.v1.GameRef
+	4,  // 19: gacha.v1.SimulateLeg.ref:type_name -> gacha.v1.GameRef
+	4,  // 20: gacha.v1.SimulateRequest.ref:type_name -> gacha.v1.GameRef
+	2,  // 21: gacha.v1.SimulateRequest.goal:type_name -> gacha.v1.TrialGoal
+	5,  // 22: gacha.v1.SimulateRequest.soft:type_name -> gacha.v1.SoftPityOverrides
+	6,  // 23: gacha.v1.SimulateRequest.banner:type_name -> gacha.v1.BannerOverrides
+	22, // 24: gacha.v1.SimulateRequest.legs:type_name -> gacha.v1.SimulateLeg
+	3,  // 25: gacha.v1.SimulateRequest.adaptive:type_name -> gacha.v1.Statistic
+	24, // 26: gacha.v1.Uncertainty.mean:type_name -> gacha.v1.Estimate
+	24, // 27: gacha.v1.Uncertainty.variance:type_name -> gacha.v1.Estimate
+	24, // 28: gacha.v1.Uncertainty.std_dev:type_name -> gacha.v1.Estimate
+	24, // 29: gacha.v1.Uncertainty.p50:type_name -> gacha.v1.Estimate
+	24, // 30: gacha.v1.Uncertainty.p90:type_name -> gacha.v1.Estimate
+	24, // 31: gacha.v1.Uncertainty.p99:type_name -> gacha.v1.Estimate
+	26, // 32: gacha.v1.SimulateResponse.histogram:type_name -> gacha.v1.HistogramBucket
+	27, // 33: gacha.v1.SimulateResponse.quantiles:type_name -> gacha.v1.QuantileValue
+	25, // 34: gacha.v1.SimulateResponse.uncertainty:type_name -> gacha.v1.Uncertainty
+	4,  // 35: gacha.v1.LuckPercentileRequest.ref:type_name -> gacha.v1.GameRef
+	29, // 36: gacha.v1.LuckPercentileRequest.pulls:type_name -> gacha.v1.LuckPull
+	2,  // 37: gacha.v1.LuckPercentileRequest.goal:type_name -> gacha.v1.TrialGoal
+	31, // 38: gacha.v1.LuckPercentileResponse.waits:type_name -> gacha.v1.LuckWait
+	7,  // 39: gacha.v1.GachaService.Resolve:input_type -> gacha.v1.ResolveRequest
+	9,  // 40: gacha.v1.GachaService.DrawN:input_type -> gacha.v1.DrawNRequest
+	11, // 41: gacha.v1.GachaService.DrawNPity:input_type -> gacha.v1.DrawNPityRequest
+	14, // 42: gacha.v1.GachaService.DrawNBanner:input_type -> gacha.v1.DrawNBannerRequest
+	23, // 43: gacha.v1.GachaService.Simulate:input_type -> gacha.v1.SimulateRequest
+	20, // 44: gacha.v1.GachaService.SetSelection:input_type -> gacha.v1.SetSelectionRequest
+	18, // 45: gacha.v1.GachaService.GetFairCommitment:input_type -> gacha.v1.GetFairCommitmentRequest
+	30, // 46: gacha.v1.GachaService.LuckPercentile:input_type -> gacha.v1.LuckPercentileRequest
+	8,  // 47: gacha.v1.GachaService.Resolve:output_type -> gacha.v1.ResolveResponse
+	10, // 48: gacha.v1.GachaService.DrawN:output_type -> gacha.v1.DrawNResponse
+	12, // 49: gacha.v1.GachaService.DrawNPity:output_type -> gacha.v1.DrawNPityResponse
+	15, // 50: gacha.v1.GachaService.DrawNBanner:output_type -> gacha.v1.DrawNBannerResponse
+	28, // 51: gacha.v1.GachaService.Simulate:output_type -> gacha.v1.SimulateResponse
+	21, // 52: gacha.v1.GachaService.SetSelection:output_type -> gacha.v1.SetSelectionResponse
+	19, // 53: gacha.v1.GachaService.GetFairCommitment:output_type -> gacha.v1.GetFairCommitmentResponse
+	32, // 54: gacha.v1.GachaService.LuckPercentile:output_type -> gacha.v1.LuckPercentileResponse
+	47, // [47:55] is the sub-list for method output_type
+	39, // [39:47] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_gacha_v1_gacha_proto_init() }
@@ -1457,8 +3258,8 @@ func file_gacha_v1_gacha_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gacha_v1_gacha_proto_rawDesc), len(file_gacha_v1_gacha_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   14,
+			NumEnums:      4,
+			NumMessages:   30,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
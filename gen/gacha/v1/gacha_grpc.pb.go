@@ -19,11 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GachaService_Resolve_FullMethodName     = "/gacha.v1.GachaService/Resolve"
-	GachaService_DrawN_FullMethodName       = "/gacha.v1.GachaService/DrawN"
-	GachaService_DrawNPity_FullMethodName   = "/gacha.v1.GachaService/DrawNPity"
-	GachaService_DrawNBanner_FullMethodName = "/gacha.v1.GachaService/DrawNBanner"
-	GachaService_Simulate_FullMethodName    = "/gacha.v1.GachaService/Simulate"
+	GachaService_Resolve_FullMethodName           = "/gacha.v1.GachaService/Resolve"
+	GachaService_DrawN_FullMethodName             = "/gacha.v1.GachaService/DrawN"
+	GachaService_DrawNPity_FullMethodName         = "/gacha.v1.GachaService/DrawNPity"
+	GachaService_DrawNBanner_FullMethodName       = "/gacha.v1.GachaService/DrawNBanner"
+	GachaService_Simulate_FullMethodName          = "/gacha.v1.GachaService/Simulate"
+	GachaService_SetSelection_FullMethodName      = "/gacha.v1.GachaService/SetSelection"
+	GachaService_GetFairCommitment_FullMethodName = "/gacha.v1.GachaService/GetFairCommitment"
+	GachaService_LuckPercentile_FullMethodName    = "/gacha.v1.GachaService/LuckPercentile"
 )
 
 // GachaServiceClient is the client API for GachaService service.
@@ -40,6 +43,12 @@ type GachaServiceClient interface {
 	DrawNBanner(ctx context.Context, in *DrawNBannerRequest, opts ...grpc.CallOption) (*DrawNBannerResponse, error)
 	// Monte Carlo simulation.
 	Simulate(ctx context.Context, in *SimulateRequest, opts ...grpc.CallOption) (*SimulateResponse, error)
+	// Set a player's featured item on a selector banner.
+	SetSelection(ctx context.Context, in *SetSelectionRequest, opts ...grpc.CallOption) (*SetSelectionResponse, error)
+	// Commitment of a player's next provably fair server seed.
+	GetFairCommitment(ctx context.Context, in *GetFairCommitmentRequest, opts ...grpc.CallOption) (*GetFairCommitmentResponse, error)
+	// Rank a player's pull history against the pool's distribution.
+	LuckPercentile(ctx context.Context, in *LuckPercentileRequest, opts ...grpc.CallOption) (*LuckPercentileResponse, error)
 }
 
 type gachaServiceClient struct {
@@ -100,6 +109,36 @@ func (c *gachaServiceClient) Simulate(ctx context.Context, in *SimulateRequest,
 	return out, nil
 }
 
+func (c *gachaServiceClient) SetSelection(ctx context.Context, in *SetSelectionRequest, opts ...grpc.CallOption) (*SetSelectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetSelectionResponse)
+	err := c.cc.Invoke(ctx, GachaService_SetSelection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gachaServiceClient) GetFairCommitment(ctx context.Context, in *GetFairCommitmentRequest, opts ...grpc.CallOption) (*GetFairCommitmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFairCommitmentResponse)
+	err := c.cc.Invoke(ctx, GachaService_GetFairCommitment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gachaServiceClient) LuckPercentile(ctx context.Context, in *LuckPercentileRequest, opts ...grpc.CallOption) (*LuckPercentileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LuckPercentileResponse)
+	err := c.cc.Invoke(ctx, GachaService_LuckPercentile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GachaServiceServer is the server API for GachaService service.
 // All implementations must embed UnimplementedGachaServiceServer
 // for forward compatibility.
@@ -114,6 +153,12 @@ type GachaServiceServer interface {
 	DrawNBanner(context.Context, *DrawNBannerRequest) (*DrawNBannerResponse, error)
 	// Monte Carlo simulation.
 	Simulate(context.Context, *SimulateRequest) (*SimulateResponse, error)
+	// Set a player's featured item on a selector banner.
+	SetSelection(context.Context, *SetSelectionRequest) (*SetSelectionResponse, error)
+	// Commitment of a player's next provably fair server seed.
+	GetFairCommitment(context.Context, *GetFairCommitmentRequest) (*GetFairCommitmentResponse, error)
+	// Rank a player's pull history against the pool's distribution.
+	LuckPercentile(context.Context, *LuckPercentileRequest) (*LuckPercentileResponse, error)
 	mustEmbedUnimplementedGachaServiceServer()
 }
 
@@ -139,6 +184,15 @@ func (UnimplementedGachaServiceServer) DrawNBanner(context.Context, *DrawNBanner
 func (UnimplementedGachaServiceServer) Simulate(context.Context, *SimulateRequest) (*SimulateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Simulate not implemented")
 }
+func (UnimplementedGachaServiceServer) SetSelection(context.Context, *SetSelectionRequest) (*SetSelectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSelection not implemented")
+}
+func (UnimplementedGachaServiceServer) GetFairCommitment(context.Context, *GetFairCommitmentRequest) (*GetFairCommitmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFairCommitment not implemented")
+}
+func (UnimplementedGachaServiceServer) LuckPercentile(context.Context, *LuckPercentileRequest) (*LuckPercentileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LuckPercentile not implemented")
+}
 func (UnimplementedGachaServiceServer) mustEmbedUnimplementedGachaServiceServer() {}
 func (UnimplementedGachaServiceServer) testEmbeddedByValue()                      {}
 
@@ -250,6 +304,60 @@ func _GachaService_Simulate_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GachaService_SetSelection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSelectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GachaServiceServer).SetSelection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GachaService_SetSelection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GachaServiceServer).SetSelection(ctx, req.(*SetSelectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GachaService_GetFairCommitment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFairCommitmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GachaServiceServer).GetFairCommitment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GachaService_GetFairCommitment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GachaServiceServer).GetFairCommitment(ctx, req.(*GetFairCommitmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GachaService_LuckPercentile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LuckPercentileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GachaServiceServer).LuckPercentile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GachaService_LuckPercentile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GachaServiceServer).LuckPercentile(ctx, req.(*LuckPercentileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GachaService_ServiceDesc is the grpc.ServiceDesc for GachaService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -277,6 +385,18 @@ var GachaService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Simulate",
 			Handler:    _GachaService_Simulate_Handler,
 		},
+		{
+			MethodName: "SetSelection",
+			Handler:    _GachaService_SetSelection_Handler,
+		},
+		{
+			MethodName: "GetFairCommitment",
+			Handler:    _GachaService_GetFairCommitment_Handler,
+		},
+		{
+			MethodName: "LuckPercentile",
+			Handler:    _GachaService_LuckPercentile_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "gacha/v1/gacha.proto",
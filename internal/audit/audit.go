@@ -0,0 +1,287 @@
+// Package audit tests observed pulls against the configured rates: hit
+// frequency by pity Count against the soft pity curve, and the 50/50 layer
+// against the off-banner probabilities.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+// ErrNoTier means the audited rarity is not a tier of the pool.
+var ErrNoTier = errors.New("rarity is not a tier of the pool")
+
+// Observation is one pull as seen by the audited tier.
+type Observation struct {
+	Count          int  // pity counter of the tier before the pull
+	Hit            bool // the tier hit on this pull
+	OffStreak      int  // banner state before the pull
+	GuaranteedNext bool
+	Radiance       int  // hidden counter of the radiance off policy
+	FatePoints     int  // epitomized path points
+	IsUp           bool // on a hit: the featured item came
+	OffUnknown     bool // the 50/50 state is unknown; the pull counts for the pity layer only
+	// State of the pool's other tiers before the pull: higher tiers bound
+	// this tier's odds (see gacha.TieredSystem.Probs). Missing tiers are taken
+	// at Count 0.
+	Others []gacha.TierSnapshot
+}
+
+// Options selects what is audited.
+type Options struct {
+	Rarity   int // tier to audit; 0 => the top tier
+	BinWidth int // pity counts per bin; <= 1 => one bin per count
+}
+
+// Bin compares observed and expected successes over a range of states.
+type Bin struct {
+	From, To int     // Count range (pity layer) or OffStreak (50/50 layer), inclusive
+	Pulls    int     // trials: pulls, or hits that went to the 50/50
+	Hits     int     // successes: hits, or hits that went off-banner
+	Expected float64 // expected successes under the config
+	ChiSq    float64 // (Hits-Expected)² over the variance; 0 when the outcome is certain
+	PValue   float64 // exact binomial if the bin has a single rate, else chi-square with 1 df
+}
+
+// Layer sums the bins of one model layer into an overall chi-square test.
+// A bin whose certain outcome (hard pity, forced UP) was violated has
+// PValue 0 and makes the layer's PValue 0.
+type Layer struct {
+	Bins   []Bin
+	ChiSq  float64
+	DF     int // bins with a random outcome
+	PValue float64
+}
+
+// Report is the audit of one tier.
+type Report struct {
+	Rarity          int
+	Pulls           int
+	Pity            Layer // hit frequency by Count against the effective probability
+	Off             Layer // off-banner frequency by OffStreak; forced hits excluded
+	Guaranteed      int   // hits forced UP: guarantee, full fate points or radiance
+	GuaranteeBroken int   // of those, hits that were not UP
+}
+
+// acc accumulates one bin.
+type acc struct {
+	n, k  int
+	e, v  float64 // expected successes and their variance
+	p     float64 // rate of the first trial
+	mixed bool    // trials with different rates
+}
+
+func (a *acc) add(p float64, success bool) {
+	if a.n == 0 {
+		a.p = p
+	} else if math.Abs(p-a.p) > 1e-12 {
+		a.mixed = true
+	}
+	a.n++
+	if success {
+		a.k++
+	}
+	a.e += p
+	a.v += p * (1 - p)
+}
+
+func (a *acc) bin(from, to int) Bin {
+	b := Bin{From: from, To: to, Pulls: a.n, Hits: a.k, Expected: a.e}
+	switch {
+	case a.v == 0:
+		// every rate was 0 or 1, so the expected count is exact
+		b.PValue = boolP(float64(a.k) == math.Round(a.e))
+	case a.mixed:
+		b.ChiSq = (float64(a.k) - a.e) * (float64(a.k) - a.e) / a.v
+		b.PValue = ChiSquareSF(b.ChiSq, 1)
+	default:
+		b.ChiSq = (float64(a.k) - a.e) * (float64(a.k) - a.e) / a.v
+		b.PValue = BinomialTest(a.k, a.n, a.p)
+	}
+	return b
+}
+
+func layer(bins map[int]*acc, width int) Layer {
+	keys := make([]int, 0, len(bins))
+	for k := range bins {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	var l Layer
+	broken := false
+	for _, k := range keys {
+		a := bins[k]
+		b := a.bin(k*width, k*width+width-1)
+		if a.v > 0 {
+			l.ChiSq += b.ChiSq
+			l.DF++
+		} else if b.PValue == 0 {
+			broken = true
+		}
+		l.Bins = append(l.Bins, b)
+	}
+	l.PValue = ChiSquareSF(l.ChiSq, l.DF)
+	if broken {
+		l.PValue = 0
+	}
+	return l
+}
+
+// Audit tests observations of one tier against the pool described by p.
+func Audit(p gacha.SimParams, obs []Observation, opt Options) (Report, error) {
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(0)) // rates only; never drawn
+	if err != nil {
+		return Report{}, err
+	}
+	idx := 0
+	if opt.Rarity != 0 {
+		idx = -1
+		for i, t := range ts.Tiers {
+			if t.Rarity == opt.Rarity {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return Report{}, ErrNoTier
+		}
+	}
+	tier := ts.Tiers[idx]
+	width := max(opt.BinWidth, 1)
+
+	rep := Report{Rarity: tier.Rarity, Pulls: len(obs)}
+	pity := make(map[int]*acc)
+	off := make(map[int]*acc)
+	fresh := ts.Snapshot()
+	for _, o := range obs {
+		ts.Restore(fresh)
+		ts.Restore(o.Others)
+		ts.Restore([]gacha.TierSnapshot{{
+			Rarity:         tier.Rarity,
+			Count:          o.Count,
+			OffStreak:      o.OffStreak,
+			GuaranteedNext: o.GuaranteedNext,
+			Radiance:       o.Radiance,
+			FatePoints:     o.FatePoints,
+		}})
+		hit, offP := ts.Probs()
+		bin(pity, o.Count/width).add(hit[idx], o.Hit)
+		if !o.Hit || tier.Banner == nil || o.OffUnknown {
+			continue
+		}
+		if offP[idx] == 0 {
+			rep.Guaranteed++
+			if !o.IsUp {
+				rep.GuaranteeBroken++
+			}
+			continue
+		}
+		bin(off, o.OffStreak).add(offP[idx], !o.IsUp)
+	}
+	rep.Pity = layer(pity, width)
+	rep.Off = layer(off, 1)
+	return rep, nil
+}
+
+func bin(bins map[int]*acc, k int) *acc {
+	a := bins[k]
+	if a == nil {
+		a = &acc{}
+		bins[k] = a
+	}
+	return a
+}
+
+// Observations extracts the pulls of one tier from logged events. Pulls under
+// a multi-pull floor follow other odds and are left out.
+func Observations(evs []eventlog.Event, rarity int) []Observation {
+	var out []Observation
+	for _, ev := range evs {
+		if ev.Floor > 0 {
+			continue
+		}
+		if o, ok := Observe(ev.Pre, rarity); ok {
+			o.Hit = ev.Outcome.Rarity == rarity
+			o.IsUp = ev.Outcome.IsUp
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Observe fills the state fields of an observation of the tier of rarity
+// from the states of every tier before a pull; the caller fills in the
+// result. ok is false if the pool has no such tier.
+func Observe(pre []gacha.TierSnapshot, rarity int) (o Observation, ok bool) {
+	for _, s := range pre {
+		if s.Rarity != rarity {
+			o.Others = append(o.Others, s)
+			continue
+		}
+		o.Count, o.OffStreak, o.GuaranteedNext = s.Count, s.OffStreak, s.GuaranteedNext
+		o.Radiance, o.FatePoints = s.Radiance, s.FatePoints
+		ok = true
+	}
+	return o, ok
+}
+
+// Group is the audit of one pool's pulls under one config version and set
+// of request overrides.
+type Group struct {
+	Game, Pool, Version string
+	Report              Report
+}
+
+// AuditLog audits every logged pull, grouped by the rules in force. The
+// rates come from params (see eventlog.ResolverParams), never from the log.
+func AuditLog(ctx context.Context, l eventlog.Log, params eventlog.ParamsFunc, opt Options) ([]Group, error) {
+	type group struct {
+		first eventlog.Event
+		evs   []eventlog.Event
+	}
+	var order []string
+	groups := make(map[string]*group)
+	err := l.Scan(ctx, func(ev eventlog.Event) error {
+		o, err := json.Marshal(ev.Overrides)
+		if err != nil {
+			return err
+		}
+		key := ev.Game + "\x00" + ev.Pool + "\x00" + ev.Version + "\x00" + string(o)
+		g := groups[key]
+		if g == nil {
+			g = &group{first: ev}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.evs = append(g.evs, ev)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Group, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		p, err := params(g.first)
+		if err != nil {
+			return nil, err
+		}
+		rarity := opt.Rarity
+		if rarity == 0 && len(g.first.Pre) > 0 {
+			rarity = g.first.Pre[0].Rarity
+		}
+		o := opt
+		o.Rarity = rarity
+		rep, err := Audit(p, Observations(g.evs, rarity), o)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Group{Game: g.first.Game, Pool: g.first.Pool, Version: g.first.Version, Report: rep})
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package audit
+
+import "math"
+
+// BinomialTest returns the exact two-sided p-value of k successes in n trials
+// at rate p: the total probability of all outcomes no more likely than k.
+func BinomialTest(k, n int, p float64) float64 {
+	switch {
+	case n <= 0:
+		return 1
+	case p <= 0:
+		return boolP(k == 0)
+	case p >= 1:
+		return boolP(k == n)
+	}
+	lk := logBinomPMF(k, n, p)
+	sum := 0.0
+	for i := 0; i <= n; i++ {
+		if li := logBinomPMF(i, n, p); li <= lk+1e-7 {
+			sum += math.Exp(li)
+		}
+	}
+	return math.Min(sum, 1)
+}
+
+func logBinomPMF(k, n int, p float64) float64 {
+	return lgamma(n+1) - lgamma(k+1) - lgamma(n-k+1) +
+		float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p)
+}
+
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
+
+func boolP(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// ChiSquareSF is the upper tail P(X >= x) of a chi-square with df degrees of freedom.
+func ChiSquareSF(x float64, df int) float64 {
+	if df <= 0 || x <= 0 {
+		return 1
+	}
+	return gammaQ(float64(df)/2, x/2)
+}
+
+// gammaQ is the regularized upper incomplete gamma function Q(a, x):
+// a series below a+1, a continued fraction above.
+func gammaQ(a, x float64) float64 {
+	const (
+		eps   = 1e-14
+		iters = 1000
+	)
+	lga, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lga)
+	if x < a+1 {
+		sum, term := 1/a, 1/a
+		for n := 1; n < iters; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*eps {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*front)
+	}
+	// modified Lentz
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < iters; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return math.Min(1, h*front)
+}
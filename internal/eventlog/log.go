@@ -0,0 +1,200 @@
+// Package eventlog keeps an append-only record of every draw and replays it.
+package eventlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// Event records one pull with everything needed to re-run it.
+type Event struct {
+	Seq       uint64               `json:"seq"` // assigned by the log, from 1
+	Time      time.Time            `json:"time"`
+	Player    string               `json:"player,omitempty"` // "" for anonymous draws
+	Game      string               `json:"game"`
+	Pool      string               `json:"pool,omitempty"`
+	Version   string               `json:"version,omitempty"`  // effective config version
+	Overrides game.Overrides       `json:"overrides"`          // request overrides in effect
+	Selected  map[int]string       `json:"selected,omitempty"` // selector choice in effect, by rarity
+	Floor     int                  `json:"floor,omitempty"`    // multi-pull floor applied to this pull
+	Pre       []gacha.TierSnapshot `json:"pre"`                // state before the pull
+	Probs     []float64            `json:"probs"`              // probability of each tier winning the pull, as in Pre
+	OffProbs  []float64            `json:"off_probs"`          // off-banner probability per tier on a hit
+	RNG       []float64            `json:"rng"`                // random values consumed, in order
+	Outcome   Outcome              `json:"outcome"`
+	Post      []gacha.TierSnapshot `json:"post"` // state after the pull
+}
+
+// Outcome is the logged result of a pull.
+type Outcome struct {
+	Rarity   int    `json:"rarity"`
+	Hit      bool   `json:"hit,omitempty"`
+	IsUp     bool   `json:"is_up,omitempty"`
+	OnTarget bool   `json:"on_target,omitempty"`
+	Item     string `json:"item,omitempty"`
+}
+
+func outcomeOf(o gacha.TierOutcome) Outcome {
+	return Outcome{Rarity: o.Rarity, Hit: o.Hit, IsUp: o.IsUp, OnTarget: o.OnTarget, Item: o.Item}
+}
+
+// FromTrace builds the engine part of an event from a pull trace and the
+// random values the pull consumed; the caller fills in who and where.
+func FromTrace(tr gacha.PullTrace, rng []float64) Event {
+	return Event{
+		Floor:    tr.Floor,
+		Pre:      tr.Pre,
+		Probs:    tr.Probs,
+		OffProbs: tr.OffProbs,
+		RNG:      rng,
+		Outcome:  outcomeOf(tr.Outcome),
+		Post:     tr.Post,
+	}
+}
+
+// Log is an append-only event log.
+type Log interface {
+	// Append numbers the events, stamps those without a time and writes them
+	// in order. Either all of them are written or none.
+	Append(ctx context.Context, evs ...Event) error
+	// Scan calls fn for every event in order; an error from fn stops the scan.
+	Scan(ctx context.Context, fn func(Event) error) error
+}
+
+// MemoryLog keeps events in process memory; they are lost on restart.
+type MemoryLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryLog creates an empty in-process log.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+func (m *MemoryLog) Append(ctx context.Context, evs ...Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	for _, ev := range evs {
+		ev.Seq = uint64(len(m.events)) + 1
+		if ev.Time.IsZero() {
+			ev.Time = now
+		}
+		m.events = append(m.events, ev)
+	}
+	return nil
+}
+
+func (m *MemoryLog) Scan(ctx context.Context, fn func(Event) error) error {
+	m.mu.Lock()
+	events := append([]Event(nil), m.events...)
+	m.mu.Unlock()
+	for _, ev := range events {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileLog appends events to a JSON Lines file, one event per line.
+// It is meant for a single writing process; every Append is synced to disk.
+type FileLog struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+	seq  uint64
+}
+
+// OpenFileLog opens or creates the log at path and resumes its numbering.
+func OpenFileLog(path string) (*FileLog, error) {
+	l := &FileLog{path: path}
+	err := l.Scan(context.Background(), func(ev Event) error {
+		l.seq = ev.Seq
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return l, nil
+}
+
+func (l *FileLog) Append(ctx context.Context, evs ...Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var buf []byte
+	now := time.Now().UTC()
+	for i, ev := range evs {
+		ev.Seq = l.seq + uint64(i) + 1
+		if ev.Time.IsZero() {
+			ev.Time = now
+		}
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	if _, err := l.f.Write(buf); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.seq += uint64(len(evs))
+	return nil
+}
+
+// Scan holds off appends until it is done, so it never sees a torn line.
+func (l *FileLog) Scan(ctx context.Context, fn func(Event) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return fmt.Errorf("decode %s:%d: %w", l.path, line, err)
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Close closes the underlying file.
+func (l *FileLog) Close() error {
+	return l.f.Close()
+}
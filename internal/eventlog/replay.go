@@ -0,0 +1,103 @@
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// Divergence is one difference between a logged pull and its replay.
+type Divergence struct {
+	Seq      uint64
+	Field    string // params, probs, off_probs, rng, outcome or post
+	Logged   string
+	Replayed string
+}
+
+func (d Divergence) String() string {
+	return fmt.Sprintf("event %d: %s: logged %s, replayed %s", d.Seq, d.Field, d.Logged, d.Replayed)
+}
+
+// ParamsFunc returns the engine parameters an event was drawn with.
+type ParamsFunc func(ev Event) (gacha.SimParams, error)
+
+// ResolverParams resolves an event's game, pool and overrides with r. It fails
+// when the config version differs from the logged one, since the replay
+// would then run against other rules.
+func ResolverParams(r game.Resolver) ParamsFunc {
+	return func(ev Event) (gacha.SimParams, error) {
+		_, ep, err := r.Resolve(ev.Game, ev.Pool, ev.Overrides)
+		if err != nil {
+			return gacha.SimParams{}, err
+		}
+		if ep.Version != ev.Version {
+			return gacha.SimParams{}, fmt.Errorf("config version is %q, event was drawn under %q", ep.Version, ev.Version)
+		}
+		return game.ToSimParams(ep), nil
+	}
+}
+
+// Replay re-runs every logged pull and reports where it diverges.
+// A pull that cannot be rebuilt is reported as a params divergence.
+func Replay(ctx context.Context, log Log, params ParamsFunc) ([]Divergence, error) {
+	var out []Divergence
+	err := log.Scan(ctx, func(ev Event) error {
+		p, err := params(ev)
+		if err != nil {
+			out = append(out, Divergence{Seq: ev.Seq, Field: "params", Logged: ev.Version, Replayed: err.Error()})
+			return nil
+		}
+		out = append(out, ReplayEvent(ev, p)...)
+		return nil
+	})
+	return out, err
+}
+
+// ReplayEvent re-runs one pull: a tiered system built from p is set to the
+// logged pre-state and selection and fed the logged random values. The hit
+// of a tier with a banner resolves through the same BannerSystem rules as live
+// draws. Every logged field the replay recomputes is compared.
+func ReplayEvent(ev Event, p gacha.SimParams) []Divergence {
+	fail := func(err error) []Divergence {
+		return []Divergence{{Seq: ev.Seq, Field: "params", Logged: ev.Version, Replayed: err.Error()}}
+	}
+	rng := &gacha.ReplayRNG{Values: ev.RNG}
+	ts, err := gacha.NewTieredFromParams(p, rng)
+	if err != nil {
+		return fail(err)
+	}
+	for r, id := range ev.Selected {
+		if err := ts.Select(r, id); err != nil {
+			return fail(err)
+		}
+	}
+	ts.Restore(ev.Pre)
+	var tr gacha.PullTrace
+	ts.Trace = func(t gacha.PullTrace) { tr = t }
+	if _, err := ts.DrawAtLeast(ev.Floor); err != nil {
+		return fail(err)
+	}
+
+	var out []Divergence
+	diff := func(field string, logged, replayed any) {
+		l, r := fmt.Sprint(logged), fmt.Sprint(replayed)
+		if l != r {
+			out = append(out, Divergence{Seq: ev.Seq, Field: field, Logged: l, Replayed: r})
+		}
+	}
+	if !slices.Equal(tr.Probs, ev.Probs) {
+		diff("probs", ev.Probs, tr.Probs)
+	}
+	if !slices.Equal(tr.OffProbs, ev.OffProbs) {
+		diff("off_probs", ev.OffProbs, tr.OffProbs)
+	}
+	if rng.Overrun > 0 || rng.Remaining() > 0 {
+		diff("rng", fmt.Sprintf("%d values", len(ev.RNG)), fmt.Sprintf("%d values", len(ev.RNG)-rng.Remaining()+rng.Overrun))
+	}
+	diff("outcome", ev.Outcome, outcomeOf(tr.Outcome))
+	diff("post", ev.Post, tr.Post)
+	return out
+}
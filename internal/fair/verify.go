@@ -0,0 +1,82 @@
+// Package fair replays provably fair DrawNBanner batches from their revealed seeds.
+package fair
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+var (
+	// ErrCommitment means the revealed server seed does not match its commitment.
+	ErrCommitment = errors.New("server seed does not match commitment")
+	// ErrMismatch means the replayed draws differ from the reported ones.
+	ErrMismatch = errors.New("replayed draws differ from reported results")
+	// ErrVersion means the pool config is not the version the batch was drawn with.
+	ErrVersion = errors.New("pool config version differs from the batch's")
+)
+
+// Proof is what a fair DrawNBanner response discloses about one batch.
+type Proof struct {
+	Commitment string
+	ServerSeed string // revealed after the batch
+	ClientSeed string
+	Nonce      uint64
+	Start      state.PlayerState // Tiers and Selected before the batch
+	Batch      int               // multi-pull size; 1 for single pulls
+	Version    string            // effective config version of the pool
+}
+
+// Replay recomputes the n draws of a batch on the pool described by ep
+// (resolved without overrides, as fair batches are), exactly as the server
+// drew them: a tiered system seeded with the fair source, the start state
+// applied, the selection (or the pool default) in effect, then n pulls.
+func Replay(ep game.EngineParams, proof Proof, n int) ([]gacha.TierOutcome, error) {
+	if !gacha.CheckCommitment(proof.ServerSeed, proof.Commitment) {
+		return nil, ErrCommitment
+	}
+	if ep.Version != proof.Version {
+		return nil, fmt.Errorf("%w: pool at %q, batch at %q", ErrVersion, ep.Version, proof.Version)
+	}
+	rng := gacha.NewFairRNG(proof.ServerSeed, proof.ClientSeed, proof.Nonce)
+	ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), rng)
+	if err != nil {
+		return nil, err
+	}
+	if proof.Batch <= 1 {
+		ts.MultiFloor = 0
+	}
+	proof.Start.Apply(ts)
+	if sel := ep.Selector; sel != nil {
+		choice := proof.Start.Selected[sel.Rarity]
+		if choice == "" {
+			choice = sel.Default
+		}
+		if choice != "" {
+			if err := ts.Select(sel.Rarity, choice); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ts.DrawBatch(n, proof.Batch)
+}
+
+// Verify replays a batch and checks it against the reported results:
+// rarity, UP flag and item of every pull must match.
+func Verify(ep game.EngineParams, proof Proof, results []gacha.TierOutcome) error {
+	got, err := Replay(ep, proof, len(results))
+	if err != nil {
+		return err
+	}
+	for i, want := range results {
+		g := got[i]
+		if g.Rarity != want.Rarity || g.IsUp != want.IsUp || g.Item != want.Item {
+			return fmt.Errorf("%w: draw %d: replayed %d★ up=%v %q, reported %d★ up=%v %q",
+				ErrMismatch, i, g.Rarity, g.IsUp, g.Item, want.Rarity, want.IsUp, want.Item)
+		}
+	}
+	return nil
+}
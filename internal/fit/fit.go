@@ -0,0 +1,420 @@
+// Package fit estimates undisclosed pity and banner parameters from pull
+// histories by maximum likelihood against the engine's own model.
+package fit
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// ErrNoData means the histories hold no usable pulls.
+var ErrNoData = errors.New("no usable pulls to fit")
+
+// Pull is one pull as seen by the fitted rarity.
+type Pull struct {
+	Hit  bool // the rarity was obtained
+	IsUp bool // on a hit: it was the featured item
+}
+
+// History is one account's pulls on a banner, oldest first.
+// Unless Fresh, the pity counter and the 50/50 state at the first pull are
+// unknown: pulls before the first hit are left out of the pity fit and hits
+// before the first UP out of the 50/50 fit.
+type History struct {
+	Pulls []Pull
+	Fresh bool // the first pull starts at Count 0 with no offs behind it
+}
+
+// Options bounds the search.
+type Options struct {
+	PitySpan int // hard pity values tried above the smallest one the data allows; 0 => 10. An interval reaching the end of the span is open-ended
+}
+
+// Estimate is a point estimate with a 95% confidence interval.
+type Estimate struct {
+	Value, Low, High float64
+}
+
+// Result is the maximum likelihood fit.
+type Result struct {
+	Pulls    int                      // pulls used by the pity fit
+	Hits     int                      // hits used by the 50/50 fit
+	LogLik   float64                  // pity layer log-likelihood at the estimate
+	PBase    Estimate                 // Wald interval
+	Pity     Estimate                 // profile likelihood interval
+	Soft     bool                     // a soft ramp fits significantly better than none
+	StartAt  Estimate                 // profile likelihood interval at the fitted pity; 0 without a ramp
+	Target   Estimate                 // Wald interval; 0 without a ramp
+	Easing   gacha.Easing             // best easing; "" without a ramp
+	Easings  map[gacha.Easing]float64 // profile log-likelihood of each easing
+	OffProbs []Estimate               // per off streak, Wilson intervals
+	MaxOff   int                      // smallest value consistent with the longest off run
+}
+
+// profileCut is half the 95% quantile of chi-square with 1 df: values whose
+// profile log-likelihood is within it of the maximum form the interval.
+const profileCut = 1.920729
+
+// softGain is the log-likelihood a ramp must add over a flat rate (start and
+// target are 2 extra parameters: half the 95% chi-square quantile with 2 df).
+const softGain = 2.995732
+
+var easings = []gacha.Easing{gacha.EaseLinear, gacha.EaseOutQuad, gacha.EaseInOutCubic}
+
+// counts aggregates pulls by pity Count: n pulls, k hits.
+type counts struct {
+	n, k []int
+}
+
+func (c *counts) add(count int, hit bool) {
+	for len(c.n) <= count {
+		c.n = append(c.n, 0)
+		c.k = append(c.k, 0)
+	}
+	c.n[count]++
+	if hit {
+		c.k[count]++
+	}
+}
+
+// model is one candidate pity curve.
+type model struct {
+	pity    int
+	startAt int // < 0 => no ramp
+	easing  gacha.Easing
+	pBase   float64
+	target  float64
+}
+
+// logLik of the counts under m; -Inf if m cannot produce them.
+func (c *counts) logLik(m model) float64 {
+	var soft *gacha.SoftPityConfig
+	if m.startAt >= 0 {
+		soft = &gacha.SoftPityConfig{Mode: gacha.SoftTargetRamp, StartAt: m.startAt, TargetProb: m.target, Easing: m.easing}
+	}
+	sp, err := gacha.NewSoftPitySystem(m.pity, soft, gacha.NewSeededRNG(0))
+	if err != nil || m.pBase <= 0 || m.pBase >= 1 {
+		return math.Inf(-1)
+	}
+	ll := 0.0
+	for cnt, n := range c.n {
+		if n == 0 {
+			continue
+		}
+		k := c.k[cnt]
+		sp.Count = cnt
+		p := sp.EffectiveProb(m.pBase)
+		if k > 0 {
+			if p <= 0 {
+				return math.Inf(-1)
+			}
+			ll += float64(k) * math.Log(p)
+		}
+		if n > k {
+			if p >= 1 {
+				return math.Inf(-1)
+			}
+			ll += float64(n-k) * math.Log1p(-p)
+		}
+	}
+	return ll
+}
+
+// rate is the hit rate of counts in [from, to).
+func (c *counts) rate(from, to int) float64 {
+	n, k := 0, 0
+	for i := from; i < min(to, len(c.n)); i++ {
+		n += c.n[i]
+		k += c.k[i]
+	}
+	if n == 0 || k == 0 {
+		return 0.01
+	}
+	return math.Min(float64(k)/float64(n), 0.99)
+}
+
+// golden maximizes f on [lo, hi] by golden-section search.
+func golden(f func(float64) float64, lo, hi float64) float64 {
+	const r = 0.6180339887498949
+	a, b := lo, hi
+	x1, x2 := b-r*(b-a), a+r*(b-a)
+	f1, f2 := f(x1), f(x2)
+	for i := 0; i < 40; i++ {
+		if f1 < f2 {
+			a, x1, f1 = x1, x2, f2
+			x2 = a + r*(b-a)
+			f2 = f(x2)
+		} else {
+			b, x2, f2 = x2, x1, f1
+			x1 = b - r*(b-a)
+			f1 = f(x1)
+		}
+	}
+	return (a + b) / 2
+}
+
+// best fits the continuous parameters of m by coordinate ascent.
+func (c *counts) best(m model) (model, float64) {
+	if m.startAt < 0 {
+		m.pBase = c.rate(0, m.pity-1)
+		return m, c.logLik(m)
+	}
+	m.pBase = c.rate(0, m.startAt)
+	m.target = math.Max(m.pBase, 0.5)
+	for round := 0; round < 3; round++ {
+		m.target = golden(func(x float64) float64 { t := m; t.target = x; return c.logLik(t) }, m.pBase, 0.999)
+		m.pBase = golden(func(x float64) float64 { t := m; t.pBase = x; return c.logLik(t) }, 1e-6, math.Min(m.target, 0.999))
+	}
+	return m, c.logLik(m)
+}
+
+// Fit estimates the pity curve and the 50/50 layer of one rarity.
+// The 50/50 layer is fitted under the streak policy (off_probs by streak).
+func Fit(hs []History, opt Options) (Result, error) {
+	var c counts
+	res := Result{Easings: make(map[gacha.Easing]float64)}
+	maxCount, maxMiss := -1, -1
+	for _, h := range hs {
+		count, known := 0, h.Fresh
+		for _, p := range h.Pulls {
+			if known {
+				c.add(count, p.Hit)
+				res.Pulls++
+				maxCount = max(maxCount, count)
+				if !p.Hit {
+					maxMiss = max(maxMiss, count)
+				}
+			}
+			count++
+			if p.Hit {
+				count, known = 0, true
+			}
+		}
+	}
+	if res.Pulls == 0 {
+		return Result{}, ErrNoData
+	}
+
+	// hard pity: no pull at Count >= pity, no miss at Count >= pity-1
+	pityLo := max(maxCount+1, maxMiss+2, 2)
+	span := opt.PitySpan
+	if span <= 0 {
+		span = 10
+	}
+	var (
+		bestM    model
+		bestLL   = math.Inf(-1)
+		flatM    model
+		flatLL   = math.Inf(-1)
+		pityProf = make(map[int]float64)
+		// startProf[pity][start] profiles start_at for a given hard pity
+		startProf = make(map[int]map[int]float64)
+	)
+	for pity := pityLo; pity <= pityLo+span; pity++ {
+		m, ll := c.best(model{pity: pity, startAt: -1})
+		prof := ll
+		if ll > flatLL {
+			flatM, flatLL = m, ll
+		}
+		startProf[pity] = make(map[int]float64)
+		for start := 1; start < pity-1; start++ {
+			startProf[pity][start] = math.Inf(-1)
+			for _, e := range easings {
+				m, ll := c.best(model{pity: pity, startAt: start, easing: e})
+				prof = math.Max(prof, ll)
+				startProf[pity][start] = math.Max(startProf[pity][start], ll)
+				if prev, ok := res.Easings[e]; !ok || ll > prev {
+					res.Easings[e] = ll
+				}
+				if ll > bestLL {
+					bestM, bestLL = m, ll
+				}
+			}
+		}
+		pityProf[pity] = prof
+	}
+
+	res.Soft = bestLL-flatLL > softGain
+	if !res.Soft {
+		bestM, bestLL = flatM, flatLL
+	}
+	res.LogLik = bestLL
+	res.Pity = profileInterval(pityProf, bestM.pity)
+	res.PBase = c.wald(bestM, 0)
+	if res.Soft {
+		res.StartAt = profileInterval(startProf[bestM.pity], bestM.startAt)
+		res.Target = c.wald(bestM, 1)
+		res.Easing = bestM.easing
+	}
+
+	res.OffProbs, res.MaxOff, res.Hits = fitOff(hs)
+	return res, nil
+}
+
+// profileInterval turns a profile log-likelihood over integer values into
+// an estimate at best with the range of values within profileCut.
+func profileInterval(prof map[int]float64, best int) Estimate {
+	top := prof[best]
+	keys := make([]int, 0, len(prof))
+	for k := range prof {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	est := Estimate{Value: float64(best), Low: float64(best), High: float64(best)}
+	for _, k := range keys {
+		if prof[k] >= top-profileCut {
+			est.Low = math.Min(est.Low, float64(k))
+			est.High = math.Max(est.High, float64(k))
+		}
+	}
+	return est
+}
+
+// wald is the Wald interval of parameter i (0 pBase, 1 target) of m from the
+// observed information, with the other continuous parameter profiled out.
+func (c *counts) wald(m model, i int) Estimate {
+	x := []float64{m.pBase, m.target}
+	n := 1
+	if m.startAt >= 0 {
+		n = 2
+	}
+	f := func(v []float64) float64 {
+		t := m
+		t.pBase = v[0]
+		if n > 1 {
+			t.target = v[1]
+		}
+		return c.logLik(t)
+	}
+	// Hessian by central differences
+	h := make([][]float64, n)
+	for a := range h {
+		h[a] = make([]float64, n)
+	}
+	step := func(v float64) float64 { return math.Max(1e-6, 1e-4*v) }
+	for a := 0; a < n; a++ {
+		for b := a; b < n; b++ {
+			ha, hb := step(x[a]), step(x[b])
+			at := func(da, db float64) float64 {
+				v := append([]float64(nil), x...)
+				v[a] += da
+				v[b] += db
+				return f(v)
+			}
+			d := (at(ha, hb) - at(ha, -hb) - at(-ha, hb) + at(-ha, -hb)) / (4 * ha * hb)
+			h[a][b], h[b][a] = d, d
+		}
+	}
+	// variance = diagonal of the inverse of -H
+	var variance float64
+	if n == 1 {
+		variance = -1 / h[0][0]
+	} else {
+		det := h[0][0]*h[1][1] - h[0][1]*h[1][0]
+		variance = -h[1-i][1-i] / det
+	}
+	est := Estimate{Value: x[i], Low: x[i], High: x[i]}
+	if variance > 0 && !math.IsInf(variance, 0) && !math.IsNaN(variance) {
+		se := math.Sqrt(variance)
+		est.Low = math.Max(0, x[i]-1.959964*se)
+		est.High = math.Min(1, x[i]+1.959964*se)
+	}
+	return est
+}
+
+// fitOff estimates off_probs by streak and max_off from the 50/50 results.
+// Streaks count consecutive off-banner hits since the last UP; under the
+// engine a hit at a streak above max_off is forced UP, so the longest run of
+// offs L implies max_off >= L-1, and the smallest such value is the MLE.
+func fitOff(hs []History) ([]Estimate, int, int) {
+	type tally struct{ n, off int }
+	var byStreak []tally
+	longest := 0
+	for _, h := range hs {
+		streak, known := 0, h.Fresh
+		for _, p := range h.Pulls {
+			if !p.Hit {
+				continue
+			}
+			if known {
+				for len(byStreak) <= streak {
+					byStreak = append(byStreak, tally{})
+				}
+				byStreak[streak].n++
+				if !p.IsUp {
+					byStreak[streak].off++
+				}
+			}
+			if p.IsUp {
+				streak, known = 0, true
+			} else {
+				streak++
+				if known {
+					longest = max(longest, streak)
+				}
+			}
+		}
+	}
+	maxOff := max(longest-1, 1)
+	var out []Estimate
+	hits := 0
+	for s := 0; s <= maxOff && s < len(byStreak); s++ {
+		t := byStreak[s]
+		hits += t.n
+		out = append(out, wilson(t.off, t.n))
+	}
+	return out, maxOff, hits
+}
+
+// wilson is the Wilson score 95% interval of k successes in n trials.
+func wilson(k, n int) Estimate {
+	if n == 0 {
+		return Estimate{Value: 0.5, Low: 0, High: 1}
+	}
+	const z = 1.959964
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	den := 1 + z*z/nf
+	mid := (p + z*z/(2*nf)) / den
+	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / den
+	return Estimate{Value: p, Low: math.Max(0, mid-half), High: math.Min(1, mid+half)}
+}
+
+// Config is the fit as a pool config for game.Loader. Off probabilities are
+// kept inside (0, 1), which the engine requires.
+func (r Result) Config() game.RawConfig {
+	pBase, pity := r.PBase.Value, int(r.Pity.Value)
+	cfg := game.RawConfig{
+		Version: "fit",
+		Notes:   "maximum likelihood fit of observed pulls",
+		Draw:    game.DrawConfig{PBase: &pBase, Pity: &pity},
+	}
+	if r.Soft {
+		start, target := int(r.StartAt.Value), r.Target.Value
+		cfg.Draw.Soft = &game.SoftCfg{
+			Mode:    string(gacha.SoftTargetRamp),
+			StartAt: &start,
+			Target:  &target,
+			Easing:  string(r.Easing),
+		}
+	}
+	if len(r.OffProbs) > 0 {
+		b := &game.BannerConfig{MaxOff: r.MaxOff}
+		for _, e := range r.OffProbs {
+			b.OffProbs = append(b.OffProbs, math.Min(math.Max(e.Value, 0.001), 0.999))
+		}
+		cfg.Banner = b
+	}
+	return cfg
+}
+
+// YAML renders Config as a pool file.
+func (r Result) YAML() ([]byte, error) {
+	return yaml.Marshal(r.Config())
+}
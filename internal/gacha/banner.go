@@ -1,7 +1,5 @@
 package gacha
 
-import "math"
-
 // BannerOutcome reports one draw's result under banner rules.
 
 type BannerOutcome struct {
@@ -24,11 +22,13 @@ type BannerOutcome struct {
 // - A Hit (UP of off) always resets SoftPity.Count to 0; misses increment Count.
 // - MaxOff controls how many consecutive offs are allowed before forcing UP on the next Hit.
 // If MaxOff <= 0, it defaults to len(OffProbs).
+// - Policy replaces the OffProbs lookup when set (see OffPolicy).
 
 type BannerSystem struct {
 	SoftPity *SoftPitySystem
 	OffProbs []float64 // per-off probabilities; last value repeats if OffStreak exceeds len-1
 	MaxOff int // threshold for consecutive offs before guarantee flips
+	Policy OffPolicy // decides off vs UP; nil => StreakPolicy over OffProbs
 	GuaranteedNext bool
 	OffStreak int
 }
@@ -61,27 +61,17 @@ func NewBannerSystem(soft *SoftPitySystem, offProbs []float64, maxOff int) *Bann
 	}
 }
 
-// currentOffProb returns the probability of going off-banner at the current streak
-func (b *BannerSystem) currentOffProb() float64 {
-	if len(b.OffProbs) == 0 {
-		return 0.5
-	}
-	idx := b.OffStreak
-	if idx < 0 {
-		idx = 0
-	}
-	if idx >= len(b.OffProbs) {
-		idx = len(b.OffProbs) - 1 // repeat the last value
+// policy returns the off-decision policy in effect.
+func (b *BannerSystem) policy() OffPolicy {
+	if b.Policy != nil {
+		return b.Policy
 	}
-	// keep strictly within (0,1) to avoid degenracy
-	p := b.OffProbs[idx]
-	if p <= 0 {
-		p = math.SmallestNonzeroFloat64
-	}
-	if p >= 1 {
-		p = 1 - 1e-12
-	}
-	return p
+	return StreakPolicy{OffProbs: b.OffProbs}
+}
+
+// currentOffProb returns the probability of going off-banner at the current state
+func (b *BannerSystem) currentOffProb() float64 {
+	return b.policy().OffProb(b.OffStreak)
 }
 
 // Draw performs one banner draw using base probability pBase for early segment.
@@ -105,6 +95,12 @@ func (b *BannerSystem) Draw(pBase float64) (BannerOutcome, error) {
 	}
 
 	// 2) on hit: guarantee or 50/50-like decision chain
+	return b.resolveHit()
+}
+
+// resolveHit decides UP vs off-banner for a Hit that has already happened
+// (SoftPity.Count is expected to be reset by the caller).
+func (b *BannerSystem) resolveHit() (BannerOutcome, error) {
 	if b.GuaranteedNext {
 		b.GuaranteedNext = false
 		b.OffStreak = 0
@@ -117,12 +113,16 @@ func (b *BannerSystem) Draw(pBase float64) (BannerOutcome, error) {
 		}, nil
 	}
 
-	// decide off vs up using per-streak probability
-	offProbs := b.currentOffProb()
-	off, derr := Draw(offProbs, b.SoftPity.RNG)
-	if derr != nil {
-		return BannerOutcome{}, derr
+	// decide off vs up using the policy's probability
+	off := false
+	if offProb := b.currentOffProb(); offProb > 0 {
+		var derr error
+		off, derr = Draw(offProb, b.SoftPity.RNG)
+		if derr != nil {
+			return BannerOutcome{}, derr
+		}
 	}
+	b.policy().Record(off)
 	if off {
 		b.OffStreak++
 		// after reaching MaxOff consecutive offs, flip guarantee for the next hit
@@ -148,4 +148,30 @@ func (b *BannerSystem) Draw(pBase float64) (BannerOutcome, error) {
 		GuaranteedNext: b.GuaranteedNext,
 		OffStreak: b.OffStreak,
 	}, nil
-}
\ No newline at end of file
+}
+
+// settle applies the state change of a hit whose UP/off result is already
+// known (see TieredSystem.Observe). An off hit under a guarantee is
+// impossible; it is counted as off and reported with ErrObservation.
+func (b *BannerSystem) settle(off bool) error {
+	if b.GuaranteedNext && !off {
+		b.GuaranteedNext = false
+		b.OffStreak = 0
+		return nil
+	}
+	var err error
+	if b.GuaranteedNext || b.currentOffProb() <= 0 && off {
+		err = ErrObservation
+	}
+	b.policy().Record(off)
+	if off {
+		b.OffStreak++
+		if b.OffStreak > b.MaxOff {
+			b.GuaranteedNext = true
+		}
+		return err
+	}
+	b.OffStreak = 0
+	b.GuaranteedNext = false
+	return err
+}
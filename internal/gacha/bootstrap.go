@@ -0,0 +1,211 @@
+package gacha
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var ErrAdaptive = errors.New("invalid adaptive sampling config")
+
+// Quantity names one statistic of Stats.
+type Quantity string
+
+const (
+	QuantityMean   Quantity = "mean"
+	QuantityVar    Quantity = "var"
+	QuantityStdDev Quantity = "std_dev"
+	QuantityP50    Quantity = "p50"
+	QuantityP90    Quantity = "p90"
+	QuantityP99    Quantity = "p99"
+)
+
+// Interval is the sampling uncertainty of one statistic.
+type Interval struct {
+	StdErr    float64 // standard deviation of the bootstrap replicates
+	Low, High float64 // percentile bootstrap confidence interval
+}
+
+// HalfWidth is half the length of the interval.
+func (iv Interval) HalfWidth() float64 { return (iv.High - iv.Low) / 2 }
+
+// Uncertainty is the sampling uncertainty of every statistic in Stats.
+type Uncertainty struct {
+	Mean, Var, StdDev, P50, P90, P99 Interval
+	Level                            float64 // confidence level of the intervals
+	Resamples                        int
+}
+
+// Of returns the interval of q; ok is false for an unknown quantity.
+func (u Uncertainty) Of(q Quantity) (iv Interval, ok bool) {
+	switch q {
+	case QuantityMean:
+		return u.Mean, true
+	case QuantityVar:
+		return u.Var, true
+	case QuantityStdDev:
+		return u.StdDev, true
+	case QuantityP50:
+		return u.P50, true
+	case QuantityP90:
+		return u.P90, true
+	case QuantityP99:
+		return u.P99, true
+	}
+	return Interval{}, false
+}
+
+// Bootstrap estimates the uncertainty of s by recomputing every statistic on
+// resamples resamples of Samples drawn with replacement (<= 0 means 200),
+// with intervals at level (outside (0,1) means 0.95). A resample is drawn as
+// multinomial counts over the distinct sample values, so its cost does not
+// grow with the number of samples; counts expected above 30 use the normal
+// approximation to the binomial. The same seed gives the same result.
+// Without samples the zero Uncertainty is returned.
+func (s Stats) Bootstrap(resamples int, level float64, seed uint64) Uncertainty {
+	if resamples <= 0 {
+		resamples = 200
+	}
+	if !(level > 0 && level < 1) {
+		level = 0.95
+	}
+	n := len(s.Samples)
+	if n == 0 {
+		return Uncertainty{}
+	}
+	sorted := append([]int(nil), s.Samples...)
+	sort.Ints(sorted)
+	var vals []int
+	var probs []float64
+	for i := 0; i < n; {
+		j := i
+		for j < n && sorted[j] == sorted[i] {
+			j++
+		}
+		vals = append(vals, sorted[i])
+		probs = append(probs, float64(j-i)/float64(n))
+		i = j
+	}
+
+	rng := NewSeededRNG(seed)
+	reps := make([][]float64, 6)
+	counts := make([]int, len(vals))
+	for b := 0; b < resamples; b++ {
+		left, mass := n, 1.0
+		for i, p := range probs {
+			if i == len(probs)-1 {
+				counts[i] = left
+				break
+			}
+			counts[i] = binomial(left, min(p/mass, 1), rng)
+			left -= counts[i]
+			mass -= p
+		}
+		st := countStats(vals, counts, n)
+		for i, v := range []float64{st.Mean, st.Var, st.StdDev, st.P50, st.P90, st.P99} {
+			reps[i] = append(reps[i], v)
+		}
+	}
+
+	alpha := (1 - level) / 2
+	interval := func(xs []float64) Interval {
+		var mean, ss float64
+		for _, x := range xs {
+			mean += x
+		}
+		mean /= float64(len(xs))
+		for _, x := range xs {
+			ss += (x - mean) * (x - mean)
+		}
+		sort.Float64s(xs)
+		iv := Interval{Low: interpolate(xs, alpha), High: interpolate(xs, 1-alpha)}
+		if len(xs) > 1 {
+			iv.StdErr = math.Sqrt(ss / float64(len(xs)-1))
+		}
+		return iv
+	}
+	return Uncertainty{
+		Mean:      interval(reps[0]),
+		Var:       interval(reps[1]),
+		StdDev:    interval(reps[2]),
+		P50:       interval(reps[3]),
+		P90:       interval(reps[4]),
+		P99:       interval(reps[5]),
+		Level:     level,
+		Resamples: resamples,
+	}
+}
+
+// countStats is calcStats for samples given as counts of sorted distinct
+// values; it has no Samples.
+func countStats(vals, counts []int, n int) Stats {
+	var mean float64
+	for i, v := range vals {
+		mean += float64(v) * float64(counts[i])
+	}
+	mean /= float64(n)
+	var acc float64
+	for i, v := range vals {
+		d := float64(v) - mean
+		acc += d * d * float64(counts[i])
+	}
+	variance := acc / float64(n)
+	// at returns the r-th smallest sample
+	at := func(r int) float64 {
+		for i, c := range counts {
+			if r < c {
+				return float64(vals[i])
+			}
+			r -= c
+		}
+		return float64(vals[len(vals)-1])
+	}
+	percentile := func(p float64) float64 {
+		pos := p * float64(n-1)
+		i := int(math.Floor(pos))
+		f := pos - float64(i)
+		if i+1 >= n {
+			return at(i)
+		}
+		return at(i)*(1-f) + at(i+1)*f
+	}
+	return Stats{
+		Mean:   mean,
+		Var:    variance,
+		StdDev: math.Sqrt(variance),
+		P50:    percentile(0.50),
+		P90:    percentile(0.90),
+		P99:    percentile(0.99),
+	}
+}
+
+// binomial draws from Binomial(n, p): by inversion when fewer than 30
+// successes or failures are expected, else from the normal approximation.
+func binomial(n int, p float64, rng RandomSource) int {
+	switch {
+	case n <= 0 || p <= 0:
+		return 0
+	case p >= 1:
+		return n
+	case p > 0.5:
+		return n - binomial(n, 1-p, rng)
+	}
+	mean := float64(n) * p
+	if mean >= 30 {
+		// Box-Muller
+		u1, u2 := rng.Float64(), rng.Float64()
+		z := math.Sqrt(-2*math.Log(1-u1)) * math.Cos(2*math.Pi*u2)
+		k := int(math.Round(mean + z*math.Sqrt(mean*(1-p))))
+		return min(max(k, 0), n)
+	}
+	u := rng.Float64()
+	pk := math.Pow(1-p, float64(n))
+	cdf := pk
+	k := 0
+	for u > cdf && k < n {
+		pk *= float64(n-k) / float64(k+1) * p / (1 - p)
+		k++
+		cdf += pk
+	}
+	return k
+}
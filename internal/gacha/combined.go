@@ -0,0 +1,61 @@
+package gacha
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoLegs = errors.New("combined goal needs at least one leg")
+
+// GoalLeg is one part of a combined goal: collect Copies UPs on the banner described by Params.
+// Example: 2 copies of the character on the character banner, then 1 signature weapon.
+type GoalLeg struct {
+	Params SimParams
+	Copies int // <=0 means 1
+}
+
+// simulateCombined returns the total draws to finish every leg, pulled one leg after another.
+// Each leg has its own pity state; the legs share the trial's rng. Leftover spark
+// points move to the next leg when that leg's spark has Carry set. Refunds are
+// account-wide: the first leg with refunds opens the wallet and later legs share it.
+func simulateCombined(legs []GoalLeg, rng RandomSource) (int, error) {
+	total := 0
+	var prev *Spark
+	var wallet *Wallet
+	for _, leg := range legs {
+		if wallet == nil {
+			w, err := NewWalletFromParams(leg.Params)
+			if err != nil {
+				return 0, err
+			}
+			wallet = w
+		} else if leg.Params.Refund != nil {
+			wallet.Refunds = leg.Params.Refund.Amounts
+		}
+		t, err := newTrial(leg.Params, wallet, rng)
+		if err != nil {
+			return 0, err
+		}
+		if t.spark != nil && prev != nil && leg.Params.Spark.Carry {
+			t.spark.Points += prev.Points
+		}
+		n, err := t.drawsForCopies((&SimBudget{Copies: leg.Copies}).copies())
+		if err != nil {
+			return 0, err
+		}
+		total += n
+		prev = t.spark
+	}
+	return total, nil
+}
+
+// RunMonteCarloCombined estimates the total draws needed to complete every leg,
+// with the same worker pool and reproducibility guarantees as RunMonteCarloParallel.
+func RunMonteCarloCombined(ctx context.Context, legs []GoalLeg, trials int, opt MCOptions) (Stats, error) {
+	if len(legs) == 0 {
+		return Stats{}, ErrNoLegs
+	}
+	return runTrials(ctx, trials, opt, func(rng RandomSource) (int, error) {
+		return simulateCombined(legs, rng)
+	})
+}
@@ -0,0 +1,288 @@
+package gacha
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrExactUnsupported = errors.New("exact solver does not support this configuration")
+
+// Distribution is the exact distribution of a trial metric.
+// Stats has the same meaning as in RunMonteCarlo (Samples is nil);
+// percentiles are the smallest k with CDF[k] >= q.
+type Distribution struct {
+	Stats
+	PMF []float64 // PMF[k] = P(metric == k)
+	CDF []float64 // CDF[k] = P(metric <= k)
+}
+
+// chain is the Markov chain over (Count, OffStreak, GuaranteedNext) of one
+// SoftPitySystem + BannerSystem pair. Transition probabilities come from the
+// engine's own effectiveProb and currentOffProb.
+type chain struct {
+	pity    int
+	streaks int       // number of OffStreak values (MaxOff+2 with banner, 1 without)
+	hitProb []float64 // hitProb[c] = P(hit | Count == c)
+	offProb []float64 // offProb[s] = P(off | hit, OffStreak == s, !GuaranteedNext)
+	maxOff  int
+	banner  bool
+	start   int // initial state index
+}
+
+func (m *chain) states() int { return m.pity * m.streaks * 2 }
+
+func (m *chain) index(c, s int, g bool) int {
+	i := (c*m.streaks + s) * 2
+	if g {
+		i++
+	}
+	return i
+}
+
+func (m *chain) decode(i int) (c, s int, g bool) {
+	g = i%2 == 1
+	i /= 2
+	return i / m.streaks, i % m.streaks, g
+}
+
+// newChain builds the chain for p. Multi-rarity, epitomized path, spark, refund,
+// multi-pull and non-streak off policies are not supported.
+func newChain(p SimParams) (*chain, error) {
+	if len(p.Tiers) > 0 || p.Path != nil || p.Spark != nil || p.Refund != nil || p.Multi != nil ||
+		OffPolicyKind(p.OffPolicy) == OffPolicyRadiance {
+		return nil, ErrExactUnsupported
+	}
+	if p.TargetRarity > 0 && p.TargetRarity != topRarity(p) {
+		return nil, ErrTierConfig
+	}
+	if p.Pity < 1 {
+		return nil, ErrSoftPityConfig
+	}
+	if err := validateProb(p.PBase); err != nil {
+		return nil, err
+	}
+	sp, err := NewSoftFromParams(p, nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &chain{pity: p.Pity, streaks: 1, hitProb: make([]float64, p.Pity)}
+	start := sp.Count
+	for c := 0; c < p.Pity; c++ {
+		sp.Count = c
+		m.hitProb[c] = sp.effectiveProb(p.PBase)
+	}
+	if b := NewBannerFromParams(sp, p); b != nil {
+		m.banner = true
+		m.maxOff = b.MaxOff
+		// OffStreak can reach MaxOff+1 before the guarantee resets it
+		m.streaks = b.MaxOff + 2
+		m.offProb = make([]float64, m.streaks)
+		for s := range m.offProb {
+			b.OffStreak = s
+			m.offProb[s] = b.currentOffProb()
+		}
+	}
+	m.start = m.index(start, 0, false)
+	return m, nil
+}
+
+// transition describes where probability mass goes from one state in one draw.
+type transition struct {
+	to   int
+	prob float64
+	hit  bool
+	up   bool
+}
+
+// step lists the transitions out of state i.
+// Without a banner every hit counts as UP, matching simulateOne.
+func (m *chain) step(i int) []transition {
+	c, s, g := m.decode(i)
+	h := m.hitProb[c]
+	var out []transition
+	if h < 1 {
+		next := c + 1
+		if next >= m.pity {
+			next = m.pity - 1
+		}
+		out = append(out, transition{to: m.index(next, s, g), prob: 1 - h})
+	}
+	if h <= 0 {
+		return out
+	}
+	if !m.banner || g {
+		return append(out, transition{to: m.index(0, 0, false), prob: h, hit: true, up: true})
+	}
+	pOff := m.offProb[s]
+	s2 := s + 1
+	if s2 >= m.streaks {
+		s2 = m.streaks - 1
+	}
+	return append(out,
+		transition{to: m.index(0, s2, s2 > m.maxOff), prob: h * pOff, hit: true},
+		transition{to: m.index(0, 0, false), prob: h * (1 - pOff), hit: true, up: true},
+	)
+}
+
+// exactTol is the leftover probability mass at which iteration stops.
+const exactTol = 1e-15
+
+// RunExact computes the exact distribution of the metric RunMonteCarlo samples
+// for the same params, goal and budget, by iterating the Markov chain over
+// (Count, OffStreak, GuaranteedNext) instead of sampling.
+// - GoalFirstHit / GoalFirstUP: PMF over the number of draws.
+// - GoalFixedBudget: PMF over the number of Hits/UPs within budget.NumDraws.
+// - GoalNthUP: PMF over the number of draws until budget.Copies UPs.
+// Multi-rarity params (Tiers), epitomized paths, sparks, refunds, multi-pulls
+// and the radiance off policy return ErrExactUnsupported.
+func RunExact(p SimParams, goal TrialGoal, budget *SimBudget) (Distribution, error) {
+	m, err := newChain(p)
+	if err != nil {
+		return Distribution{}, err
+	}
+	switch goal {
+	case GoalFirstHit, GoalFirstUP:
+		return newDistribution(m.firstPassage(goal, m.start)), nil
+	case GoalNthUP:
+		// every UP resets the chain to (0, 0, false), so later copies are i.i.d.
+		pmf := m.firstPassage(GoalFirstUP, m.start)
+		if n := budget.copies(); n > 1 {
+			again := m.firstPassage(GoalFirstUP, m.index(0, 0, false))
+			for i := 1; i < n; i++ {
+				pmf = convolve(pmf, again)
+			}
+		}
+		return newDistribution(pmf), nil
+	case GoalFixedBudget:
+		if budget == nil || budget.NumDraws <= 0 {
+			return newDistribution([]float64{1}), nil
+		}
+		return m.countWithin(budget.NumDraws), nil
+	}
+	return Distribution{}, ErrExactUnsupported
+}
+
+// firstPassage returns the PMF of draws until the first hit/UP from state start.
+func (m *chain) firstPassage(goal TrialGoal, start int) []float64 {
+	cur := make([]float64, m.states())
+	next := make([]float64, m.states())
+	cur[start] = 1
+	pmf := []float64{0}
+	// hard pity plus the guarantee bound the horizon; the cap only guards against
+	// pathological configs that never terminate.
+	maxDraws := m.pity*(m.streaks+1) + 1
+	for k := 1; k <= maxDraws; k++ {
+		clear(next)
+		done := 0.0
+		left := 0.0
+		for i, mass := range cur {
+			if mass == 0 {
+				continue
+			}
+			for _, t := range m.step(i) {
+				w := mass * t.prob
+				if (goal == GoalFirstHit && t.hit) || (goal == GoalFirstUP && t.up) {
+					done += w
+					continue
+				}
+				next[t.to] += w
+				left += w
+			}
+		}
+		pmf = append(pmf, done)
+		cur, next = next, cur
+		if left <= exactTol {
+			break
+		}
+	}
+	return pmf
+}
+
+// convolve returns the PMF of the sum of two independent variables.
+func convolve(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
+
+// countWithin returns the distribution of hits/UPs within n draws.
+func (m *chain) countWithin(n int) Distribution {
+	ns := m.states()
+	// cur[k][i]: probability of k successes so far and chain state i
+	cur := [][]float64{make([]float64, ns)}
+	cur[0][m.start] = 1
+	for d := 0; d < n; d++ {
+		next := make([][]float64, len(cur)+1)
+		for k := range next {
+			next[k] = make([]float64, ns)
+		}
+		for k, row := range cur {
+			for i, mass := range row {
+				if mass == 0 {
+					continue
+				}
+				for _, t := range m.step(i) {
+					if t.up {
+						next[k+1][t.to] += mass * t.prob
+					} else {
+						next[k][t.to] += mass * t.prob
+					}
+				}
+			}
+		}
+		// drop trailing success counts that are unreachable so far
+		for len(next) > 1 && sum(next[len(next)-1]) == 0 {
+			next = next[:len(next)-1]
+		}
+		cur = next
+	}
+	pmf := make([]float64, len(cur))
+	for k, row := range cur {
+		pmf[k] = sum(row)
+	}
+	return newDistribution(pmf)
+}
+
+func sum(xs []float64) float64 {
+	var s float64
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+// newDistribution derives CDF and Stats from a PMF over 0..len(pmf)-1.
+func newDistribution(pmf []float64) Distribution {
+	cdf := make([]float64, len(pmf))
+	var acc, mean float64
+	for k, p := range pmf {
+		acc += p
+		cdf[k] = acc
+		mean += float64(k) * p
+	}
+	var variance float64
+	for k, p := range pmf {
+		d := float64(k) - mean
+		variance += d * d * p
+	}
+	quantile := func(q float64) float64 { return cdfQuantile(cdf, q) }
+	return Distribution{
+		Stats: Stats{
+			Mean:   mean,
+			Var:    variance,
+			StdDev: math.Sqrt(variance),
+			P50:    quantile(0.50),
+			P90:    quantile(0.90),
+			P99:    quantile(0.99),
+		},
+		PMF: pmf,
+		CDF: cdf,
+	}
+}
@@ -0,0 +1,60 @@
+package gacha
+
+import (
+	"crypto/hmac"
+	cryptoRand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+)
+
+// FairRNG is a provably fair RandomSource (commit-reveal).
+// The server publishes Commitment(serverSeed) before the draws; the player
+// supplies clientSeed; nonce numbers the batches drawn from one seed.
+// The i-th value (i from 0) is the first 53 bits of
+// HMAC-SHA256(serverSeed, clientSeed + ":" + nonce + ":" + i) scaled to [0, 1),
+// so once serverSeed is revealed anyone can recompute every draw.
+type FairRNG struct {
+	mac    []byte // server seed, the HMAC key
+	prefix []byte // "clientSeed:nonce:"
+	cursor uint64 // values produced so far
+}
+
+// NewFairRNG creates the fair source of one batch.
+func NewFairRNG(serverSeed, clientSeed string, nonce uint64) *FairRNG {
+	prefix := clientSeed + ":" + strconv.FormatUint(nonce, 10) + ":"
+	return &FairRNG{mac: []byte(serverSeed), prefix: []byte(prefix)}
+}
+
+func (f *FairRNG) Float64() float64 {
+	h := hmac.New(sha256.New, f.mac)
+	h.Write(f.prefix)
+	h.Write(strconv.AppendUint(nil, f.cursor, 10))
+	f.cursor++
+	u := binary.BigEndian.Uint64(h.Sum(nil)) >> 11 // 53 bits
+	return float64(u) / (1 << 53)
+}
+
+// Cursor reports how many values have been drawn.
+func (f *FairRNG) Cursor() uint64 { return f.cursor }
+
+// NewServerSeed returns a fresh secret seed: 32 random bytes, hex encoded.
+func NewServerSeed() (string, error) {
+	var buf [32]byte
+	if _, err := cryptoRand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+// Commitment is the hex SHA-256 of serverSeed, safe to publish ahead.
+func Commitment(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckCommitment reports whether serverSeed is the seed behind commitment.
+func CheckCommitment(serverSeed, commitment string) bool {
+	return hmac.Equal([]byte(Commitment(serverSeed)), []byte(commitment))
+}
@@ -0,0 +1,79 @@
+package gacha
+
+import "sort"
+
+// Bucket is one bar of a histogram over metric values From..To inclusive.
+type Bucket struct {
+	From, To int
+	Prob     float64 // P(From <= metric <= To)
+	Cum      float64 // P(metric <= To)
+}
+
+// Histogram groups the PMF into buckets of width metric values, starting at
+// 0 and ending at the largest value with mass. Width < 1 means 1.
+func (d Distribution) Histogram(width int) []Bucket {
+	if width < 1 {
+		width = 1
+	}
+	last := len(d.PMF) - 1
+	for last > 0 && d.PMF[last] == 0 {
+		last--
+	}
+	var out []Bucket
+	for from := 0; from <= last; from += width {
+		b := Bucket{From: from, To: min(from+width-1, last)}
+		for k := b.From; k <= b.To; k++ {
+			b.Prob += d.PMF[k]
+		}
+		b.Cum = d.CDF[b.To]
+		out = append(out, b)
+	}
+	return out
+}
+
+// Quantile returns the smallest k with CDF[k] >= q, the rule RunExact uses
+// for P50/P90/P99.
+func (d Distribution) Quantile(q float64) float64 {
+	return cdfQuantile(d.CDF, q)
+}
+
+func cdfQuantile(cdf []float64, q float64) float64 {
+	for k, c := range cdf {
+		if c >= q-1e-12 {
+			return float64(k)
+		}
+	}
+	return float64(len(cdf) - 1)
+}
+
+// Quantiles returns the qs-quantiles of Samples, interpolated like the
+// P50/P90/P99 of RunMonteCarlo. Without samples every quantile is 0.
+func (s Stats) Quantiles(qs ...float64) []float64 {
+	sorted := append([]int(nil), s.Samples...)
+	sort.Ints(sorted)
+	out := make([]float64, len(qs))
+	for i, q := range qs {
+		out[i] = interpolate(sorted, q)
+	}
+	return out
+}
+
+// Empirical returns the distribution of Samples: PMF[k] is the share of
+// samples equal to k. Stats is s itself, so its percentiles stay interpolated.
+func (s Stats) Empirical() Distribution {
+	var pmf []float64
+	for _, k := range s.Samples {
+		for len(pmf) <= k {
+			pmf = append(pmf, 0)
+		}
+		pmf[k]++
+	}
+	cdf := make([]float64, len(pmf))
+	var acc float64
+	for k := range pmf {
+		pmf[k] /= float64(len(s.Samples))
+		acc += pmf[k]
+		cdf[k] = acc
+	}
+	return Distribution{Stats: s, PMF: pmf, CDF: cdf}
+}
@@ -0,0 +1,59 @@
+package gacha
+
+// WeightedItem is one pullable unit (character/weapon) with a relative weight.
+type WeightedItem struct {
+	ID     string
+	Weight float64 // relative weight within its list; <=0 counts as 1
+}
+
+// ItemPool is the roster of one rarity.
+// - Featured: UP items, picked when a hit is UP.
+// - Standard: off-banner items, picked otherwise.
+// If the list a draw asks for is empty, the other list is used.
+type ItemPool struct {
+	Featured []WeightedItem
+	Standard []WeightedItem
+}
+
+// Pick selects an item id for a hit of this rarity. Returns "" if the pool is empty.
+func (ip *ItemPool) Pick(isUp bool, rng RandomSource) string {
+	if ip == nil {
+		return ""
+	}
+	list, other := ip.Standard, ip.Featured
+	if isUp {
+		list, other = ip.Featured, ip.Standard
+	}
+	if len(list) == 0 {
+		list = other
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	if len(list) == 1 {
+		return list[0].ID
+	}
+	if rng == nil {
+		rng = DefaultRNG()
+	}
+	total := 0.0
+	for _, it := range list {
+		total += itemWeight(it)
+	}
+	u := rng.Float64() * total
+	acc := 0.0
+	for _, it := range list {
+		acc += itemWeight(it)
+		if u < acc {
+			return it.ID
+		}
+	}
+	return list[len(list)-1].ID
+}
+
+func itemWeight(it WeightedItem) float64 {
+	if it.Weight <= 0 {
+		return 1
+	}
+	return it.Weight
+}
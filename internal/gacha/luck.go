@@ -0,0 +1,125 @@
+package gacha
+
+import "context"
+
+// WaitFrom returns the distribution of draws until goal (GoalFirstHit or
+// GoalFirstUP) of p's measured tier, starting from the tier states pre rather
+// than from p.Cushion. Spark, refunds and multi-pulls are ignored: a wait is
+// counted in pulls actually made. Lower tiers never change the top tier's
+// odds, so they are dropped when the top tier is measured. The distribution
+// is exact when RunExact supports what is left; otherwise it is the empirical
+// PMF of trials samples drawn as in RunMonteCarloParallel, and exact reports
+// false. With trials <= 0 only the exact distribution is tried, and
+// ErrExactUnsupported means it would have to be sampled.
+func WaitFrom(ctx context.Context, p SimParams, goal TrialGoal, pre []TierSnapshot, trials int, opt MCOptions) (d Distribution, exact bool, err error) {
+	if goal != GoalFirstHit && goal != GoalFirstUP {
+		return Distribution{}, false, ErrExactUnsupported
+	}
+	p.Spark, p.Refund, p.Multi = nil, nil, nil
+	if p.TargetRarity <= 0 || p.TargetRarity == topRarity(p) {
+		p.Tiers, p.TargetRarity = nil, 0
+	}
+	if m, err := newChain(p); err == nil {
+		s := TierSnapshot{Rarity: topRarity(p)}
+		for _, snap := range pre {
+			if snap.Rarity == s.Rarity {
+				s = snap
+			}
+		}
+		c := min(max(s.Count, 0), m.pity-1)
+		off, g := 0, false
+		if m.banner {
+			off, g = min(max(s.OffStreak, 0), m.streaks-1), s.GuaranteedNext
+		}
+		return newDistribution(m.firstPassage(goal, m.index(c, off, g))), true, nil
+	}
+
+	if trials <= 0 {
+		return Distribution{}, false, ErrExactUnsupported
+	}
+	if _, err := NewTieredFromParams(p, nil); err != nil {
+		return Distribution{}, false, err
+	}
+	st, err := runTrials(ctx, trials, opt, func(rng RandomSource) (int, error) {
+		ts, err := NewTieredFromParams(p, rng)
+		if err != nil {
+			return 0, err
+		}
+		ts.Restore(pre)
+		target := p.TargetRarity
+		if target <= 0 {
+			target = ts.Tiers[0].Rarity
+		}
+		tier := ts.Tier(target)
+		if tier == nil {
+			return 0, ErrTierConfig
+		}
+		for draws := 1; ; draws++ {
+			out, err := ts.Draw()
+			if err != nil {
+				return 0, err
+			}
+			if out.Rarity == target && (goal == GoalFirstHit || countsAsUp(tier, out)) {
+				return draws, nil
+			}
+		}
+	})
+	if err != nil {
+		return Distribution{}, false, err
+	}
+	return st.Empirical(), false, nil
+}
+
+// countsAsUp reports whether a hit of tier meets GoalFirstUP, as in newDrawStep.
+func countsAsUp(tier *Tier, out TierOutcome) bool {
+	switch {
+	case tier.Path != nil && tier.Path.Target >= 0:
+		return out.OnTarget
+	case tier.Banner != nil:
+		return out.IsUp
+	}
+	return true
+}
+
+func topRarity(p SimParams) int {
+	if p.Rarity <= 0 {
+		return 5
+	}
+	return p.Rarity
+}
+
+// Rank places an observed metric k in d: better is P(metric > k), the share
+// of outcomes above k, and worse is P(metric < k). For draw counts, better is
+// the share of players who needed more draws.
+func (d Distribution) Rank(k int) (better, worse float64) {
+	switch {
+	case len(d.CDF) == 0:
+		return 0, 0
+	case k <= 0:
+		better = 1 - d.CDF[0]
+	case k < len(d.CDF):
+		better, worse = 1-d.CDF[k], d.CDF[k-1]
+	default:
+		worse = d.CDF[len(d.CDF)-1]
+	}
+	// rounding leaves CDF a hair off 1; a share that small is none at all
+	if better < 1e-12 {
+		better = 0
+	}
+	if worse > 1-1e-12 {
+		worse = 1
+	}
+	return better, worse
+}
+
+// Sum returns the distribution of the sum of independent metrics distributed
+// as ds; the sum of none is always 0.
+func Sum(ds ...Distribution) Distribution {
+	pmf := []float64{1}
+	for _, d := range ds {
+		if len(d.PMF) > 0 {
+			pmf = convolve(pmf, d.PMF)
+		}
+	}
+	return newDistribution(pmf)
+}
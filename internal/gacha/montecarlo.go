@@ -15,6 +15,8 @@ const (
 	GoalFirstUP  TrialGoal = "first_up"
 	// Given a fixed budget N, count number of Hits or UPs (depending on Banner!=nil).
 	GoalFixedBudget TrialGoal = "fixed_budget"
+	// Draws until the SimBudget.Copies-th UP (constellations / duplicates).
+	GoalNthUP TrialGoal = "nth_up"
 )
 
 // SimParams describes the mechanics for one simulation run.
@@ -28,16 +30,82 @@ type SimParams struct {
 	StartPct   *float64 // optional: start percentage (0..1); unused if StartAt is provided
 	TargetProb *float64 // optional: target probability at (Pity-1); if nil -> no soft ramp
 	Easing     string   // "linear", "easeOutQuad", "easeInOutCubic"; default linear
+	SoftMode   string   // "target_ramp" (default) or "per_draw_increment"
+	Increment  *float64 // per_draw_increment: +p per draw from StartAt; if nil -> no soft ramp
 	Cushion    int      // carry-over draws since last Hit when entering this pool
 
 	// Banner multi-off configuration. If OffProbs is empty, banner is disabled.
-	OffProbs []float64 // e.g., [0.5] or [0.5,0.4,0.3]
-	MaxOff   int       // <=0 means defaults to len(OffProbs)
+	OffProbs []float64   // e.g., [0.5] or [0.5,0.4,0.3]
+	MaxOff   int         // <=0 means defaults to len(OffProbs)
+	Path     *PathParams // optional epitomized path on top of the banner; needs OffProbs
+	// Off-decision policy: "streak" (default) or "radiance", whose base off
+	// probability is OffProbs[0] and win threshold RadianceThreshold.
+	OffPolicy         string
+	RadianceThreshold int
+
+	// Multi-rarity configuration. If Tiers is empty, only the tier above is drawn.
+	Rarity       int         // rarity of the tier above; <=0 means 5
+	BaseRarity   int         // rarity when no tier hits; <=0 means 3
+	Tiers        []SimParams // lower tiers with their own pity/banner; their Tiers fields are ignored
+	TargetRarity int         // tier measured by the goal; <=0 means the top tier
+
+	// Optional spark (exchange points) on the top tier; nil disables it.
+	Spark *SparkParams
+
+	// Optional multi-pulls: simulations pull in units of Multi.Size.
+	Multi *MultiParams
+
+	// Optional duplicate refunds; they need item rosters to detect duplicates.
+	Refund *RefundParams
+
+	// Optional item rosters; only used by draw APIs that report item ids.
+	Items     *ItemPool // roster of this tier's rarity
+	BaseItems *ItemPool // roster of BaseRarity (top-level params only)
+}
+
+// PathParams configures an epitomized path (see PathSystem).
+// With a path, UP in simulation goals means the charted Target item.
+type PathParams struct {
+	Featured int // number of featured items; <=0 means 2
+	Target   int // charted featured item; <0 means none charted
+	MaxFate  int // fate points that force Target; <=0 means 1
+}
+
+// SparkParams configures exchange points (see Spark).
+// In simulation goals a redeemed spark counts as an UP of the top tier.
+type SparkParams struct {
+	Threshold int  // points per exchange
+	PerDraw   int  // points per draw; <=0 means 1
+	Points    int  // points held when entering this pool
+	Carry     bool // leftover points carry into the next leg of a combined goal
+}
+
+// MultiParams describes a multi-pull (e.g., a ten-pull) and its rarity floor.
+type MultiParams struct {
+	Size      int // draws per multi-pull, >= 1
+	MinRarity int // guaranteed rarity or better per multi-pull; 0 => none
+}
+
+// RefundParams configures duplicate refunds in a secondary currency (see Wallet).
+type RefundParams struct {
+	Amounts   map[int][]int  // per rarity: refund of the 1st, 2nd, ... duplicate; last value repeats
+	PerDraw   int            // secondary currency per draw when reconverting
+	Reconvert bool           // spend refunds on draws; goals then count paid draws only
+	Owned     map[string]int // copies already owned when the trial starts
 }
 
-// SimBudget controls the number of draws used in GoalFixedBudget.
+// SimBudget carries per-trial goal parameters.
 type SimBudget struct {
-	NumDraws int // number of draws in one trial
+	NumDraws int // GoalFixedBudget: number of draws in one trial
+	Copies   int // GoalNthUP: number of UPs to collect; <=0 means 1
+}
+
+// copies returns the number of UPs GoalNthUP collects.
+func (b *SimBudget) copies() int {
+	if b == nil || b.Copies <= 0 {
+		return 1
+	}
+	return b.Copies
 }
 
 // Stats summarizes simulation results.
@@ -77,24 +145,7 @@ func calcStats(xs []int) Stats {
 	// percentiles
 	cp := append([]int(nil), xs...)
 	sort.Ints(cp)
-	percentile := func(p float64) float64 {
-		if n == 1 {
-			return float64(cp[0])
-		}
-		if p <= 0 {
-			return float64(cp[0])
-		}
-		if p >= 1 {
-			return float64(cp[n-1])
-		}
-		pos := p * float64(n-1)
-		i := int(math.Floor(pos))
-		f := pos - float64(i)
-		if i+1 >= n {
-			return float64(cp[i])
-		}
-		return float64(cp[i])*(1-f) + float64(cp[i+1])*f
-	}
+	percentile := func(p float64) float64 { return interpolate(cp, p) }
 
 	return Stats{
 		Mean:    mean,
@@ -107,38 +158,59 @@ func calcStats(xs []int) Stats {
 	}
 }
 
-// newSoft constructs a fresh SoftPitySystem using SimParams.
-func newSoft(p SimParams) (*SoftPitySystem, error) {
+// interpolate returns the p-quantile of sorted samples, interpolating
+// linearly between order statistics.
+func interpolate[T int | float64](sorted []T, p float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 || p <= 0 {
+		return float64(sorted[0])
+	}
+	if p >= 1 {
+		return float64(sorted[n-1])
+	}
+	pos := p * float64(n-1)
+	i := int(math.Floor(pos))
+	f := pos - float64(i)
+	if i+1 >= n {
+		return float64(sorted[i])
+	}
+	return float64(sorted[i])*(1-f) + float64(sorted[i+1])*f
+}
+
+// NewSoftFromParams constructs a fresh SoftPitySystem using SimParams.
+// Cushion is applied as the initial Count. A nil rng uses DefaultRNG().
+func NewSoftFromParams(p SimParams, rng RandomSource) (*SoftPitySystem, error) {
 	var cfg *SoftPityConfig
-	if p.TargetProb != nil && (p.StartAt != nil || p.StartPct != nil) {
-		startAt := 0
-		if p.StartAt != nil {
-			startAt = *p.StartAt
-		} else {
-			sp := *p.StartPct
-			if sp < 0 {
-				sp = 0
-			}
-			if sp > 1 {
-				sp = 1
-			}
-			startAt = int(math.Ceil(sp * float64(p.Pity)))
-			if startAt >= p.Pity {
-				startAt = p.Pity - 1
-			}
+	mode := SoftMode(p.SoftMode)
+	if mode == "" {
+		mode = SoftTargetRamp
+	}
+	hasStart := p.StartAt != nil || p.StartPct != nil
+	switch {
+	case mode == SoftPerDrawIncrement && p.Increment != nil && hasStart:
+		cfg = &SoftPityConfig{
+			Mode:      mode,
+			Pity:      p.Pity,
+			StartAt:   resolveStartAt(p),
+			Increment: *p.Increment,
 		}
+	case mode == SoftTargetRamp && p.TargetProb != nil && hasStart:
 		easing := Easing(p.Easing)
 		if easing == "" {
 			easing = EaseLinear
 		}
 		cfg = &SoftPityConfig{
+			Mode:       mode,
 			Pity:       p.Pity,
-			StartAt:    startAt,
+			StartAt:    resolveStartAt(p),
 			TargetProb: *p.TargetProb,
 			Easing:     easing,
 		}
 	}
-	sp, err := NewSoftPitySystem(p.Pity, cfg, nil)
+	sp, err := NewSoftPitySystem(p.Pity, cfg, rng)
 	if err != nil {
 		return nil, err
 	}
@@ -154,88 +226,269 @@ func newSoft(p SimParams) (*SoftPitySystem, error) {
 	return sp, nil
 }
 
-// newBanner wraps a fresh BannerSystem if OffProbs provided; else returns nil.
-func newBanner(sp *SoftPitySystem, p SimParams) *BannerSystem {
+// resolveStartAt returns StartAt, or derives it from StartPct (ceil(pct * Pity), capped at Pity-1).
+func resolveStartAt(p SimParams) int {
+	if p.StartAt != nil {
+		return *p.StartAt
+	}
+	sp := *p.StartPct
+	if sp < 0 {
+		sp = 0
+	}
+	if sp > 1 {
+		sp = 1
+	}
+	startAt := int(math.Ceil(sp * float64(p.Pity)))
+	if startAt >= p.Pity {
+		startAt = p.Pity - 1
+	}
+	return startAt
+}
+
+// NewBannerFromParams wraps a fresh BannerSystem if OffProbs provided; else returns nil.
+// The off-decision policy follows p.OffPolicy; unknown kinds fall back to streak.
+func NewBannerFromParams(sp *SoftPitySystem, p SimParams) *BannerSystem {
 	if len(p.OffProbs) == 0 {
 		return nil
 	}
-	return NewBannerSystem(sp, p.OffProbs, p.MaxOff)
+	b := NewBannerSystem(sp, p.OffProbs, p.MaxOff)
+	if OffPolicyKind(p.OffPolicy) == OffPolicyRadiance {
+		threshold := p.RadianceThreshold
+		if threshold <= 0 {
+			threshold = 1
+		}
+		b.Policy = &RadiancePolicy{Base: b.OffProbs[0], Threshold: threshold}
+	}
+	return b
+}
+
+// NewSparkFromParams returns the spark counter described by p, or nil if none.
+func NewSparkFromParams(p SimParams) (*Spark, error) {
+	if p.Spark == nil {
+		return nil, nil
+	}
+	perDraw := p.Spark.PerDraw
+	if perDraw <= 0 {
+		perDraw = 1
+	}
+	return NewSpark(p.Spark.Threshold, perDraw, p.Spark.Points)
+}
+
+// NewPathFromParams wraps banner with the epitomized path in p; returns nil if
+// either is absent.
+func NewPathFromParams(banner *BannerSystem, p SimParams) (*PathSystem, error) {
+	if banner == nil || p.Path == nil {
+		return nil, nil
+	}
+	featured, maxFate := p.Path.Featured, p.Path.MaxFate
+	if featured <= 0 {
+		featured = 2
+	}
+	if maxFate <= 0 {
+		maxFate = 1
+	}
+	return NewPathSystem(banner, featured, p.Path.Target, maxFate)
+}
+
+// NewTieredFromParams builds a TieredSystem from the top tier in p plus p.Tiers.
+// All tiers share rng. A nil rng uses DefaultRNG().
+func NewTieredFromParams(p SimParams, rng RandomSource) (*TieredSystem, error) {
+	if rng == nil {
+		rng = DefaultRNG()
+	}
+	top := p
+	top.Tiers = nil
+	if top.Rarity <= 0 {
+		top.Rarity = 5
+	}
+	base := p.BaseRarity
+	if base <= 0 {
+		base = 3
+	}
+	var tiers []*Tier
+	for _, tp := range append([]SimParams{top}, p.Tiers...) {
+		soft, err := NewSoftFromParams(tp, rng)
+		if err != nil {
+			return nil, err
+		}
+		banner := NewBannerFromParams(soft, tp)
+		path, err := NewPathFromParams(banner, tp)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, &Tier{
+			Rarity: tp.Rarity,
+			PBase:  tp.PBase,
+			Soft:   soft,
+			Banner: banner,
+			Path:   path,
+			Items:  tp.Items,
+		})
+	}
+	ts, err := NewTieredSystem(tiers, base, rng)
+	if err != nil {
+		return nil, err
+	}
+	ts.BaseItems = p.BaseItems
+	if p.Multi != nil {
+		ts.MultiFloor = p.Multi.MinRarity
+	}
+	return ts, nil
+}
+
+// drawStep performs one draw and reports whether the measured tier hit and
+// whether that hit counts as UP. Without a banner layer every hit counts as UP;
+// with an epitomized path only the charted item does.
+type drawStep func() (hit, up bool, err error)
+
+// newDrawStep wires the draw system described by p into a drawStep.
+// Refunds need item ids, so a non-nil wallet records every pull of a tiered system.
+// With p.Multi, pulls come from whole multi-pulls drawn ahead of time.
+func newDrawStep(p SimParams, wallet *Wallet, rng RandomSource) (drawStep, error) {
+	if len(p.Tiers) > 0 || wallet != nil || p.Multi != nil {
+		ts, err := NewTieredFromParams(p, rng)
+		if err != nil {
+			return nil, err
+		}
+		if (ts.MultiFloor > 0 && ts.Tier(ts.MultiFloor) == nil) || (p.Multi != nil && p.Multi.Size < 1) {
+			return nil, ErrTierConfig
+		}
+		var pending []TierOutcome
+		next := ts.Draw
+		if p.Multi != nil && p.Multi.Size > 1 {
+			next = func() (TierOutcome, error) {
+				if len(pending) == 0 {
+					outs, err := ts.DrawMulti(p.Multi.Size)
+					if err != nil {
+						return TierOutcome{}, err
+					}
+					pending = outs
+				}
+				out := pending[0]
+				pending = pending[1:]
+				return out, nil
+			}
+		}
+		target := p.TargetRarity
+		if target <= 0 {
+			target = ts.Tiers[0].Rarity
+		}
+		tier := ts.Tier(target)
+		if tier == nil {
+			return nil, ErrTierConfig
+		}
+		return func() (bool, bool, error) {
+			out, err := next()
+			if err != nil {
+				return false, false, err
+			}
+			wallet.Record(out.Rarity, out.Item)
+			hit := out.Rarity == target
+			switch {
+			case tier.Path != nil && tier.Path.Target >= 0:
+				return hit, hit && out.OnTarget, nil
+			case tier.Banner != nil:
+				return hit, hit && out.IsUp, nil
+			}
+			return hit, hit, nil
+		}, nil
+	}
+
+	if p.TargetRarity > 0 && p.TargetRarity != p.Rarity && !(p.Rarity <= 0 && p.TargetRarity == 5) {
+		return nil, ErrTierConfig
+	}
+	sp, err := NewSoftFromParams(p, rng)
+	if err != nil {
+		return nil, err
+	}
+	banner := NewBannerFromParams(sp, p)
+	path, err := NewPathFromParams(banner, p)
+	if err != nil {
+		return nil, err
+	}
+	if path != nil && path.Target >= 0 {
+		return func() (bool, bool, error) {
+			out, err := path.Draw(p.PBase)
+			return out.Hit, out.OnTarget, err
+		}, nil
+	}
+	if path != nil {
+		return func() (bool, bool, error) {
+			out, err := path.Draw(p.PBase)
+			return out.Hit, out.Hit && out.IsUp, err
+		}, nil
+	}
+	if banner == nil {
+		return func() (bool, bool, error) {
+			hit, err := sp.Draw(p.PBase)
+			return hit, hit, err
+		}, nil
+	}
+	return func() (bool, bool, error) {
+		out, err := banner.Draw(p.PBase)
+		return out.Hit, out.Hit && out.IsUp, err
+	}, nil
 }
 
 // simulateOne returns the primary metric for one trial depending on the goal.
 // - GoalFirstHit: number of draws until first Hit
 // - GoalFirstUP:  number of draws until first UP
 // - GoalFixedBudget: number of Hits (if banner==nil) or UPs (if banner!=nil) within budget.NumDraws
-func simulateOne(p SimParams, goal TrialGoal, budget *SimBudget) (int, error) {
-	sp, err := newSoft(p)
+// - GoalNthUP: number of draws until budget.Copies UPs
+// With Tiers configured, Hit/UP refer to the TargetRarity tier. A nil rng uses DefaultRNG().
+// With a spark, every redeemed exchange counts as one UP (not as a Hit); points
+// are spent as soon as they suffice.
+// With Refund.Reconvert, draws count paid draws only: refunds buy extra draws
+// as soon as they suffice, and FixedBudget spends them after the paid budget.
+// With Multi, draw counts are rounded up to whole multi-pulls.
+func simulateOne(p SimParams, goal TrialGoal, budget *SimBudget, rng RandomSource) (int, error) {
+	wallet, err := NewWalletFromParams(p)
+	if err != nil {
+		return 0, err
+	}
+	t, err := newTrial(p, wallet, rng)
 	if err != nil {
 		return 0, err
 	}
-	banner := newBanner(sp, p)
 
 	switch goal {
-	case GoalFirstHit:
+	case GoalFirstHit, GoalFirstUP:
 		draws := 0
 		for {
-			draws++
-			hit, err := sp.Draw(p.PBase)
+			hit, up, paid, err := t.pull()
 			if err != nil {
 				return 0, err
 			}
-			if hit {
-				return draws, nil
-			}
-		}
-
-	case GoalFirstUP:
-		if banner == nil {
-			// If banner layer is not configured, fall back to first Hit.
-			draws := 0
-			for {
+			if paid {
 				draws++
-				hit, err := sp.Draw(p.PBase)
-				if err != nil {
-					return 0, err
-				}
-				if hit {
-					return draws, nil
-				}
 			}
-		}
-		draws := 0
-		for {
-			draws++
-			out, err := banner.Draw(p.PBase)
-			if err != nil {
-				return 0, err
-			}
-			if out.Hit && out.IsUp {
-				return draws, nil
+			if (goal == GoalFirstHit && hit) || (goal == GoalFirstUP && (up || t.spark.Redeem())) {
+				return t.finish(draws), nil
 			}
 		}
 
+	case GoalNthUP:
+		return t.drawsForCopies(budget.copies())
+
 	case GoalFixedBudget:
 		if budget == nil || budget.NumDraws <= 0 {
 			return 0, nil
 		}
-		count := 0
-		for i := 0; i < budget.NumDraws; i++ {
-			if banner == nil {
-				hit, err := sp.Draw(p.PBase)
-				if err != nil {
-					return 0, err
-				}
-				if hit {
-					count++
-				}
-			} else {
-				out, err := banner.Draw(p.PBase)
-				if err != nil {
-					return 0, err
-				}
-				if out.Hit && out.IsUp {
-					count++
-				}
+		count, paidDraws := 0, 0
+		// the cap only guards against refunds worth more than the draws they came from
+		for draws := 0; draws < budget.NumDraws*maxFreeRatio && (paidDraws < budget.NumDraws || t.canReconvert()); draws++ {
+			_, up, paid, err := t.pull()
+			if err != nil {
+				return 0, err
+			}
+			if paid {
+				paidDraws++
+			}
+			if up {
+				count++
+			}
+			for t.spark.Redeem() {
+				count++
 			}
 		}
 		return count, nil
@@ -244,6 +497,107 @@ func simulateOne(p SimParams, goal TrialGoal, budget *SimBudget) (int, error) {
 	return 0, nil
 }
 
+// maxFreeRatio bounds the draws of one FixedBudget trial to this multiple of its paid budget.
+const maxFreeRatio = 100
+
+// trial is one simulated player on one pool: the draw system plus the
+// balances the player carries.
+type trial struct {
+	step      drawStep
+	spark     *Spark
+	wallet    *Wallet // may be nil
+	reconvert bool    // spend refunds on draws
+	multi     int     // draws per multi-pull; <=1 => single pulls
+	pulls     int     // draws so far, paid or not
+}
+
+// newTrial wires p into a trial. wallet may be shared across pools.
+func newTrial(p SimParams, wallet *Wallet, rng RandomSource) (*trial, error) {
+	step, err := newDrawStep(p, wallet, rng)
+	if err != nil {
+		return nil, err
+	}
+	spark, err := newTrialSpark(p)
+	if err != nil {
+		return nil, err
+	}
+	return &trial{
+		step:      step,
+		spark:     spark,
+		wallet:    wallet,
+		reconvert: p.Refund != nil && p.Refund.Reconvert,
+		multi:     multiSize(p),
+	}, nil
+}
+
+func multiSize(p SimParams) int {
+	if p.Multi == nil {
+		return 1
+	}
+	return p.Multi.Size
+}
+
+// finish adds the rest of the current multi-pull to paid draws: the goal may
+// complete mid-multi, but the whole multi is bought.
+func (t *trial) finish(draws int) int {
+	if t.multi > 1 {
+		draws += (t.multi - t.pulls%t.multi) % t.multi
+	}
+	return draws
+}
+
+// canReconvert reports whether refunds can pay for the next draw.
+func (t *trial) canReconvert() bool {
+	return t.reconvert && t.wallet.CanBuyPull()
+}
+
+// pull performs one draw and earns spark points; paid is false when refunds
+// paid for it.
+func (t *trial) pull() (hit, up, paid bool, err error) {
+	paid = !(t.reconvert && t.wallet.BuyPull())
+	t.pulls++
+	hit, up, err = t.step()
+	if err != nil {
+		return false, false, false, err
+	}
+	t.spark.Earn()
+	return hit, up, paid, nil
+}
+
+// newTrialSpark returns the spark of the measured tier: the top tier's, or nil
+// when the goal targets a lower rarity.
+func newTrialSpark(p SimParams) (*Spark, error) {
+	top := p.Rarity
+	if top <= 0 {
+		top = 5
+	}
+	if p.TargetRarity > 0 && p.TargetRarity != top {
+		return nil, nil
+	}
+	return NewSparkFromParams(p)
+}
+
+// drawsForCopies counts paid draws until draws plus spark exchanges have produced n UPs.
+func (t *trial) drawsForCopies(n int) (int, error) {
+	draws, got := 0, 0
+	for got < n {
+		_, up, paid, err := t.pull()
+		if err != nil {
+			return 0, err
+		}
+		if paid {
+			draws++
+		}
+		if up {
+			got++
+		}
+		for got < n && t.spark.Redeem() {
+			got++
+		}
+	}
+	return t.finish(draws), nil
+}
+
 // RunMonteCarlo repeats trials and returns summary stats.
 // goal determines what metric is recorded per trial.
 func RunMonteCarlo(p SimParams, goal TrialGoal, trials int, budget *SimBudget) (Stats, error) {
@@ -252,7 +606,7 @@ func RunMonteCarlo(p SimParams, goal TrialGoal, trials int, budget *SimBudget) (
 	}
 	samples := make([]int, trials)
 	for i := 0; i < trials; i++ {
-		v, err := simulateOne(p, goal, budget)
+		v, err := simulateOne(p, goal, budget, nil)
 		if err != nil {
 			return Stats{}, err
 		}
@@ -0,0 +1,153 @@
+package gacha
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// mcBlockSize is the number of consecutive trials sharing one RNG stream.
+// It is part of the reproducibility contract: changing it changes results for a seed.
+const mcBlockSize = 1024
+
+// MCOptions controls RunMonteCarloParallel.
+type MCOptions struct {
+	Seed     uint64    // master seed; the same seed yields bit-identical Stats
+	Workers  int       // <=0 means runtime.GOMAXPROCS(0)
+	Adaptive *Adaptive // optional; keep sampling until a statistic is precise enough
+}
+
+// Adaptive sampling runs the requested trials, then adds rounds that double
+// the total until the bootstrap interval (see Stats.Bootstrap) of Quantity is
+// at most Tolerance wide on each side, or MaxTrials trials have run. Round r
+// draws from master seed derived(Seed, r), so results stay reproducible.
+type Adaptive struct {
+	Quantity  Quantity
+	Tolerance float64 // target half-width of the interval; <= 0 runs MaxTrials
+	MaxTrials int     // cap on the total trials
+	Level     float64 // confidence level; outside (0,1) means 0.95
+	Resamples int     // bootstrap resamples per check; <= 0 means 200
+}
+
+// done reports whether the samples of st meet the tolerance, checked with
+// the bootstrap seed of the run.
+func (a *Adaptive) done(st Stats, seed uint64) bool {
+	iv, _ := st.Bootstrap(a.Resamples, a.Level, seed).Of(a.Quantity)
+	return a.Tolerance > 0 && iv.HalfWidth() <= a.Tolerance
+}
+
+// splitmix64 is a bijective mixer used to derive independent stream seeds.
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// blockRNG returns the RNG stream of trial block b under master seed.
+func blockRNG(seed uint64, b int) RandomSource {
+	return NewSeededRNG(splitmix64(seed ^ splitmix64(uint64(b))))
+}
+
+// RunMonteCarloParallel is RunMonteCarlo spread over a worker pool.
+// Trials are split into fixed blocks of mcBlockSize; block b always draws from
+// the stream NewSeededRNG(derived(Seed, b)) and writes samples[b*size:...], so the
+// result depends only on Seed, never on Workers or scheduling.
+// Cancelling ctx stops the run between trials and returns ctx.Err().
+func RunMonteCarloParallel(ctx context.Context, p SimParams, goal TrialGoal, trials int, budget *SimBudget, opt MCOptions) (Stats, error) {
+	return runTrials(ctx, trials, opt, func(rng RandomSource) (int, error) {
+		return simulateOne(p, goal, budget, rng)
+	})
+}
+
+// runTrials runs trial over the worker pool with per-block seeded streams,
+// in rounds when opt.Adaptive is set.
+func runTrials(ctx context.Context, trials int, opt MCOptions, trial func(rng RandomSource) (int, error)) (Stats, error) {
+	ad := opt.Adaptive
+	if ad != nil {
+		if _, ok := (Uncertainty{}).Of(ad.Quantity); !ok || ad.MaxTrials < trials {
+			return Stats{}, ErrAdaptive
+		}
+	}
+	samples, err := sampleTrials(ctx, trials, opt, trial)
+	if err != nil {
+		return Stats{}, err
+	}
+	for round := uint64(1); ad != nil && len(samples) > 0 && len(samples) < ad.MaxTrials; round++ {
+		if ad.done(Stats{Samples: samples}, opt.Seed) {
+			break
+		}
+		more := opt
+		more.Seed = splitmix64(opt.Seed + round)
+		next, err := sampleTrials(ctx, min(len(samples), ad.MaxTrials-len(samples)), more, trial)
+		if err != nil {
+			return Stats{}, err
+		}
+		samples = append(samples, next...)
+	}
+	return calcStats(samples), nil
+}
+
+// sampleTrials runs one round of trials.
+func sampleTrials(ctx context.Context, trials int, opt MCOptions, trial func(rng RandomSource) (int, error)) ([]int, error) {
+	if trials <= 0 {
+		return nil, nil
+	}
+	workers := opt.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	blocks := (trials + mcBlockSize - 1) / mcBlockSize
+	if workers > blocks {
+		workers = blocks
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	samples := make([]int, trials)
+	var (
+		next     atomic.Int64
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				b := int(next.Add(1) - 1)
+				if b >= blocks {
+					return
+				}
+				rng := blockRNG(opt.Seed, b)
+				end := min((b+1)*mcBlockSize, trials)
+				for i := b * mcBlockSize; i < end; i++ {
+					if err := ctx.Err(); err != nil {
+						fail(err)
+						return
+					}
+					v, err := trial(rng)
+					if err != nil {
+						fail(err)
+						return
+					}
+					samples[i] = v
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return samples, nil
+}
@@ -0,0 +1,127 @@
+package gacha
+
+import "errors"
+
+var ErrPathConfig = errors.New("invalid epitomized path config")
+
+// PathSystem adds an epitomized path (fate points) on top of a BannerSystem,
+// as used by weapon banners with several featured items.
+// - Banner decides UP vs off-banner as usual (including its guarantee).
+// - An UP hit picks one of Featured items uniformly.
+// - The player charts a path to featured item Target; every hit that is not
+// Target (off-banner or another featured item) earns one fate point.
+// - Once FatePoints >= MaxFate the next hit is Target outright; getting Target
+// by any route resets FatePoints to 0.
+// - Target < 0 means no path is charted and no fate points accrue.
+type PathSystem struct {
+	Banner     *BannerSystem
+	Featured   int // number of featured (UP) items, >= 1
+	Target     int // index of the charted item in [0, Featured); <0 => none
+	MaxFate    int // fate points that force Target, >= 1
+	FatePoints int
+}
+
+// PathOutcome reports one draw under epitomized path rules.
+type PathOutcome struct {
+	BannerOutcome
+	Featured   int  // index of the featured item obtained; -1 on a miss or off-banner hit
+	OnTarget   bool // true if the charted item was obtained
+	FatePoints int  // fate points after this draw
+}
+
+// NewPathSystem wraps banner with an epitomized path.
+// Featured and maxFate must be >= 1 and target < featured.
+func NewPathSystem(banner *BannerSystem, featured, target, maxFate int) (*PathSystem, error) {
+	if banner == nil || featured < 1 || maxFate < 1 || target >= featured {
+		return nil, ErrPathConfig
+	}
+	if target < 0 {
+		target = -1
+	}
+	return &PathSystem{Banner: banner, Featured: featured, Target: target, MaxFate: maxFate}, nil
+}
+
+// Draw performs one draw; SoftPity decides the hit, resolveHit the item.
+func (ps *PathSystem) Draw(pBase float64) (PathOutcome, error) {
+	hit, err := ps.Banner.SoftPity.Draw(pBase)
+	if err != nil {
+		return PathOutcome{}, err
+	}
+	if !hit {
+		return PathOutcome{
+			BannerOutcome: BannerOutcome{
+				Count:          ps.Banner.SoftPity.Count,
+				GuaranteedNext: ps.Banner.GuaranteedNext,
+				OffStreak:      ps.Banner.OffStreak,
+			},
+			Featured:   -1,
+			FatePoints: ps.FatePoints,
+		}, nil
+	}
+	return ps.resolveHit()
+}
+
+// resolveHit decides the item of a Hit that has already happened.
+func (ps *PathSystem) resolveHit() (PathOutcome, error) {
+	b := ps.Banner
+	if ps.Target >= 0 && ps.FatePoints >= ps.MaxFate {
+		// the charted item counts as UP, so it also clears the banner guarantee
+		b.GuaranteedNext = false
+		b.OffStreak = 0
+		ps.FatePoints = 0
+		return PathOutcome{
+			BannerOutcome: BannerOutcome{Hit: true, IsUp: true, Count: b.SoftPity.Count},
+			Featured:      ps.Target,
+			OnTarget:      true,
+		}, nil
+	}
+
+	bo, err := b.resolveHit()
+	if err != nil {
+		return PathOutcome{}, err
+	}
+	out := PathOutcome{BannerOutcome: bo, Featured: -1}
+	if bo.IsUp {
+		out.Featured = 0
+		if ps.Featured > 1 {
+			out.Featured = int(b.SoftPity.RNG.Float64() * float64(ps.Featured))
+			if out.Featured >= ps.Featured {
+				out.Featured = ps.Featured - 1
+			}
+		}
+	}
+	if ps.Target >= 0 {
+		out.OnTarget = out.Featured == ps.Target
+		if out.OnTarget {
+			ps.FatePoints = 0
+		} else if ps.FatePoints < ps.MaxFate {
+			ps.FatePoints++
+		}
+	}
+	out.FatePoints = ps.FatePoints
+	return out, nil
+}
+
+// settle applies the state change of a hit whose result is already known
+// (see TieredSystem.Observe). With full fate points only the target is possible.
+func (ps *PathSystem) settle(isUp, onTarget bool) error {
+	b := ps.Banner
+	if ps.Target >= 0 && ps.FatePoints >= ps.MaxFate {
+		b.GuaranteedNext = false
+		b.OffStreak = 0
+		ps.FatePoints = 0
+		if !onTarget {
+			return ErrObservation
+		}
+		return nil
+	}
+	err := b.settle(!isUp)
+	if ps.Target >= 0 {
+		if onTarget {
+			ps.FatePoints = 0
+		} else if ps.FatePoints < ps.MaxFate {
+			ps.FatePoints++
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package gacha
+
+import "math"
+
+// OffPolicyKind names a built-in OffPolicy.
+type OffPolicyKind string
+
+const (
+	// OffPolicyStreak indexes OffProbs by the current OffStreak (default).
+	OffPolicyStreak OffPolicyKind = "streak"
+	// OffPolicyRadiance uses a hidden counter that forces a win at a threshold
+	// ("capturing radiance").
+	OffPolicyRadiance OffPolicyKind = "radiance"
+)
+
+// OffPolicy decides whether a non-guaranteed Hit goes off-banner.
+// BannerSystem asks OffProb before each such Hit and reports the result through
+// Record; guaranteed Hits consult neither.
+type OffPolicy interface {
+	// OffProb returns P(off) for the next non-guaranteed Hit; <= 0 means a certain win.
+	OffProb(offStreak int) float64
+	// Record updates policy state after a non-guaranteed Hit resolved.
+	Record(off bool)
+}
+
+// StreakPolicy is the classic multi-off rule: OffProbs[min(OffStreak, len-1)].
+type StreakPolicy struct {
+	OffProbs []float64
+}
+
+func (p StreakPolicy) OffProb(offStreak int) float64 {
+	if len(p.OffProbs) == 0 {
+		return 0.5
+	}
+	idx := offStreak
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(p.OffProbs) {
+		idx = len(p.OffProbs) - 1 // repeat the last value
+	}
+	// keep strictly within (0,1) to avoid degenracy
+	prob := p.OffProbs[idx]
+	if prob <= 0 {
+		prob = math.SmallestNonzeroFloat64
+	}
+	if prob >= 1 {
+		prob = 1 - 1e-12
+	}
+	return prob
+}
+
+func (StreakPolicy) Record(bool) {}
+
+// RadiancePolicy consolidates the 50/50 with a hidden counter:
+// - each lost 50/50 increments Counter, each won one decrements it (not below 0);
+// - once Counter >= Threshold the next 50/50 is won outright and Counter resets to 0.
+// Below the threshold the off probability is Base.
+type RadiancePolicy struct {
+	Base      float64
+	Threshold int
+	Counter   int
+}
+
+func (p *RadiancePolicy) OffProb(int) float64 {
+	if p.Counter >= p.Threshold {
+		return 0
+	}
+	return p.Base
+}
+
+func (p *RadiancePolicy) Record(off bool) {
+	switch {
+	case off:
+		p.Counter++
+	case p.Counter >= p.Threshold:
+		p.Counter = 0
+	case p.Counter > 0:
+		p.Counter--
+	}
+}
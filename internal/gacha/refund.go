@@ -0,0 +1,79 @@
+package gacha
+
+import "errors"
+
+var ErrRefundConfig = errors.New("invalid refund config")
+
+// Wallet tracks one player's duplicate refunds in a secondary currency
+// (starglitter, stardust, ...) and converts them back into draws.
+// - Record refunds Refunds[rarity][k] for the (k+1)-th duplicate of an item;
+// the last value repeats and rarities without an entry refund nothing.
+// - BuyPull spends PerDraw of Balance for one draw.
+// A nil *Wallet is valid: it records nothing and never buys draws.
+type Wallet struct {
+	Refunds map[int][]int
+	PerDraw int // cost of one draw in the secondary currency; <=0 disables BuyPull
+	Balance int
+	Owned   map[string]int // copies owned per item id
+}
+
+// NewWallet creates an empty wallet; owned seeds the collection and is copied.
+func NewWallet(refunds map[int][]int, perDraw int, owned map[string]int) (*Wallet, error) {
+	if perDraw < 0 {
+		return nil, ErrRefundConfig
+	}
+	for _, amounts := range refunds {
+		for _, a := range amounts {
+			if a < 0 {
+				return nil, ErrRefundConfig
+			}
+		}
+	}
+	w := &Wallet{Refunds: refunds, PerDraw: perDraw, Owned: make(map[string]int, len(owned))}
+	for id, n := range owned {
+		w.Owned[id] = n
+	}
+	return w, nil
+}
+
+// NewWalletFromParams returns the wallet described by p.Refund, or nil if none.
+func NewWalletFromParams(p SimParams) (*Wallet, error) {
+	if p.Refund == nil {
+		return nil, nil
+	}
+	return NewWallet(p.Refund.Amounts, p.Refund.PerDraw, p.Refund.Owned)
+}
+
+// Record adds one copy of item and returns the refund it earned.
+// Items without an id cannot be told apart and refund nothing.
+func (w *Wallet) Record(rarity int, item string) int {
+	if w == nil || item == "" {
+		return 0
+	}
+	dupes := w.Owned[item]
+	w.Owned[item] = dupes + 1
+	amounts := w.Refunds[rarity]
+	if dupes == 0 || len(amounts) == 0 {
+		return 0
+	}
+	idx := dupes - 1
+	if idx >= len(amounts) {
+		idx = len(amounts) - 1 // repeat the last value
+	}
+	w.Balance += amounts[idx]
+	return amounts[idx]
+}
+
+// CanBuyPull reports whether Balance covers one draw.
+func (w *Wallet) CanBuyPull() bool {
+	return w != nil && w.PerDraw > 0 && w.Balance >= w.PerDraw
+}
+
+// BuyPull spends PerDraw for one draw if Balance covers it.
+func (w *Wallet) BuyPull() bool {
+	if !w.CanBuyPull() {
+		return false
+	}
+	w.Balance -= w.PerDraw
+	return true
+}
@@ -11,15 +11,28 @@ const (
 	EaseInOutCubic    Easing = "easeInOutCubic"
 )
 
+// SoftMode selects the shape of the soft pity curve.
+type SoftMode string
+
+const (
+	// Interpolate base p -> TargetProb across [StartAt .. Pity-1] using Easing.
+	SoftTargetRamp SoftMode = "target_ramp"
+	// Add Increment to base p for every draw from StartAt on (e.g., +2% per draw after 50).
+	SoftPerDrawIncrement SoftMode = "per_draw_increment"
+)
+
 var ErrSoftPityConfig = errors.New("invalid soft pity config")
 
 // SoftPityConfig defines the ramp behavior before the hard pity.
 // Example: Pity=90, StartAt=74, Target=0.5 → from draw #74 up to #89, p ramps to 0.5
+// Example: Mode=per_draw_increment, Pity=99, StartAt=50, Increment=0.02 → p = base + 2% * (Count-49)
 type SoftPityConfig struct {
-	Pity       int     // hard pity threshold (same as PitySystem.Pity)
-	StartAt    int     // start draw index (since last hit) to begin ramp, e.g., 74
-	TargetProb float64 // probability at draw (Pity-1), must be in (0,1)
-	Easing     Easing  // easing function
+	Mode       SoftMode // curve shape; empty means SoftTargetRamp
+	Pity       int      // hard pity threshold (same as PitySystem.Pity)
+	StartAt    int      // start draw index (since last hit) to begin ramp, e.g., 74
+	TargetProb float64  // target_ramp: probability at draw (Pity-1), must be in (0,1)
+	Easing     Easing   // target_ramp: easing function
+	Increment  float64  // per_draw_increment: probability added per draw from StartAt, must be in (0,1]
 }
 
 // normalize validates and adjusts StartAt; returns error if invalid.
@@ -27,12 +40,28 @@ func (c *SoftPityConfig) normalize() error {
 	if c.Pity <= 1 {
 		return ErrSoftPityConfig
 	}
-	if c.TargetProb <= 0 || c.TargetProb >= 1 {
-		return ErrSoftPityConfig
-	}
 	if c.StartAt < 0 {
 		c.StartAt = 0
 	}
+	if c.Mode == "" {
+		c.Mode = SoftTargetRamp
+	}
+	if c.Mode == SoftPerDrawIncrement {
+		// increments start at StartAt and may run right up to the hard pity draw.
+		if c.Increment <= 0 || c.Increment > 1 {
+			return ErrSoftPityConfig
+		}
+		if c.StartAt >= c.Pity {
+			return ErrSoftPityConfig
+		}
+		return nil
+	}
+	if c.Mode != SoftTargetRamp {
+		return ErrSoftPityConfig
+	}
+	if c.TargetProb <= 0 || c.TargetProb >= 1 {
+		return ErrSoftPityConfig
+	}
 	// Ramp ends at (Pity-1). StartAt must be < (Pity-1) to have room to ramp.
 	if c.StartAt >= c.Pity-1 {
 		return ErrSoftPityConfig
@@ -65,9 +94,13 @@ func NewSoftPitySystem(pity int, soft *SoftPityConfig, rng RandomSource) (*SoftP
 	return &SoftPitySystem{PitySystem: base, Soft: soft}, nil
 }
 
+// EffectiveProb is the probability that the next draw hits at the current Count.
+func (s *SoftPitySystem) EffectiveProb(pBase float64) float64 { return s.effectiveProb(pBase) }
+
 // effectiveProb computes the actual probability this draw should use:
 // - If Count+1 >= Pity: return 1 (hard pity).
-// - Else if soft ramp is configured and Count >= StartAt: ramp p toward TargetProb at (Pity-1).
+// - Else if soft ramp is configured and Count >= StartAt: ramp p toward TargetProb at (Pity-1),
+//   or add Increment for every draw since StartAt in per_draw_increment mode.
 // - Else: return base p.
 func (s *SoftPitySystem) effectiveProb(pBase float64) float64 {
 	// hard pity
@@ -82,6 +115,10 @@ func (s *SoftPitySystem) effectiveProb(pBase float64) float64 {
 	if s.Count < s.Soft.StartAt {
 		return pBase
 	}
+	if s.Soft.Mode == SoftPerDrawIncrement {
+		// Count == StartAt is the first boosted draw: base + 1*Increment
+		return clampSoft(pBase + s.Soft.Increment*float64(s.Count-s.Soft.StartAt+1))
+	}
 	end := s.Pity - 1
 	// progress t in [0,1], inclusive at end (Count == end)
 	// Example: StartAt=74, end=89 → length = 16 draws (74..89)
@@ -112,8 +149,11 @@ func (s *SoftPitySystem) effectiveProb(pBase float64) float64 {
 		// linear
 	}
 	// interpolate from base to target
-	p := pBase + (s.Soft.TargetProb-pBase)*t
-	// clamp to [0,1)
+	return clampSoft(pBase + (s.Soft.TargetProb-pBase)*t)
+}
+
+// clampSoft keeps a soft pity probability in [0,1).
+func clampSoft(p float64) float64 {
 	if p < 0 {
 		p = 0
 	}
@@ -0,0 +1,38 @@
+package gacha
+
+import "errors"
+
+var ErrSparkConfig = errors.New("invalid spark config")
+
+// Spark is an exchange-point counter ("spark"): every draw earns PerDraw points
+// and Threshold points buy the featured item outright, independent of pity.
+// A nil *Spark is valid and never earns or redeems.
+type Spark struct {
+	Threshold int // points per exchange, >= 1
+	PerDraw   int // points earned per draw, >= 1
+	Points    int
+}
+
+// NewSpark creates a spark counter holding points.
+func NewSpark(threshold, perDraw, points int) (*Spark, error) {
+	if threshold < 1 || perDraw < 1 || points < 0 {
+		return nil, ErrSparkConfig
+	}
+	return &Spark{Threshold: threshold, PerDraw: perDraw, Points: points}, nil
+}
+
+// Earn credits one draw.
+func (s *Spark) Earn() {
+	if s != nil {
+		s.Points += s.PerDraw
+	}
+}
+
+// Redeem spends Threshold points if available and reports whether it did.
+func (s *Spark) Redeem() bool {
+	if s == nil || s.Points < s.Threshold {
+		return false
+	}
+	s.Points -= s.Threshold
+	return true
+}
@@ -0,0 +1,322 @@
+package gacha
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrTierConfig  = errors.New("invalid tier config")
+	ErrSelection   = errors.New("item is not a selectable featured item")
+	ErrObservation = errors.New("observed outcome is impossible under the pool's rules")
+)
+
+// Tier is one rarity level of a TieredSystem with its own pity counter.
+type Tier struct {
+	Rarity int             // e.g., 5 for 5★
+	PBase  float64         // base probability of this rarity far from pity
+	Soft   *SoftPitySystem // own soft/hard pity counter
+	Banner *BannerSystem   // optional UP/off layer; must wrap Soft. nil => no featured items
+	Path   *PathSystem     // optional epitomized path; must wrap Banner
+	Items  *ItemPool       // optional roster; nil => outcomes carry no Item
+}
+
+// TierOutcome reports one draw's result across all tiers.
+type TierOutcome struct {
+	Rarity   int    // rarity obtained this draw; BaseRarity when no tier hit
+	Hit      bool   // true if any tier hit (Rarity > BaseRarity)
+	IsUp     bool   // featured item of that rarity (only for tiers with a Banner)
+	OnTarget bool   // charted item of an epitomized path (only for tiers with a Path)
+	Item     string // item id picked from the rarity's roster; "" if none configured
+}
+
+// TieredSystem draws several rarities on one pull, e.g. 5★ / 4★ over a 3★ floor.
+// - Tiers are evaluated in priority order (highest Rarity first).
+// - One uniform u is compared against cumulative effective probabilities,
+// so P(5★) = p5 and P(4★) = p4 as configured (no conditional shrinking).
+// - A tier at hard pity has probability 1 and takes all the mass higher tiers
+// leave: a 5★ rolled by probability pre-empts a 4★ at hard pity, so lower
+// tiers never change the odds of higher ones.
+// - The winning tier resets its Count and runs its banner logic; every other tier counts a miss.
+// A pre-empted tier at hard pity keeps Count >= Pity-1, so its guarantee moves to the next draw.
+type TieredSystem struct {
+	Tiers      []*Tier
+	BaseRarity int
+	BaseItems  *ItemPool // optional roster of the base rarity
+	RNG        RandomSource
+	MultiFloor int             // DrawMulti guarantees one item of this rarity or better; 0 => no guarantee
+	Trace      func(PullTrace) // optional; called after every successful pull
+}
+
+// NewTieredSystem sorts tiers by priority and validates them.
+// Rarities must be unique and above baseRarity.
+func NewTieredSystem(tiers []*Tier, baseRarity int, rng RandomSource) (*TieredSystem, error) {
+	if len(tiers) == 0 {
+		return nil, ErrTierConfig
+	}
+	if rng == nil {
+		rng = DefaultRNG()
+	}
+	sorted := append([]*Tier(nil), tiers...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Rarity > sorted[j].Rarity })
+	for i, t := range sorted {
+		if t == nil || t.Soft == nil || t.Rarity <= baseRarity {
+			return nil, ErrTierConfig
+		}
+		if i > 0 && sorted[i-1].Rarity == t.Rarity {
+			return nil, ErrTierConfig
+		}
+		if t.Banner != nil && t.Banner.SoftPity != t.Soft {
+			return nil, ErrTierConfig
+		}
+		if t.Path != nil && (t.Banner == nil || t.Path.Banner != t.Banner) {
+			return nil, ErrTierConfig
+		}
+		if err := validateProb(t.PBase); err != nil {
+			return nil, err
+		}
+	}
+	return &TieredSystem{Tiers: sorted, BaseRarity: baseRarity, RNG: rng}, nil
+}
+
+// Tier returns the tier with the given rarity, or nil.
+func (ts *TieredSystem) Tier(rarity int) *Tier {
+	for _, t := range ts.Tiers {
+		if t.Rarity == rarity {
+			return t
+		}
+	}
+	return nil
+}
+
+// Select makes the featured item id the only UP of the given rarity
+// (choose-your-UP banners). The choices are the tier's featured roster; with an
+// epitomized path the path is charted to id instead and the roster is kept.
+func (ts *TieredSystem) Select(rarity int, id string) error {
+	t := ts.Tier(rarity)
+	if t == nil {
+		return ErrTierConfig
+	}
+	if t.Items == nil {
+		return ErrSelection
+	}
+	for i, it := range t.Items.Featured {
+		if it.ID != id {
+			continue
+		}
+		if t.Path != nil {
+			if i >= t.Path.Featured {
+				return ErrSelection
+			}
+			t.Path.Target = i
+		} else {
+			t.Items = &ItemPool{Featured: []WeightedItem{it}, Standard: t.Items.Standard}
+		}
+		return nil
+	}
+	return ErrSelection
+}
+
+// Draw performs one pull across all tiers.
+func (ts *TieredSystem) Draw() (TierOutcome, error) {
+	return ts.draw(0)
+}
+
+// DrawMulti performs n pulls as one multi-pull. With MultiFloor set, the last
+// pull is upgraded to MultiFloor when none of the earlier ones reached it;
+// higher tiers keep their odds on that pull and the floor tier takes the rest.
+func (ts *TieredSystem) DrawMulti(n int) ([]TierOutcome, error) {
+	if ts.MultiFloor > 0 && ts.Tier(ts.MultiFloor) == nil {
+		return nil, ErrTierConfig
+	}
+	outs := make([]TierOutcome, 0, n)
+	met := ts.MultiFloor <= 0
+	for i := 0; i < n; i++ {
+		floor := 0
+		if i == n-1 && !met {
+			floor = ts.MultiFloor
+		}
+		out, err := ts.draw(floor)
+		if err != nil {
+			return nil, err
+		}
+		if out.Rarity >= ts.MultiFloor {
+			met = true
+		}
+		outs = append(outs, out)
+	}
+	return outs, nil
+}
+
+// Observe advances the state the way a pull with the given result would,
+// without drawing: for rebuilding state from a pull history. Rarity, IsUp and
+// OnTarget are used. A result the rules rule out (a miss at hard pity, an
+// off-banner hit under a guarantee) is still applied as observed and
+// reported with ErrObservation.
+func (ts *TieredSystem) Observe(out TierOutcome) error {
+	winner := -1
+	for i, t := range ts.Tiers {
+		if t.Rarity == out.Rarity {
+			winner = i
+		}
+	}
+	if winner < 0 && out.Rarity != ts.BaseRarity {
+		return ErrTierConfig
+	}
+	var err error
+	for _, t := range ts.Tiers {
+		// the first tier at hard pity rules out every lower result
+		if t.Soft.Count+1 >= t.Soft.Pity {
+			if t.Rarity > out.Rarity {
+				err = ErrObservation
+			}
+			break
+		}
+	}
+	for i, t := range ts.Tiers {
+		if i == winner {
+			t.Soft.Count = 0
+		} else {
+			t.Soft.Count++
+		}
+	}
+	if winner < 0 {
+		return err
+	}
+	t := ts.Tiers[winner]
+	if t.Path != nil {
+		if perr := t.Path.settle(out.IsUp, out.OnTarget); perr != nil {
+			err = perr
+		}
+	} else if t.Banner != nil {
+		if berr := t.Banner.settle(!out.IsUp); berr != nil {
+			err = berr
+		}
+	}
+	return err
+}
+
+// DrawAtLeast performs one pull whose rarity is at least floor, as the
+// guaranteed pull of a multi-pull does; floor 0 is a plain Draw.
+func (ts *TieredSystem) DrawAtLeast(floor int) (TierOutcome, error) {
+	if floor > 0 && ts.Tier(floor) == nil {
+		return TierOutcome{}, ErrTierConfig
+	}
+	return ts.draw(floor)
+}
+
+// DrawBatch performs n pulls as consecutive multi-pulls of batch pulls each
+// (batch 1 => single pulls); a short last multi takes the remainder.
+func (ts *TieredSystem) DrawBatch(n, batch int) ([]TierOutcome, error) {
+	batch = max(batch, 1)
+	outs := make([]TierOutcome, 0, n)
+	for len(outs) < n {
+		multi, err := ts.DrawMulti(min(batch, n-len(outs)))
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, multi...)
+	}
+	return outs, nil
+}
+
+// draw performs one pull whose rarity is at least floor (0 => unrestricted).
+// floor must be the rarity of a tier. Tiers below floor can neither win by
+// probability nor by hard pity; the floor tier absorbs their probability.
+func (ts *TieredSystem) draw(floor int) (TierOutcome, error) {
+	if ts.Trace == nil {
+		return ts.pull(floor)
+	}
+	tr := PullTrace{Floor: floor, Pre: ts.Snapshot()}
+	tr.Probs, tr.OffProbs = ts.probs(floor)
+	out, err := ts.pull(floor)
+	if err != nil {
+		return TierOutcome{}, err
+	}
+	tr.Post, tr.Outcome = ts.Snapshot(), out
+	ts.Trace(tr)
+	return out, nil
+}
+
+// pull is draw without tracing.
+func (ts *TieredSystem) pull(floor int) (TierOutcome, error) {
+	probs, err := ts.winProbs(floor)
+	if err != nil {
+		return TierOutcome{}, err
+	}
+	winner := -1
+	u := ts.RNG.Float64()
+	acc := 0.0
+	for i, p := range probs {
+		acc += p
+		if u < acc {
+			winner = i
+			break
+		}
+	}
+	if winner < 0 && floor > 0 {
+		for i, t := range ts.Tiers {
+			if t.Rarity == floor {
+				winner = i
+			}
+		}
+	}
+
+	for i, t := range ts.Tiers {
+		if i == winner {
+			t.Soft.Count = 0
+		} else {
+			t.Soft.Count++
+		}
+	}
+	if winner < 0 {
+		return TierOutcome{Rarity: ts.BaseRarity, Item: ts.BaseItems.Pick(false, ts.RNG)}, nil
+	}
+
+	t := ts.Tiers[winner]
+	out := TierOutcome{Rarity: t.Rarity, Hit: true}
+	if t.Path != nil {
+		po, err := t.Path.resolveHit()
+		if err != nil {
+			return TierOutcome{}, err
+		}
+		out.IsUp, out.OnTarget = po.IsUp, po.OnTarget
+		if po.Featured >= 0 && t.Items != nil && po.Featured < len(t.Items.Featured) {
+			// the path picked which featured item; the roster only names it
+			out.Item = t.Items.Featured[po.Featured].ID
+			return out, nil
+		}
+	} else if t.Banner != nil {
+		bo, err := t.Banner.resolveHit()
+		if err != nil {
+			return TierOutcome{}, err
+		}
+		out.IsUp = bo.IsUp
+	}
+	out.Item = t.Items.Pick(out.IsUp, ts.RNG)
+	return out, nil
+}
+
+// winProbs returns the probability that each tier wins the next pull with
+// floor applied (see TieredSystem): each tier takes its effective probability
+// out of the mass the tiers above it leave. Tiers below floor get 0 and the
+// floor tier takes whatever is left.
+func (ts *TieredSystem) winProbs(floor int) ([]float64, error) {
+	probs := make([]float64, len(ts.Tiers))
+	left := 1.0
+	for i, t := range ts.Tiers {
+		if t.Rarity < floor {
+			break
+		}
+		p := t.Soft.effectiveProb(t.PBase)
+		if err := validateProb(p); err != nil {
+			return nil, err
+		}
+		if t.Rarity == floor {
+			p = 1
+		}
+		probs[i] = max(min(p, left), 0)
+		left -= probs[i]
+	}
+	return probs, nil
+}
@@ -0,0 +1,131 @@
+package gacha
+
+// TierSnapshot is the pity state of one tier at a point in time.
+type TierSnapshot struct {
+	Rarity         int  `json:"rarity"`
+	Count          int  `json:"count"`
+	OffStreak      int  `json:"off_streak,omitempty"`
+	GuaranteedNext bool `json:"guaranteed_next,omitempty"`
+	FatePoints     int  `json:"fate_points,omitempty"`
+	Radiance       int  `json:"radiance,omitempty"`
+}
+
+// PullTrace describes one pull of a TieredSystem, see TieredSystem.Trace.
+type PullTrace struct {
+	Floor    int            // multi-pull floor applied to this pull; 0 => none
+	Pre      []TierSnapshot // every tier before the pull, priority order
+	Probs    []float64      // probability of each tier winning this pull (see TieredSystem.Probs)
+	OffProbs []float64      // probability of each tier's hit going off-banner; 0 if forced UP or no banner
+	Post     []TierSnapshot // every tier after the pull
+	Outcome  TierOutcome
+}
+
+// Snapshot returns the state of every tier, priority order.
+func (ts *TieredSystem) Snapshot() []TierSnapshot {
+	out := make([]TierSnapshot, len(ts.Tiers))
+	for i, t := range ts.Tiers {
+		s := TierSnapshot{Rarity: t.Rarity, Count: t.Soft.Count}
+		if b := t.Banner; b != nil {
+			s.OffStreak = b.OffStreak
+			s.GuaranteedNext = b.GuaranteedNext
+			if rp, ok := b.Policy.(*RadiancePolicy); ok {
+				s.Radiance = rp.Counter
+			}
+		}
+		if t.Path != nil {
+			s.FatePoints = t.Path.FatePoints
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// Restore sets tier state from snapshots; tiers without one are left alone.
+func (ts *TieredSystem) Restore(snaps []TierSnapshot) {
+	for _, s := range snaps {
+		t := ts.Tier(s.Rarity)
+		if t == nil {
+			continue
+		}
+		t.Soft.Count = s.Count
+		if b := t.Banner; b != nil {
+			b.OffStreak = s.OffStreak
+			b.GuaranteedNext = s.GuaranteedNext
+			if rp, ok := b.Policy.(*RadiancePolicy); ok {
+				rp.Counter = s.Radiance
+			}
+		}
+		if t.Path != nil {
+			t.Path.FatePoints = min(s.FatePoints, t.Path.MaxFate)
+		}
+	}
+}
+
+// Probs returns the probability that each tier wins the next pull and the
+// off-banner probability of its hit, priority order (see PullTrace).
+func (ts *TieredSystem) Probs() (hit, off []float64) { return ts.probs(0) }
+
+// probs is Probs for a pull with floor applied. Invalid odds on any tier
+// report every hit probability as 0.
+func (ts *TieredSystem) probs(floor int) (hit, off []float64) {
+	hit, err := ts.winProbs(floor)
+	if err != nil {
+		hit = make([]float64, len(ts.Tiers))
+	}
+	off = make([]float64, len(ts.Tiers))
+	for i, t := range ts.Tiers {
+		fated := t.Path != nil && t.Path.Target >= 0 && t.Path.FatePoints >= t.Path.MaxFate
+		if b := t.Banner; b != nil && !b.GuaranteedNext && !fated {
+			off[i] = b.currentOffProb()
+		}
+	}
+	return hit, off
+}
+
+// RecordingRNG passes the values of Src through and remembers them.
+type RecordingRNG struct {
+	Src    RandomSource
+	values []float64
+}
+
+// NewRecordingRNG wraps src; nil => DefaultRNG().
+func NewRecordingRNG(src RandomSource) *RecordingRNG {
+	if src == nil {
+		src = DefaultRNG()
+	}
+	return &RecordingRNG{Src: src}
+}
+
+func (r *RecordingRNG) Float64() float64 {
+	v := r.Src.Float64()
+	r.values = append(r.values, v)
+	return v
+}
+
+// Take returns the values drawn since the last Take.
+func (r *RecordingRNG) Take() []float64 {
+	v := r.values
+	r.values = nil
+	return v
+}
+
+// ReplayRNG hands out recorded values in order. Past the end it returns 1
+// (never a hit) and counts the overrun.
+type ReplayRNG struct {
+	Values  []float64
+	pos     int
+	Overrun int
+}
+
+func (r *ReplayRNG) Float64() float64 {
+	if r.pos >= len(r.Values) {
+		r.Overrun++
+		return 1
+	}
+	v := r.Values[r.pos]
+	r.pos++
+	return v
+}
+
+// Remaining reports how many recorded values were not consumed.
+func (r *ReplayRNG) Remaining() int { return len(r.Values) - r.pos }
@@ -1,15 +1,22 @@
 package game
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ErrInvalidID means a game or pool id is not a plain file name.
+var ErrInvalidID = errors.New("invalid config id")
+
 // Paths helper for default/game/pool files.
 type Paths struct {
 	BaseDir string // base directory, e.g., /opt/app/config
@@ -25,6 +32,56 @@ func (p Paths) PoolPath(game, pool string) string {
 	return filepath.Join(p.BaseDir, "games", game, "pools", pool+".yaml")
 }
 
+// Games lists game ids discovered as games/<game>.yaml or games/<game>/pools/, sorted.
+// default.yaml is not a game.
+func (p Paths) Games() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(p.BaseDir, "games"))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case e.IsDir():
+			if fi, err := os.Stat(filepath.Join(p.BaseDir, "games", name, "pools")); err == nil && fi.IsDir() {
+				seen[name] = true
+			}
+		case strings.HasSuffix(name, ".yaml") && name != "default.yaml":
+			seen[strings.TrimSuffix(name, ".yaml")] = true
+		}
+	}
+	games := make([]string, 0, len(seen))
+	for g := range seen {
+		games = append(games, g)
+	}
+	sort.Strings(games)
+	return games, nil
+}
+
+// Pools lists pool ids found as games/<game>/pools/<pool>.yaml, sorted.
+// A game without a pools directory has no pools.
+func (p Paths) Pools(game string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(p.BaseDir, "games", game, "pools", "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	pools := make([]string, 0, len(matches))
+	for _, m := range matches {
+		pools = append(pools, strings.TrimSuffix(filepath.Base(m), ".yaml"))
+	}
+	sort.Strings(pools)
+	return pools, nil
+}
+
+// checkID rejects ids that would name a file outside their directory.
+func checkID(id string) error {
+	if id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("%q: %w", id, ErrInvalidID)
+	}
+	return nil
+}
+
 // Loader reads YAML configs and merges default → game → pool.
 type Loader struct {
 	paths Paths
@@ -41,6 +98,11 @@ func NewLoader(baseDir string) *Loader {
 	}
 }
 
+// Paths returns the file layout this loader reads from.
+func (l *Loader) Paths() Paths {
+	return l.paths
+}
+
 // LoadMerged loads and merges default → game → pool (pool optional).
 // It returns the merged RawConfig (without normalization).
 func (l *Loader) LoadMerged(game, pool string) (RawConfig, error) {
@@ -57,15 +119,27 @@ func (l *Loader) LoadMerged(game, pool string) (RawConfig, error) {
 	}
 	l.mu.RUnlock()
 
+	for _, id := range []string{game, pool} {
+		if err := checkID(id); err != nil {
+			return RawConfig{}, err
+		}
+	}
+
 	// Read files from disk
 	defCfg, err := readYAML(l.paths.DefaultPath())
 	if err != nil {
 		return RawConfig{}, fmt.Errorf("read default: %w", err)
 	}
-	gameCfg, _ := readYAML(l.paths.GamePath(game)) // game file may not exist
+	gameCfg, err := readYAML(l.paths.GamePath(game)) // game file may not exist
+	if err != nil {
+		return RawConfig{}, fmt.Errorf("read game %s: %w", game, err)
+	}
 	var poolCfg RawConfig
 	if pool != "" {
-		poolCfg, _ = readYAML(l.paths.PoolPath(game, pool)) // pool file optional
+		poolCfg, err = readYAML(l.paths.PoolPath(game, pool)) // pool file optional
+		if err != nil {
+			return RawConfig{}, fmt.Errorf("read pool %s/%s: %w", game, pool, err)
+		}
 	}
 
 	// Merge: default <- game <- pool
@@ -110,6 +184,21 @@ func readYAML(path string) (RawConfig, error) {
 	return cfg, nil
 }
 
+// ParseRaw strictly decodes a candidate YAML config: unknown keys are rejected
+// so typos don't silently fall back to defaults.
+func ParseRaw(text []byte) (RawConfig, error) {
+	var cfg RawConfig
+	dec := yaml.NewDecoder(bytes.NewReader(text))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return RawConfig{}, errors.New("empty config")
+		}
+		return RawConfig{}, err
+	}
+	return cfg, nil
+}
+
 // mergeRaw performs a deep merge: 'b' overrides 'a' where non-zero/non-nil.
 // For slices (e.g., OffProbs), 'b' replaces 'a' if provided.
 func mergeRaw(a, b RawConfig) RawConfig {
@@ -122,33 +211,47 @@ func mergeRaw(a, b RawConfig) RawConfig {
 	if b.Notes != "" {
 		out.Notes = b.Notes
 	}
+	if b.DisplayName != "" {
+		out.DisplayName = b.DisplayName
+	}
 
 	// draw
-	if out.Draw.PBase == nil && b.Draw.PBase != nil {
+	if b.Draw.PBase != nil {
 		out.Draw.PBase = b.Draw.PBase
 	}
-	if out.Draw.Pity == nil && b.Draw.Pity != nil {
+	if b.Draw.Pity != nil {
 		out.Draw.Pity = b.Draw.Pity
 	}
+	if b.Draw.Rarity != 0 {
+		out.Draw.Rarity = b.Draw.Rarity
+	}
+	if b.Draw.BaseRarity != 0 {
+		out.Draw.BaseRarity = b.Draw.BaseRarity
+	}
 	// soft
 	switch {
-	case out.Draw.Soft == nil && b.Draw.Soft != nil:
+	case b.Draw.Soft != nil && (out.Draw.Soft == nil || b.Draw.Soft.Mode != ""):
+		// a layer that sets the mode brings the whole curve; fields of the
+		// parent's mode would not fit it
 		softCopy := *b.Draw.Soft
 		out.Draw.Soft = &softCopy
 	case out.Draw.Soft != nil && b.Draw.Soft != nil:
-		if b.Draw.Soft.Mode != "" {
-			out.Draw.Soft.Mode = b.Draw.Soft.Mode
-		}
-		if out.Draw.Soft.StartAt == nil && b.Draw.Soft.StartAt != nil {
+		softCopy := *out.Draw.Soft // don't write through to the cached parent
+		out.Draw.Soft = &softCopy
+		if b.Draw.Soft.StartAt != nil {
 			out.Draw.Soft.StartAt = b.Draw.Soft.StartAt
 		}
-		if out.Draw.Soft.StartPct == nil && b.Draw.Soft.StartPct != nil {
+		if b.Draw.Soft.StartPct != nil {
 			out.Draw.Soft.StartPct = b.Draw.Soft.StartPct
+			// a percentage wins over a start_at inherited from the parent
+			if b.Draw.Soft.StartAt == nil {
+				out.Draw.Soft.StartAt = nil
+			}
 		}
-		if out.Draw.Soft.Target == nil && b.Draw.Soft.Target != nil {
+		if b.Draw.Soft.Target != nil {
 			out.Draw.Soft.Target = b.Draw.Soft.Target
 		}
-		if out.Draw.Soft.Increment == nil && b.Draw.Soft.Increment != nil {
+		if b.Draw.Soft.Increment != nil {
 			out.Draw.Soft.Increment = b.Draw.Soft.Increment
 		}
 		if b.Draw.Soft.Easing != "" {
@@ -162,25 +265,124 @@ func mergeRaw(a, b RawConfig) RawConfig {
 		c := *b.Banner
 		out.Banner = &c
 	case out.Banner != nil && b.Banner != nil:
+		c := *out.Banner
+		out.Banner = &c
 		if len(b.Banner.OffProbs) > 0 {
 			out.Banner.OffProbs = append([]float64(nil), b.Banner.OffProbs...)
 		}
 		if b.Banner.MaxOff != 0 {
 			out.Banner.MaxOff = b.Banner.MaxOff
 		}
+		if b.Banner.Path != nil {
+			out.Banner.Path = b.Banner.Path
+		}
+		if b.Banner.OffPolicy != nil {
+			out.Banner.OffPolicy = b.Banner.OffPolicy
+		}
 		// special windows left as-is; extend if you add them to schema
 	}
 
+	// tiers: replaced as a whole, like off_probs
+	if len(b.Tiers) > 0 {
+		out.Tiers = append([]TierConfig(nil), b.Tiers...)
+	}
+
+	// items: per-rarity rosters replace the parent's roster of that rarity
+	if len(b.Items) > 0 {
+		items := make(map[int]RosterConfig, len(out.Items)+len(b.Items))
+		for r, roster := range out.Items {
+			items[r] = roster
+		}
+		for r, roster := range b.Items {
+			items[r] = roster
+		}
+		out.Items = items
+	}
+
+	// pity groups
+	if b.PityGroup != "" {
+		out.PityGroup = b.PityGroup
+	}
+	if len(b.PityGroups) > 0 {
+		groups := make(map[string]PityGroupConfig, len(out.PityGroups)+len(b.PityGroups))
+		for name, g := range out.PityGroups {
+			groups[name] = g
+		}
+		for name, g := range b.PityGroups {
+			groups[name] = g
+		}
+		out.PityGroups = groups
+	}
+
+	// spark
+	switch {
+	case out.Spark == nil && b.Spark != nil:
+		c := *b.Spark
+		out.Spark = &c
+	case out.Spark != nil && b.Spark != nil:
+		c := *out.Spark
+		out.Spark = &c
+		if b.Spark.Threshold != nil {
+			out.Spark.Threshold = b.Spark.Threshold
+		}
+		if b.Spark.PerDraw != nil {
+			out.Spark.PerDraw = b.Spark.PerDraw
+		}
+		if b.Spark.Carry != nil {
+			out.Spark.Carry = b.Spark.Carry
+		}
+	}
+
+	// refunds: per-rarity amounts replace the parent's list of that rarity
+	switch {
+	case out.Refunds == nil && b.Refunds != nil:
+		c := *b.Refunds
+		out.Refunds = &c
+	case out.Refunds != nil && b.Refunds != nil:
+		c := *out.Refunds
+		out.Refunds = &c
+		if b.Refunds.Currency != "" {
+			out.Refunds.Currency = b.Refunds.Currency
+		}
+		if b.Refunds.PerDraw != nil {
+			out.Refunds.PerDraw = b.Refunds.PerDraw
+		}
+		if len(b.Refunds.Amounts) > 0 {
+			amounts := make(map[int][]int, len(c.Amounts)+len(b.Refunds.Amounts))
+			for r, a := range c.Amounts {
+				amounts[r] = a
+			}
+			for r, a := range b.Refunds.Amounts {
+				amounts[r] = a
+			}
+			out.Refunds.Amounts = amounts
+		}
+	}
+
+	// selector: replaced as a whole
+	if b.Selector != nil {
+		c := *b.Selector
+		out.Selector = &c
+	}
+
+	// multi: replaced as a whole
+	if b.Multi != nil {
+		c := *b.Multi
+		out.Multi = &c
+	}
+
 	// tokens
 	switch {
 	case out.Tokens == nil && b.Tokens != nil:
 		c := *b.Tokens
 		out.Tokens = &c
 	case out.Tokens != nil && b.Tokens != nil:
-		if out.Tokens.PerDraw == nil && b.Tokens.PerDraw != nil {
+		c := *out.Tokens
+		out.Tokens = &c
+		if b.Tokens.PerDraw != nil {
 			out.Tokens.PerDraw = b.Tokens.PerDraw
 		}
-		if out.Tokens.PerTenDraw == nil && b.Tokens.PerTenDraw != nil {
+		if b.Tokens.PerTenDraw != nil {
 			out.Tokens.PerTenDraw = b.Tokens.PerTenDraw
 		}
 	}
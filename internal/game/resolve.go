@@ -1,21 +1,375 @@
 // resolve.go
 package game
 
+import (
+	"math"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
 // Resolve merges default → game → pool → overrides into engine params.
 // 'overrides' carries query overrides like cushion/p_base/etc.
 type Overrides struct {
-	PBase     *float64
-	StartAt   *int
-	StartPct  *float64
-	Target    *float64
-	Increment *float64
-	Easing    *string
-	OffProbs  *[]float64
-	MaxOff    *int
-	Cushion   *int
+	PBase       *float64
+	Pity        *int
+	SoftMode    *string
+	StartAt     *int
+	StartPct    *float64
+	Target      *float64
+	Increment   *float64
+	Easing      *string
+	OffProbs    *[]float64
+	MaxOff      *int
+	Cushion     *int
+	SparkPoints *int // spark points already held
 }
 
 type Resolver interface {
 	// Returns merged RawConfig and normalized EngineParams
 	Resolve(game, pool string, o Overrides) (RawConfig, EngineParams, error)
 }
+
+// LoaderResolver resolves configs read through a Loader.
+type LoaderResolver struct {
+	loader *Loader
+}
+
+// NewResolver creates a Resolver backed by the given loader.
+func NewResolver(l *Loader) *LoaderResolver {
+	return &LoaderResolver{loader: l}
+}
+
+// Resolve loads default → game → pool, applies overrides, fills defaults and validates.
+// The returned RawConfig includes the overrides and resolved defaults.
+func (r *LoaderResolver) Resolve(game, pool string, o Overrides) (RawConfig, EngineParams, error) {
+	merged, err := r.loader.LoadMerged(game, pool)
+	if err != nil {
+		return RawConfig{}, EngineParams{}, err
+	}
+	cfg := applyOverrides(merged, o)
+	normalizeRaw(&cfg)
+
+	if err := ValidateRaw(cfg); err != nil {
+		return RawConfig{}, EngineParams{}, err
+	}
+	if cfg.Draw.PBase == nil {
+		return RawConfig{}, EngineParams{}, &ValidationError{Errors: []string{"draw.p_base is required"}}
+	}
+	if cfg.Draw.Pity == nil {
+		return RawConfig{}, EngineParams{}, &ValidationError{Errors: []string{"draw.pity is required"}}
+	}
+
+	rarity := cfg.Draw.Rarity
+	if rarity == 0 {
+		rarity = 5
+	}
+	ep := tierParams(*cfg.Draw.PBase, *cfg.Draw.Pity, cfg.Draw.Soft, cfg.Banner, rosterOf(cfg.Items, rarity))
+	ep.Version = cfg.Version
+	ep.Rarity = rarity
+	ep.BaseRarity = cfg.Draw.BaseRarity
+	if ep.BaseRarity == 0 {
+		ep.BaseRarity = 3
+	}
+	ep.PityGroup = cfg.PityGroup
+	ep.CarryGuarantee, ep.CarryOffStreak = true, true
+	if g, ok := cfg.PityGroups[cfg.PityGroup]; ok {
+		if g.CarryGuarantee != nil {
+			ep.CarryGuarantee = *g.CarryGuarantee
+		}
+		if g.CarryOffStreak != nil {
+			ep.CarryOffStreak = *g.CarryOffStreak
+		}
+	}
+	ep.Items = rosterOf(cfg.Items, ep.Rarity)
+	ep.BaseItems = rosterOf(cfg.Items, ep.BaseRarity)
+	for _, t := range cfg.Tiers {
+		// ValidateRaw guarantees p_base/pity on every tier
+		tp := tierParams(*t.PBase, *t.Pity, t.Soft, t.Banner, rosterOf(cfg.Items, t.Rarity))
+		tp.Rarity = t.Rarity
+		tp.Items = rosterOf(cfg.Items, t.Rarity)
+		ep.Tiers = append(ep.Tiers, tp)
+	}
+	if sp := cfg.Spark; sp != nil && *sp.Threshold > 0 {
+		ep.Spark = &SparkParams{Threshold: *sp.Threshold, PerDraw: 1}
+		if sp.PerDraw != nil {
+			ep.Spark.PerDraw = *sp.PerDraw
+		}
+		if sp.Carry != nil {
+			ep.Spark.Carry = *sp.Carry
+		}
+		if o.SparkPoints != nil {
+			ep.Spark.Points = *o.SparkPoints
+		}
+	}
+	if rf := cfg.Refunds; rf != nil && len(rf.Amounts) > 0 {
+		ep.Refund = &RefundParams{Currency: rf.Currency, Amounts: rf.Amounts}
+		if rf.PerDraw != nil {
+			ep.Refund.PerDraw = *rf.PerDraw
+		}
+	}
+	if sel := cfg.Selector; sel != nil {
+		ep.Selector = &SelectorParams{Rarity: sel.Rarity, Default: sel.Default, KeepPity: true}
+		if ep.Selector.Rarity == 0 {
+			ep.Selector.Rarity = ep.Rarity
+		}
+		for _, it := range cfg.Items[ep.Selector.Rarity].Featured {
+			ep.Selector.Choices = append(ep.Selector.Choices, it.ID)
+		}
+		if sel.KeepPity != nil {
+			ep.Selector.KeepPity = *sel.KeepPity
+		}
+		if sel.KeepFate != nil {
+			ep.Selector.KeepFate = *sel.KeepFate
+		}
+	}
+	if cfg.Multi != nil {
+		m := *cfg.Multi
+		if m.Size == 0 {
+			m.Size = 10
+		}
+		ep.Multi = &m
+	}
+	if o.Cushion != nil {
+		ep.Cushion = *o.Cushion
+	}
+	if ep.Cushion < 0 || ep.Cushion >= ep.Pity {
+		return RawConfig{}, EngineParams{}, &ValidationError{Errors: []string{"cushion must satisfy 0 <= cushion < pity"}}
+	}
+	if o.SparkPoints != nil && *o.SparkPoints < 0 {
+		return RawConfig{}, EngineParams{}, &ValidationError{Errors: []string{"spark points must be >= 0"}}
+	}
+	return cfg, ep, nil
+}
+
+// tierParams builds the engine params of one rarity tier; roster is its item roster, or nil.
+func tierParams(pBase float64, pity int, soft *SoftCfg, banner *BannerConfig, roster *RosterConfig) EngineParams {
+	ep := EngineParams{
+		PBase:    pBase,
+		Pity:     pity,
+		SoftMode: "none",
+	}
+	if s := soft; s != nil && s.Mode != "" && s.Mode != "none" {
+		ep.SoftMode = s.Mode
+		ep.StartAt = s.StartAt
+		ep.StartPct = s.StartPct
+		ep.Target = s.Target
+		ep.Increment = s.Increment
+		ep.Easing = s.Easing
+	}
+	if banner != nil && len(banner.OffProbs) > 0 {
+		ep.OffProbs = append([]float64(nil), banner.OffProbs...)
+		ep.MaxOff = banner.MaxOff
+		if banner.Path != nil {
+			ep.Path = pathParams(*banner.Path, roster)
+		}
+		ep.OffPolicy = string(gacha.OffPolicyStreak)
+		if pol := banner.OffPolicy; pol != nil && pol.Kind != "" {
+			ep.OffPolicy = pol.Kind
+			ep.RadianceThreshold = pol.Threshold
+		}
+	}
+	return ep
+}
+
+// pathParams fills path defaults from the roster: the featured count defaults to
+// the featured roster size (or 2) and the target to the first featured item.
+func pathParams(c PathConfig, roster *RosterConfig) *PathParams {
+	p := &PathParams{Featured: c.Featured, MaxFate: c.MaxFate}
+	if p.Featured == 0 && roster != nil {
+		p.Featured = len(roster.Featured)
+	}
+	if p.Featured == 0 {
+		p.Featured = 2
+	}
+	if p.MaxFate == 0 {
+		p.MaxFate = 1
+	}
+	if c.Target != "" {
+		// ValidateRaw guarantees the target is a featured item
+		p.Target = featuredIndex(roster, c.Target)
+	}
+	return p
+}
+
+// rosterOf returns the roster configured for a rarity, or nil.
+func rosterOf(items map[int]RosterConfig, rarity int) *RosterConfig {
+	roster, ok := items[rarity]
+	if !ok {
+		return nil
+	}
+	return &roster
+}
+
+// applyOverrides copies cfg and layers request overrides on top of it.
+// Nested structs are copied so the loader cache is never mutated.
+func applyOverrides(cfg RawConfig, o Overrides) RawConfig {
+	out := cfg
+	if cfg.Draw.Soft != nil {
+		c := *cfg.Draw.Soft
+		out.Draw.Soft = &c
+	}
+	if cfg.Banner != nil {
+		c := *cfg.Banner
+		out.Banner = &c
+	}
+	out.Tiers = make([]TierConfig, len(cfg.Tiers))
+	for i, t := range cfg.Tiers {
+		if t.Soft != nil {
+			c := *t.Soft
+			t.Soft = &c
+		}
+		if t.Banner != nil {
+			c := *t.Banner
+			t.Banner = &c
+		}
+		out.Tiers[i] = t
+	}
+
+	if o.PBase != nil {
+		out.Draw.PBase = o.PBase
+	}
+	if o.Pity != nil {
+		out.Draw.Pity = o.Pity
+	}
+
+	soft := func() *SoftCfg {
+		if out.Draw.Soft == nil {
+			out.Draw.Soft = &SoftCfg{}
+		}
+		return out.Draw.Soft
+	}
+	if o.SoftMode != nil {
+		soft().Mode = *o.SoftMode
+	}
+	if o.StartAt != nil {
+		soft().StartAt = o.StartAt
+	}
+	if o.StartPct != nil {
+		soft().StartPct = o.StartPct
+		// an explicit percentage wins over a start_at inherited from the files
+		if o.StartAt == nil {
+			out.Draw.Soft.StartAt = nil
+		}
+	}
+	if o.Target != nil {
+		soft().Target = o.Target
+	}
+	if o.Increment != nil {
+		soft().Increment = o.Increment
+	}
+	if o.Easing != nil {
+		soft().Easing = *o.Easing
+	}
+
+	if o.OffProbs != nil {
+		if out.Banner == nil {
+			out.Banner = &BannerConfig{}
+		}
+		out.Banner.OffProbs = append([]float64(nil), (*o.OffProbs)...)
+	}
+	if o.MaxOff != nil {
+		if out.Banner == nil {
+			out.Banner = &BannerConfig{}
+		}
+		out.Banner.MaxOff = *o.MaxOff
+	}
+	return out
+}
+
+// normalizeRaw fills defaults the engine expects, on the top tier and every lower tier:
+// - start_pct is resolved into start_at (ceil(pct * pity), capped at pity-1)
+// - target_ramp easing defaults to linear
+// - banner.max_off defaults to len(off_probs)
+func normalizeRaw(cfg *RawConfig) {
+	normalizeTier(cfg.Draw.Soft, cfg.Draw.Pity, cfg.Banner)
+	for _, t := range cfg.Tiers {
+		normalizeTier(t.Soft, t.Pity, t.Banner)
+	}
+}
+
+func normalizeTier(s *SoftCfg, pity *int, banner *BannerConfig) {
+	if s != nil {
+		if s.StartAt == nil && s.StartPct != nil && pity != nil {
+			pct := math.Min(math.Max(*s.StartPct, 0), 1)
+			startAt := int(math.Ceil(pct * float64(*pity)))
+			if startAt >= *pity {
+				startAt = *pity - 1
+			}
+			s.StartAt = &startAt
+		}
+		if s.Mode == "target_ramp" && s.Easing == "" {
+			s.Easing = string(gacha.EaseLinear)
+		}
+	}
+	if banner != nil && banner.MaxOff == 0 {
+		banner.MaxOff = len(banner.OffProbs)
+	}
+}
+
+// ToSimParams converts resolved engine params into gacha.SimParams.
+// Soft pity fields are only forwarded for the mode in effect.
+func ToSimParams(ep EngineParams) gacha.SimParams {
+	sp := gacha.SimParams{
+		PBase:      ep.PBase,
+		Pity:       ep.Pity,
+		Cushion:    ep.Cushion,
+		MaxOff:     ep.MaxOff,
+		Rarity:     ep.Rarity,
+		BaseRarity: ep.BaseRarity,
+	}
+	for _, t := range ep.Tiers {
+		sp.Tiers = append(sp.Tiers, ToSimParams(t))
+	}
+	sp.Items = toItemPool(ep.Items)
+	sp.BaseItems = toItemPool(ep.BaseItems)
+	if len(ep.OffProbs) > 0 {
+		sp.OffProbs = append([]float64(nil), ep.OffProbs...)
+	}
+	if ep.Spark != nil {
+		sp.Spark = &gacha.SparkParams{
+			Threshold: ep.Spark.Threshold,
+			PerDraw:   ep.Spark.PerDraw,
+			Points:    ep.Spark.Points,
+			Carry:     ep.Spark.Carry,
+		}
+	}
+	if ep.Refund != nil {
+		sp.Refund = &gacha.RefundParams{Amounts: ep.Refund.Amounts, PerDraw: ep.Refund.PerDraw}
+	}
+	if ep.Multi != nil {
+		sp.Multi = &gacha.MultiParams{Size: ep.Multi.Size, MinRarity: ep.Multi.MinRarity}
+	}
+	sp.OffPolicy = ep.OffPolicy
+	sp.RadianceThreshold = ep.RadianceThreshold
+	if ep.Path != nil {
+		sp.Path = &gacha.PathParams{Featured: ep.Path.Featured, Target: ep.Path.Target, MaxFate: ep.Path.MaxFate}
+	}
+	switch gacha.SoftMode(ep.SoftMode) {
+	case gacha.SoftTargetRamp:
+		sp.SoftMode = ep.SoftMode
+		sp.StartAt = ep.StartAt
+		sp.StartPct = ep.StartPct
+		sp.TargetProb = ep.Target
+		sp.Easing = ep.Easing
+	case gacha.SoftPerDrawIncrement:
+		sp.SoftMode = ep.SoftMode
+		sp.StartAt = ep.StartAt
+		sp.StartPct = ep.StartPct
+		sp.Increment = ep.Increment
+	}
+	return sp
+}
+
+func toItemPool(r *RosterConfig) *gacha.ItemPool {
+	if r == nil {
+		return nil
+	}
+	conv := func(items []ItemConfig) []gacha.WeightedItem {
+		out := make([]gacha.WeightedItem, len(items))
+		for i, it := range items {
+			out[i] = gacha.WeightedItem{ID: it.ID, Weight: it.Weight}
+		}
+		return out
+	}
+	return &gacha.ItemPool{Featured: conv(r.Featured), Standard: conv(r.Standard)}
+}
@@ -4,8 +4,17 @@ package game
 // Raw config loaded from YAML; mirrors your schema.
 type RawConfig struct {
 	Version string          `yaml:"version"`
+	DisplayName string      `yaml:"display_name,omitempty"`
 	Draw    DrawConfig      `yaml:"draw"`
 	Banner  *BannerConfig   `yaml:"banner,omitempty"`
+	Tiers       []TierConfig               `yaml:"tiers,omitempty"`       // lower rarities drawn alongside draw/banner
+	Items   map[int]RosterConfig `yaml:"items,omitempty"` // item roster keyed by rarity
+	PityGroup  string                     `yaml:"pity_group,omitempty"`  // pools in one group share pity state
+	PityGroups map[string]PityGroupConfig `yaml:"pity_groups,omitempty"` // per-group carry-over rules
+	Spark   *SparkConfig    `yaml:"spark,omitempty"` // exchange points that buy the featured top-rarity item
+	Refunds *RefundConfig   `yaml:"refunds,omitempty"` // duplicate refunds in a secondary currency
+	Multi   *MultiConfig    `yaml:"multi,omitempty"`   // multi-pull size and guaranteed rarity
+	Selector *SelectorConfig `yaml:"selector,omitempty"` // player picks the featured item
 	Tokens  *TokenConfig    `yaml:"tokens,omitempty"`
 	Notes   string          `yaml:"notes,omitempty"`
 }
@@ -14,6 +23,8 @@ type DrawConfig struct {
 	PBase *float64 `yaml:"p_base"`
 	Pity  *int     `yaml:"pity"`
 	Soft  *SoftCfg `yaml:"soft,omitempty"`
+	Rarity     int      `yaml:"rarity,omitempty"`      // rarity of this tier; 0 means 5
+	BaseRarity int      `yaml:"base_rarity,omitempty"` // rarity when no tier hits; 0 means 3
 }
 type SoftCfg struct {
 	Mode       string   `yaml:"mode"` // "target_ramp" | "per_draw_increment"
@@ -26,13 +37,107 @@ type SoftCfg struct {
 type BannerConfig struct {
 	OffProbs []float64 `yaml:"off_probs"`
 	MaxOff   int       `yaml:"max_off"`
+	Path     *PathConfig `yaml:"path,omitempty"` // epitomized path (weapon banners)
+	OffPolicy *OffPolicyConfig `yaml:"off_policy,omitempty"` // how off vs UP is decided; nil means streak
 	// optional special rules...
 }
+// OffPolicyConfig selects the off-decision policy of a banner.
+// - streak: off_probs indexed by consecutive offs (default)
+// - radiance: off_probs[0] until a hidden counter of lost 50/50s reaches threshold, then a forced win
+type OffPolicyConfig struct {
+	Kind      string `yaml:"kind"`                // "streak" | "radiance"
+	Threshold int    `yaml:"threshold,omitempty"` // radiance: counter value that forces a win
+}
+// PathConfig charts a path to one featured item; misses on it earn fate points.
+type PathConfig struct {
+	Featured int    `yaml:"featured,omitempty"` // number of featured items; 0 means the featured roster size, or 2
+	Target   string `yaml:"target,omitempty"`   // charted featured item id; empty means the first featured item
+	MaxFate  int    `yaml:"max_fate,omitempty"` // fate points that guarantee the target; 0 means 1
+}
+// TierConfig is a lower rarity (e.g., 4★) with its own pity counter and UP/off rule.
+type TierConfig struct {
+	Rarity int           `yaml:"rarity"`
+	PBase  *float64      `yaml:"p_base"`
+	Pity   *int          `yaml:"pity"`
+	Soft   *SoftCfg      `yaml:"soft,omitempty"`
+	Banner *BannerConfig `yaml:"banner,omitempty"`
+}
+// RosterConfig lists the items of one rarity.
+type RosterConfig struct {
+	Featured []ItemConfig `yaml:"featured,omitempty"` // UP units
+	Standard []ItemConfig `yaml:"standard,omitempty"` // off-banner units
+}
+type ItemConfig struct {
+	ID     string  `yaml:"id"`
+	Weight float64 `yaml:"weight,omitempty"` // relative weight; 0 means 1
+}
+// PityGroupConfig controls what survives a banner rotation inside a pity group.
+// Count is always shared; nil flags mean carry over.
+type PityGroupConfig struct {
+	CarryGuarantee *bool `yaml:"carry_guarantee,omitempty"`  // keep GuaranteedNext on rotation
+	CarryOffStreak *bool `yaml:"carry_off_streak,omitempty"` // keep OffStreak on rotation
+}
+// SparkConfig grants exchange points per draw; threshold points buy the UP item.
+type SparkConfig struct {
+	Threshold *int  `yaml:"threshold"`          // points per exchange; 0 disables an inherited spark
+	PerDraw   *int  `yaml:"per_draw,omitempty"` // points per draw; default 1
+	Carry     *bool `yaml:"carry,omitempty"`    // points carry across banners; default false
+}
+// RefundConfig refunds a secondary currency for duplicates, keyed by rarity.
+type RefundConfig struct {
+	Currency string        `yaml:"currency,omitempty"` // display name, e.g. "Starglitter"
+	PerDraw  *int          `yaml:"per_draw"`           // secondary currency per reconverted draw; 0 disables reconversion
+	Amounts  map[int][]int `yaml:"amounts"`            // refund of the 1st, 2nd, ... duplicate; last value repeats
+}
+// MultiConfig describes a multi-pull (ten-pull) and its guaranteed rarity.
+type MultiConfig struct {
+	Size      int `yaml:"size,omitempty"`       // draws per multi-pull; 0 means 10
+	MinRarity int `yaml:"min_rarity,omitempty"` // at least one item of this rarity or better; 0 means none
+}
+// SelectorConfig lets the player choose the UP among items[rarity].featured.
+// Switching targets always keeps the selection's own rules below.
+type SelectorConfig struct {
+	Rarity   int    `yaml:"rarity,omitempty"`    // rarity whose featured item is chosen; 0 means draw.rarity
+	Default  string `yaml:"default,omitempty"`   // selection of players who have not chosen; empty requires a choice
+	KeepPity *bool  `yaml:"keep_pity,omitempty"` // keep Count on switch; default true
+	KeepFate *bool  `yaml:"keep_fate,omitempty"` // keep guarantee, off streak and fate points on switch; default false
+}
 type TokenConfig struct {
 	PerDraw    *int `yaml:"per_draw"`
 	PerTenDraw *int `yaml:"per_ten_draw"`
 }
 
+// PathParams is a resolved epitomized path.
+type PathParams struct {
+	Featured int // number of featured items
+	Target   int // index of the charted item among them
+	MaxFate  int
+}
+
+// SparkParams is a resolved spark.
+type SparkParams struct {
+	Threshold int
+	PerDraw   int
+	Carry     bool
+	Points    int // points held when entering the pool (request override)
+}
+
+// RefundParams is a resolved refund table.
+type RefundParams struct {
+	Currency string
+	PerDraw  int
+	Amounts  map[int][]int
+}
+
+// SelectorParams is a resolved selector.
+type SelectorParams struct {
+	Rarity   int
+	Choices  []string // featured item ids of Rarity
+	Default  string
+	KeepPity bool
+	KeepFate bool
+}
+
 // Normalized engine params used by internal/gacha.
 type EngineParams struct {
 	PBase     float64
@@ -45,6 +150,24 @@ type EngineParams struct {
 	Easing    string
 	OffProbs  []float64
 	MaxOff    int
+	Path      *PathParams // epitomized path; nil if not configured
+	OffPolicy string      // "streak" or "radiance"
+	RadianceThreshold int // radiance counter value that forces a win
 	Cushion   int
+	Spark     *SparkParams // top tier only; nil if not configured
+	Refund    *RefundParams // duplicate refunds; nil if not configured
+	Multi     *MultiConfig  // multi-pull with defaults filled; nil if not configured
+	Selector  *SelectorParams // choose-your-UP rules; nil if not configured
 	Version   string // effective config version for tracing
+
+	Rarity     int            // rarity of the tier above
+	BaseRarity int            // rarity when no tier hits
+	Tiers      []EngineParams // lower tiers; their Tiers/Version are unused
+
+	Items     *RosterConfig // roster of this tier's rarity; nil if none
+	BaseItems *RosterConfig // roster of BaseRarity; nil if none
+
+	PityGroup      string // shared state family; "" means the pool has its own state
+	CarryGuarantee bool   // keep GuaranteedNext when the group's banner rotates
+	CarryOffStreak bool   // keep OffStreak when the group's banner rotates
 }
@@ -2,10 +2,23 @@ package game
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
 )
 
+// ValidationError lists every semantic problem found in a config.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("config validation failed: %s", strings.Join(e.Errors, "; "))
+}
+
 // ValidateRaw checks semantic constraints of a RawConfig.
+// Failures are reported as *ValidationError.
 func ValidateRaw(cfg RawConfig) error {
 	var errs []string
 
@@ -21,59 +34,136 @@ func ValidateRaw(cfg RawConfig) error {
 	}
 
 	// soft
-	if cfg.Draw.Soft != nil {
-		mode := cfg.Draw.Soft.Mode
-		switch mode {
-		case "target_ramp":
-			// need start_at or start_pct; need target
-			if cfg.Draw.Soft.Target == nil {
-				errs = append(errs, "draw.soft.target is required for mode=target_ramp")
-			} else if *cfg.Draw.Soft.Target <= 0 || *cfg.Draw.Soft.Target >= 1 {
-				errs = append(errs, "draw.soft.target must be in (0,1)")
-			}
-			if cfg.Draw.Soft.StartAt == nil && cfg.Draw.Soft.StartPct == nil {
-				errs = append(errs, "draw.soft.start_at or start_pct is required for mode=target_ramp")
-			}
-		case "per_draw_increment":
-			// need start_at; need increment > 0
-			if cfg.Draw.Soft.StartAt == nil {
-				errs = append(errs, "draw.soft.start_at is required for mode=per_draw_increment")
-			}
-			if cfg.Draw.Soft.Increment == nil {
-				errs = append(errs, "draw.soft.increment is required for mode=per_draw_increment")
-			} else if *cfg.Draw.Soft.Increment <= 0 {
-				errs = append(errs, "draw.soft.increment must be > 0 for mode=per_draw_increment")
-			}
-		case "", "none":
-			// treat as no soft pity
-		default:
-			errs = append(errs, "draw.soft.mode must be one of: target_ramp, per_draw_increment, none")
+	errs = append(errs, validateSoft("draw.soft", cfg.Draw.Soft, cfg.Draw.Pity)...)
+
+	top, base := cfg.Draw.Rarity, cfg.Draw.BaseRarity
+	if top == 0 {
+		top = 5
+	}
+	if base == 0 {
+		base = 3
+	}
+
+	// banner
+	errs = append(errs, validateBanner("banner", cfg.Banner, rosterOf(cfg.Items, top))...)
+
+	// tiers
+	if base >= top {
+		errs = append(errs, "draw.base_rarity must be < draw.rarity")
+	}
+	seen := make(map[int]bool)
+	for i, t := range cfg.Tiers {
+		prefix := fmt.Sprintf("tiers[%d]", i)
+		if t.Rarity <= base || t.Rarity >= top {
+			errs = append(errs, fmt.Sprintf("%s.rarity must satisfy base_rarity < rarity < draw.rarity", prefix))
+		} else if seen[t.Rarity] {
+			errs = append(errs, fmt.Sprintf("%s.rarity %d is duplicated", prefix, t.Rarity))
+		}
+		seen[t.Rarity] = true
+		if t.Pity == nil {
+			errs = append(errs, prefix+".pity is required")
+		} else if *t.Pity <= 0 {
+			errs = append(errs, prefix+".pity must be >= 1")
+		}
+		if t.PBase == nil {
+			errs = append(errs, prefix+".p_base is required")
+		} else if *t.PBase <= 0 || *t.PBase >= 1 {
+			errs = append(errs, prefix+".p_base must be in (0,1)")
 		}
+		errs = append(errs, validateSoft(prefix+".soft", t.Soft, t.Pity)...)
+		errs = append(errs, validateBanner(prefix+".banner", t.Banner, rosterOf(cfg.Items, t.Rarity))...)
+	}
 
-		// start_at/start_pct bounds if present
-		if cfg.Draw.Pity != nil && cfg.Draw.Soft.StartAt != nil {
-			if *cfg.Draw.Soft.StartAt < 0 || *cfg.Draw.Soft.StartAt >= *cfg.Draw.Pity {
-				errs = append(errs, "draw.soft.start_at must satisfy 0 <= start_at < pity")
-			}
+	// items (sorted for stable error order)
+	rarities := make([]int, 0, len(cfg.Items))
+	for r := range cfg.Items {
+		rarities = append(rarities, r)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(rarities)))
+	for _, r := range rarities {
+		roster := cfg.Items[r]
+		if r != top && r != base && !seen[r] {
+			errs = append(errs, fmt.Sprintf("items[%d] does not match any configured rarity", r))
 		}
-		if cfg.Draw.Soft.StartPct != nil {
-			if *cfg.Draw.Soft.StartPct < 0 || *cfg.Draw.Soft.StartPct > 1 {
-				errs = append(errs, "draw.soft.start_pct must be in [0,1]")
+		ids := make(map[string]bool)
+		lists := []struct {
+			name  string
+			items []ItemConfig
+		}{{"featured", roster.Featured}, {"standard", roster.Standard}}
+		for _, l := range lists {
+			for i, it := range l.items {
+				prefix := fmt.Sprintf("items[%d].%s[%d]", r, l.name, i)
+				if it.ID == "" {
+					errs = append(errs, prefix+".id is required")
+				} else if ids[it.ID] {
+					errs = append(errs, fmt.Sprintf("%s.id %q is duplicated", prefix, it.ID))
+				}
+				ids[it.ID] = true
+				if it.Weight < 0 {
+					errs = append(errs, prefix+".weight must be >= 0")
+				}
 			}
 		}
 	}
 
-	// banner
-	if cfg.Banner != nil {
-		if len(cfg.Banner.OffProbs) > 0 {
-			for i, p := range cfg.Banner.OffProbs {
-				if !(p > 0 && p < 1) {
-					errs = append(errs, fmt.Sprintf("banner.off_probs[%d] must be in (0,1)", i))
+	// spark (optional)
+	if sp := cfg.Spark; sp != nil {
+		if sp.Threshold == nil {
+			errs = append(errs, "spark.threshold is required")
+		} else if *sp.Threshold < 0 {
+			errs = append(errs, "spark.threshold must be >= 0 (0 disables spark)")
+		}
+		if sp.PerDraw != nil && *sp.PerDraw < 1 {
+			errs = append(errs, "spark.per_draw must be >= 1")
+		}
+	}
+
+	// refunds (optional)
+	if rf := cfg.Refunds; rf != nil {
+		if rf.PerDraw != nil && *rf.PerDraw < 0 {
+			errs = append(errs, "refunds.per_draw must be >= 0 (0 disables reconversion)")
+		}
+		rarities := make([]int, 0, len(rf.Amounts))
+		for r := range rf.Amounts {
+			rarities = append(rarities, r)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(rarities)))
+		for _, r := range rarities {
+			if r != top && r != base && !seen[r] {
+				errs = append(errs, fmt.Sprintf("refunds.amounts[%d] does not match any configured rarity", r))
+			}
+			for i, a := range rf.Amounts[r] {
+				if a < 0 {
+					errs = append(errs, fmt.Sprintf("refunds.amounts[%d][%d] must be >= 0", r, i))
 				}
 			}
 		}
-		if cfg.Banner.MaxOff < 0 {
-			errs = append(errs, "banner.max_off must be >= 0 (0 means default to len(off_probs))")
+	}
+
+	// multi (optional)
+	if m := cfg.Multi; m != nil {
+		if m.Size < 0 {
+			errs = append(errs, "multi.size must be >= 0 (0 means 10)")
+		}
+		if m.MinRarity != 0 && m.MinRarity != top && !seen[m.MinRarity] {
+			errs = append(errs, "multi.min_rarity must be draw.rarity or a tier rarity")
+		}
+	}
+
+	// selector (optional)
+	if sel := cfg.Selector; sel != nil {
+		r := sel.Rarity
+		if r == 0 {
+			r = top
+		}
+		roster := rosterOf(cfg.Items, r)
+		switch {
+		case r != top && !seen[r]:
+			errs = append(errs, "selector.rarity must be draw.rarity or a tier rarity")
+		case roster == nil || len(roster.Featured) == 0:
+			errs = append(errs, fmt.Sprintf("selector needs featured items in items[%d]", r))
+		case sel.Default != "" && featuredIndex(roster, sel.Default) < 0:
+			errs = append(errs, fmt.Sprintf("selector.default %q is not a featured item", sel.Default))
 		}
 	}
 
@@ -88,7 +178,115 @@ func ValidateRaw(cfg RawConfig) error {
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("config validation failed: %s", strings.Join(errs, "; "))
+		return &ValidationError{Errors: errs}
 	}
 	return nil
 }
+
+// validateSoft checks one soft pity block; prefix is its YAML path, e.g. "draw.soft".
+func validateSoft(prefix string, soft *SoftCfg, pity *int) []string {
+	if soft == nil {
+		return nil
+	}
+	var errs []string
+	switch soft.Mode {
+	case "target_ramp":
+		// need start_at or start_pct; need target
+		if soft.Target == nil {
+			errs = append(errs, prefix+".target is required for mode=target_ramp")
+		} else if *soft.Target <= 0 || *soft.Target >= 1 {
+			errs = append(errs, prefix+".target must be in (0,1)")
+		}
+		if soft.StartAt == nil && soft.StartPct == nil {
+			errs = append(errs, prefix+".start_at or start_pct is required for mode=target_ramp")
+		}
+	case "per_draw_increment":
+		// need start_at; need increment > 0
+		if soft.StartAt == nil {
+			errs = append(errs, prefix+".start_at is required for mode=per_draw_increment")
+		}
+		if soft.Increment == nil {
+			errs = append(errs, prefix+".increment is required for mode=per_draw_increment")
+		} else if *soft.Increment <= 0 {
+			errs = append(errs, prefix+".increment must be > 0 for mode=per_draw_increment")
+		}
+	case "", "none":
+		// treat as no soft pity
+	default:
+		errs = append(errs, prefix+".mode must be one of: target_ramp, per_draw_increment, none")
+	}
+
+	// start_at/start_pct bounds if present
+	if pity != nil && soft.StartAt != nil {
+		if *soft.StartAt < 0 || *soft.StartAt >= *pity {
+			errs = append(errs, prefix+".start_at must satisfy 0 <= start_at < pity")
+		}
+	}
+	if soft.StartPct != nil {
+		if *soft.StartPct < 0 || *soft.StartPct > 1 {
+			errs = append(errs, prefix+".start_pct must be in [0,1]")
+		}
+	}
+	return errs
+}
+
+// validateBanner checks one UP/off block; prefix is its YAML path, e.g. "banner".
+// roster is the item roster of the block's rarity, or nil.
+func validateBanner(prefix string, b *BannerConfig, roster *RosterConfig) []string {
+	if b == nil {
+		return nil
+	}
+	var errs []string
+	for i, p := range b.OffProbs {
+		if !(p > 0 && p < 1) {
+			errs = append(errs, fmt.Sprintf("%s.off_probs[%d] must be in (0,1)", prefix, i))
+		}
+	}
+	if b.MaxOff < 0 {
+		errs = append(errs, prefix+".max_off must be >= 0 (0 means default to len(off_probs))")
+	}
+	if pol := b.OffPolicy; pol != nil {
+		switch gacha.OffPolicyKind(pol.Kind) {
+		case gacha.OffPolicyStreak:
+		case gacha.OffPolicyRadiance:
+			if pol.Threshold < 1 {
+				errs = append(errs, prefix+".off_policy.threshold must be >= 1 for radiance")
+			}
+			if len(b.OffProbs) > 1 {
+				errs = append(errs, prefix+".off_policy radiance uses a single off_probs value")
+			}
+		default:
+			errs = append(errs, prefix+".off_policy.kind must be 'streak' or 'radiance'")
+		}
+	}
+	if path := b.Path; path != nil {
+		if len(b.OffProbs) == 0 {
+			errs = append(errs, prefix+".path requires "+prefix+".off_probs")
+		}
+		if path.Featured < 0 {
+			errs = append(errs, prefix+".path.featured must be >= 0 (0 means the featured roster size)")
+		} else if path.Featured > 0 && roster != nil && len(roster.Featured) > 0 && path.Featured != len(roster.Featured) {
+			errs = append(errs, prefix+".path.featured must match the number of featured items")
+		}
+		if path.MaxFate < 0 {
+			errs = append(errs, prefix+".path.max_fate must be >= 0 (0 means 1)")
+		}
+		if path.Target != "" && featuredIndex(roster, path.Target) < 0 {
+			errs = append(errs, fmt.Sprintf("%s.path.target %q is not a featured item", prefix, path.Target))
+		}
+	}
+	return errs
+}
+
+// featuredIndex returns the position of id in the roster's featured list, or -1.
+func featuredIndex(roster *RosterConfig, id string) int {
+	if roster == nil {
+		return -1
+	}
+	for i, it := range roster.Featured {
+		if it.ID == id {
+			return i
+		}
+	}
+	return -1
+}
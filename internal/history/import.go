@@ -0,0 +1,236 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/xtding233/gacha-backend/internal/audit"
+	"github.com/xtding233/gacha-backend/internal/fit"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+// Pull is one normalized pull with the pity state rebuilt before it.
+type Pull struct {
+	Player   string               `json:"player,omitempty"`
+	Game     string               `json:"game"`
+	Pool     string               `json:"pool"`
+	Family   string               `json:"family"` // pity state family: the pity group, else the pool
+	Time     time.Time            `json:"time"`
+	Item     string               `json:"item,omitempty"`
+	Rarity   int                  `json:"rarity"`
+	IsUp     bool                 `json:"is_up,omitempty"`     // featured item of its rarity
+	OnTarget bool                 `json:"on_target,omitempty"` // charted item of an epitomized path
+	Exact    bool                 `json:"exact,omitempty"`     // the history starts at a fresh account, so Pre is exact
+	Pre      []gacha.TierSnapshot `json:"pre"`                 // state before the pull, priority order
+}
+
+// Options controls an import.
+type Options struct {
+	Player string
+	Game   string
+	Pools  map[string]string // banner type → pool; records of other types are skipped
+	Fresh  bool              // the export starts at the account's first pull
+}
+
+// Report summarizes an import.
+type Report struct {
+	Imported     int
+	Skipped      map[string]int // records per unmapped banner type
+	Unmapped     map[string]int // items missing from the rosters; counted as off-banner
+	Inconsistent int            // pulls the pool's rules rule out, e.g. off-banner under a guarantee
+}
+
+// Import maps records to pools, classifies items as UP or off with the pool
+// rosters and replays them in time order through the engine's state rules.
+// Pools of one pity group share state as they do live; selector choices are
+// not part of exports and are ignored. Records are sorted oldest first
+// (exports listed newest first keep the order of pulls with equal times).
+func Import(r game.Resolver, recs []Record, opt Options) ([]Pull, Report, error) {
+	rep := Report{Skipped: make(map[string]int), Unmapped: make(map[string]int)}
+	recs = append([]Record(nil), recs...)
+	if n := len(recs); n > 1 && recs[0].Time.After(recs[n-1].Time) {
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			recs[i], recs[j] = recs[j], recs[i]
+		}
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Time.Before(recs[j].Time) })
+
+	type pool struct {
+		ep game.EngineParams
+		ts *gacha.TieredSystem
+	}
+	pools := make(map[string]*pool)
+	families := make(map[string]*state.PlayerState)
+	var out []Pull
+	for _, rec := range recs {
+		id, ok := opt.Pools[rec.Banner]
+		if !ok {
+			rep.Skipped[rec.Banner]++
+			continue
+		}
+		p := pools[id]
+		if p == nil {
+			_, ep, err := r.Resolve(opt.Game, id, game.Overrides{})
+			if err != nil {
+				return nil, rep, fmt.Errorf("pool %s: %w", id, err)
+			}
+			ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), gacha.NewSeededRNG(0)) // state only; never drawn
+			if err != nil {
+				return nil, rep, fmt.Errorf("pool %s: %w", id, err)
+			}
+			p = &pool{ep: ep, ts: ts}
+			pools[id] = p
+		}
+		family := p.ep.PityGroup
+		if family == "" {
+			family = id
+		}
+		st := families[family]
+		if st == nil {
+			st = &state.PlayerState{}
+			families[family] = st
+		}
+		st.Rotate(id, p.ep.CarryGuarantee, p.ep.CarryOffStreak, false)
+		st.Apply(p.ts)
+
+		pull := Pull{
+			Player: opt.Player, Game: opt.Game, Pool: id, Family: family,
+			Time: rec.Time, Item: rec.Item, Rarity: rec.Rarity,
+			Exact: opt.Fresh, Pre: p.ts.Snapshot(),
+		}
+		if t := p.ts.Tier(rec.Rarity); t != nil && t.Banner != nil {
+			if t.Items != nil {
+				pull.IsUp, pull.OnTarget = classify(t, rec.Item)
+			}
+			if !pull.IsUp && (t.Items == nil || !listed(t.Items.Standard, rec.Item)) {
+				rep.Unmapped[rec.Item]++
+			}
+		}
+		err := p.ts.Observe(gacha.TierOutcome{Rarity: rec.Rarity, IsUp: pull.IsUp, OnTarget: pull.OnTarget})
+		switch {
+		case errors.Is(err, gacha.ErrObservation):
+			rep.Inconsistent++
+		case err != nil:
+			return nil, rep, fmt.Errorf("%s at %s: rarity %d is not drawn in pool %s: %w", rec.Item, rec.Time.Format(time.RFC3339), rec.Rarity, id, err)
+		}
+		st.Capture(p.ts)
+		out = append(out, pull)
+		rep.Imported++
+	}
+	return out, rep, nil
+}
+
+// classify reports whether item is the tier's UP and, with a path, its target.
+func classify(t *gacha.Tier, item string) (isUp, onTarget bool) {
+	for i, it := range t.Items.Featured {
+		if it.ID == item {
+			return true, t.Path != nil && t.Path.Target == i
+		}
+	}
+	return false, false
+}
+
+func listed(items []gacha.WeightedItem, id string) bool {
+	for _, it := range items {
+		if it.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSONL writes pulls as JSON Lines, one pull per line.
+func WriteJSONL(w io.Writer, pulls []Pull) error {
+	enc := json.NewEncoder(w)
+	for _, p := range pulls {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJSONL reads pulls written by WriteJSONL.
+func ReadJSONL(r io.Reader) ([]Pull, error) {
+	var out []Pull
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var p Pull
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, p)
+	}
+	return out, sc.Err()
+}
+
+// familyKey groups pulls that share pity state.
+type familyKey struct{ player, game, family string }
+
+// byFamily splits pulls into per-family sequences, keeping their order.
+func byFamily(pulls []Pull) [][]Pull {
+	var order []familyKey
+	groups := make(map[familyKey][]Pull)
+	for _, p := range pulls {
+		k := familyKey{p.Player, p.Game, p.Family}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], p)
+	}
+	out := make([][]Pull, len(order))
+	for i, k := range order {
+		out[i] = groups[k]
+	}
+	return out
+}
+
+// FitHistories turns pulls into one fit.History per player and pity family.
+func FitHistories(pulls []Pull, rarity int) []fit.History {
+	var out []fit.History
+	for _, seq := range byFamily(pulls) {
+		h := fit.History{Fresh: seq[0].Exact}
+		for _, p := range seq {
+			h.Pulls = append(h.Pulls, fit.Pull{Hit: p.Rarity == rarity, IsUp: p.Rarity == rarity && p.IsUp})
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// Observations turns pulls into audit observations of one tier. Without an
+// exact start, a family's pulls before its first hit of rarity (unknown
+// Count) are left out, and its 50/50 state is trusted only after an UP.
+func Observations(pulls []Pull, rarity int) []audit.Observation {
+	var out []audit.Observation
+	for _, seq := range byFamily(pulls) {
+		counted, settled := seq[0].Exact, seq[0].Exact
+		for _, p := range seq {
+			hit := p.Rarity == rarity
+			if o, ok := audit.Observe(p.Pre, rarity); ok && counted {
+				o.Hit = hit
+				o.IsUp = p.IsUp
+				o.OffUnknown = !settled
+				out = append(out, o)
+			}
+			if hit {
+				counted = true
+				if p.IsUp {
+					settled = true
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+// Package history imports players' pull histories from community export
+// formats and normalizes them, with the pity state rebuilt before every pull.
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is one pull as exported by a game client or community tracker.
+type Record struct {
+	Time   time.Time
+	Banner string // banner type as exported, e.g. "301" or "character"
+	Item   string
+	Rarity int
+}
+
+// column names accepted for each field, lower case, preferred first
+var columns = map[string][]string{
+	"time":   {"time", "timestamp", "date", "datetime"},
+	"banner": {"banner", "banner_type", "gacha_type", "pool", "type"},
+	"item":   {"item", "item_id", "name"},
+	"rarity": {"rarity", "rank_type", "rank", "stars"},
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006/01/02 15:04:05", "2006-01-02"}
+
+// parseTime accepts the layouts above (UTC unless a zone is given) and unix seconds.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, l := range timeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// record builds a Record from field values keyed as in columns.
+func record(get func(field string) (string, bool)) (Record, error) {
+	var rec Record
+	for _, f := range []string{"time", "banner", "item", "rarity"} {
+		v, ok := get(f)
+		if !ok {
+			return Record{}, fmt.Errorf("missing %s", f)
+		}
+		v = strings.TrimSpace(v)
+		switch f {
+		case "time":
+			t, err := parseTime(v)
+			if err != nil {
+				return Record{}, err
+			}
+			rec.Time = t
+		case "banner":
+			rec.Banner = v
+		case "item":
+			rec.Item = v
+		case "rarity":
+			r, err := strconv.Atoi(strings.TrimSuffix(v, "★"))
+			if err != nil {
+				return Record{}, fmt.Errorf("bad rarity %q", v)
+			}
+			rec.Rarity = r
+		}
+	}
+	return rec, nil
+}
+
+// ReadCSV reads records from a CSV export with a header row. Columns are
+// matched by name, case-insensitively: time/timestamp/date, banner/gacha_type,
+// item/name and rarity/rank_type are recognized. Other columns are ignored.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	at := make(map[string]int)
+	for i, h := range header {
+		at[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	index := make(map[string]int)
+	for f, names := range columns {
+		for _, n := range names {
+			if i, ok := at[n]; ok {
+				index[f] = i
+				break
+			}
+		}
+	}
+	var out []Record
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec, err := record(func(f string) (string, bool) {
+			i, ok := index[f]
+			if !ok || i >= len(row) {
+				return "", false
+			}
+			return row[i], true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, rec)
+	}
+}
+
+// ReadJSON reads records from a JSON array of objects, or from an object
+// holding that array under "list", "records" or "pulls". Keys are matched
+// like ReadCSV's columns; numbers and strings are both accepted.
+func ReadJSON(r io.Reader) ([]Record, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	// numbers stay json.Number so unix times print exactly
+	decode := func(b []byte, v any) error {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		return dec.Decode(v)
+	}
+	var rows []map[string]any
+	if err := decode(raw, &rows); err != nil {
+		var wrapped map[string]json.RawMessage
+		if json.Unmarshal(raw, &wrapped) != nil {
+			return nil, errors.New("expected an array of pulls or an object holding one")
+		}
+		found := false
+		for _, k := range []string{"list", "records", "pulls"} {
+			if v, ok := wrapped[k]; ok {
+				if err := decode(v, &rows); err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("no list, records or pulls array")
+		}
+	}
+	out := make([]Record, 0, len(rows))
+	for i, row := range rows {
+		keys := make(map[string]any, len(row))
+		for k, v := range row {
+			keys[strings.ToLower(k)] = v
+		}
+		rec, err := record(func(f string) (string, bool) {
+			for _, n := range columns[f] {
+				if v, ok := keys[n]; ok && v != nil {
+					return fmt.Sprint(v), true
+				}
+			}
+			return "", false
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pull %d: %w", i, err)
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
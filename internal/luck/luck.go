@@ -0,0 +1,299 @@
+// Package luck ranks a player's real pulls against the engine's own
+// distribution of the same waits: "your 5★ came at draw 12, better than 93%
+// of players", for every wait and for all waits together.
+package luck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/history"
+)
+
+// ErrNoRoster means UP pulls cannot be told apart: the measured tier has a
+// banner but no featured items to classify pulls with.
+var ErrNoRoster = errors.New("pool has no featured items to classify UP pulls")
+
+// Options controls a ranking.
+type Options struct {
+	Rarity int             // tier measured; 0 => the top tier of each pool
+	Goal   gacha.TrialGoal // GoalFirstHit or GoalFirstUP; "" => GoalFirstUP
+	Trials int             // Monte Carlo trials per distinct wait when exact is unsupported; <= 0 => 10000
+	Budget int             // Monte Carlo trials across all sampled waits; <= 0 => 1000000
+	Seed   uint64          // Monte Carlo master seed
+}
+
+// Wait is the run of pulls from one success (or a fresh account) to the next.
+type Wait struct {
+	Pool   string // pool of the wait's first pull; its rules rank the wait
+	Family string
+	End    int  // index in the input of the pull that ended the wait, or of its last pull if open
+	Draws  int  // pulls in the wait, the successful one included
+	Open   bool // no success yet
+	Exact  bool // ranked against the exact distribution rather than samples
+	Mean   float64
+	Better float64 // share of players who needed more draws from the same start
+	Worse  float64 // share who needed fewer
+	Luck   float64 // Better plus half the ties; 0.5 is an average wait
+}
+
+// Report ranks every wait and their sum.
+// An open wait is ranked as if it ended on its next pull, its best case, and
+// is left out of the totals.
+type Report struct {
+	Waits     []Wait
+	Completed int
+	Draws     int     // pulls in completed waits
+	Expected  float64 // expected pulls for the same waits
+	Better    float64 // Draws against the sum of the completed waits' distributions
+	Worse     float64
+	Luck      float64
+	MeanLuck  float64 // average Luck of completed waits
+}
+
+// Rank splits pulls (see history.Import) into waits per player and pity family
+// and ranks each against the draws needed from the state it started in.
+// Without an exact start, a family's pulls up to its first success are left
+// out, since the state they began in is unknown.
+func Rank(ctx context.Context, r game.Resolver, pulls []history.Pull, opt Options) (Report, error) {
+	if opt.Goal == "" {
+		opt.Goal = gacha.GoalFirstUP
+	}
+	if opt.Goal != gacha.GoalFirstHit && opt.Goal != gacha.GoalFirstUP {
+		return Report{}, fmt.Errorf("goal %q: %w", opt.Goal, gacha.ErrExactUnsupported)
+	}
+	if opt.Trials <= 0 {
+		opt.Trials = 10000
+	}
+	if opt.Budget <= 0 {
+		opt.Budget = 1_000_000
+	}
+
+	type pool struct {
+		sim  gacha.SimParams
+		tier gacha.SimParams
+		top  bool // the top tier is measured
+	}
+	pools := make(map[string]*pool)
+	load := func(gameID, id string) (*pool, error) {
+		key := gameID + "/" + id
+		if p := pools[key]; p != nil {
+			return p, nil
+		}
+		_, ep, err := r.Resolve(gameID, id, game.Overrides{})
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %w", id, err)
+		}
+		sim := game.ToSimParams(ep)
+		sim.TargetRarity = opt.Rarity
+		tier, ok := tierParams(sim, opt.Rarity)
+		if !ok {
+			return nil, fmt.Errorf("pool %s: %w", id, gacha.ErrTierConfig)
+		}
+		if opt.Goal == gacha.GoalFirstUP && len(tier.OffProbs) > 0 && (tier.Items == nil || len(tier.Items.Featured) == 0) {
+			return nil, fmt.Errorf("pool %s: %w", id, ErrNoRoster)
+		}
+		p := &pool{sim: sim, tier: tier, top: opt.Rarity <= 0 || opt.Rarity == rarityOf(sim)}
+		pools[key] = p
+		return p, nil
+	}
+
+	// a wait's distribution depends only on its pool and start state, and
+	// when the top tier is measured only on that tier's state
+	type start struct {
+		p   *pool
+		id  string
+		pre []gacha.TierSnapshot
+	}
+	var starts []start
+	keys := make(map[string]int)
+	startOf := func(p *pool, gameID, id string, pre []gacha.TierSnapshot) int {
+		if p.top {
+			pre = own(pre, p.tier)
+		}
+		key := fmt.Sprint(gameID, "/", id, pre)
+		i, ok := keys[key]
+		if !ok {
+			i = len(starts)
+			keys[key] = i
+			starts = append(starts, start{p, id, pre})
+		}
+		return i
+	}
+
+	type open struct {
+		known       bool // the state the family's next wait starts in is known
+		first, last int  // input indices of the current wait's first and latest pull
+		draws       int
+	}
+	type found struct {
+		w     Wait
+		start int
+	}
+	var waits []found
+	emit := func(w *open, isOpen bool) error {
+		first := pulls[w.first]
+		p, err := load(first.Game, first.Pool)
+		if err != nil {
+			return err
+		}
+		waits = append(waits, found{
+			w:     Wait{Pool: first.Pool, Family: first.Family, End: w.last, Draws: w.draws, Open: isOpen},
+			start: startOf(p, first.Game, first.Pool, first.Pre),
+		})
+		return nil
+	}
+
+	type familyKey struct{ player, game, family string }
+	families := make(map[familyKey]*open)
+	var order []familyKey
+	for i, pl := range pulls {
+		fk := familyKey{pl.Player, pl.Game, pl.Family}
+		w := families[fk]
+		if w == nil {
+			w = &open{known: pl.Exact}
+			families[fk] = w
+			order = append(order, fk)
+		}
+		p, err := load(pl.Game, pl.Pool)
+		if err != nil {
+			return Report{}, err
+		}
+		hit := success(p.tier, opt.Goal, pl)
+		if !w.known {
+			w.known = hit
+			continue
+		}
+		if w.draws == 0 {
+			w.first = i
+		}
+		w.draws++
+		w.last = i
+		if hit {
+			if err := emit(w, false); err != nil {
+				return Report{}, err
+			}
+			w.draws = 0
+		}
+	}
+	for _, fk := range order {
+		if w := families[fk]; w.draws > 0 {
+			if err := emit(w, true); err != nil {
+				return Report{}, err
+			}
+		}
+	}
+
+	// exact distributions cost nothing; the sampled ones share the budget
+	type dist struct {
+		d     gacha.Distribution
+		exact bool
+	}
+	dists := make([]dist, len(starts))
+	var sampled []int
+	for i, st := range starts {
+		d, _, err := gacha.WaitFrom(ctx, st.p.sim, opt.Goal, st.pre, 0, gacha.MCOptions{})
+		switch {
+		case errors.Is(err, gacha.ErrExactUnsupported):
+			sampled = append(sampled, i)
+		case err != nil:
+			return Report{}, fmt.Errorf("pool %s: %w", st.id, err)
+		default:
+			dists[i] = dist{d, true}
+		}
+	}
+	if len(sampled) > 0 {
+		trials := min(opt.Trials, max(opt.Budget/len(sampled), 1))
+		for _, i := range sampled {
+			st := starts[i]
+			d, exact, err := gacha.WaitFrom(ctx, st.p.sim, opt.Goal, st.pre, trials, gacha.MCOptions{Seed: opt.Seed})
+			if err != nil {
+				return Report{}, fmt.Errorf("pool %s: %w", st.id, err)
+			}
+			dists[i] = dist{d, exact}
+		}
+	}
+
+	var rep Report
+	var done []gacha.Distribution
+	var sumLuck float64
+	for _, f := range waits {
+		w, d := f.w, dists[f.start]
+		k := w.Draws
+		if w.Open {
+			k++
+		}
+		w.Better, w.Worse = d.d.Rank(k)
+		w.Exact, w.Mean, w.Luck = d.exact, d.d.Mean, luckOf(w.Better, w.Worse)
+		rep.Waits = append(rep.Waits, w)
+		if !w.Open {
+			done = append(done, d.d)
+			rep.Completed++
+			rep.Draws += w.Draws
+			rep.Expected += w.Mean
+			sumLuck += w.Luck
+		}
+	}
+	if rep.Completed > 0 {
+		total := gacha.Sum(done...)
+		rep.Better, rep.Worse = total.Rank(rep.Draws)
+		rep.Luck = luckOf(rep.Better, rep.Worse)
+		rep.MeanLuck = sumLuck / float64(rep.Completed)
+	}
+	return rep, nil
+}
+
+// tierParams returns the params of p's tier of rarity; 0 means the top tier.
+func tierParams(p gacha.SimParams, rarity int) (gacha.SimParams, bool) {
+	if rarity <= 0 || rarity == rarityOf(p) {
+		return p, true
+	}
+	for _, t := range p.Tiers {
+		if t.Rarity == rarity {
+			return t, true
+		}
+	}
+	return gacha.SimParams{}, false
+}
+
+// success reports whether pull ends a wait for goal on tier, counting UP as
+// the simulations do: the charted item with an epitomized path, any hit
+// without a banner.
+func success(tier gacha.SimParams, goal gacha.TrialGoal, pull history.Pull) bool {
+	rarity := rarityOf(tier)
+	switch {
+	case pull.Rarity != rarity:
+		return false
+	case goal == gacha.GoalFirstHit || len(tier.OffProbs) == 0:
+		return true
+	case tier.Path != nil && tier.Path.Target >= 0:
+		return pull.OnTarget
+	}
+	return pull.IsUp
+}
+
+// luckOf counts ties as half better, half worse.
+func luckOf(better, worse float64) float64 {
+	return better + (1-better-worse)/2
+}
+
+// rarityOf returns the rarity of the tier p describes; 0 means 5★.
+func rarityOf(p gacha.SimParams) int {
+	if p.Rarity <= 0 {
+		return 5
+	}
+	return p.Rarity
+}
+
+// own keeps only the state of tier among pre.
+func own(pre []gacha.TierSnapshot, tier gacha.SimParams) []gacha.TierSnapshot {
+	for _, s := range pre {
+		if s.Rarity == rarityOf(tier) {
+			return []gacha.TierSnapshot{s}
+		}
+	}
+	return nil
+}
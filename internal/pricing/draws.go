@@ -0,0 +1,10 @@
+package pricing
+
+import "github.com/xtding233/gacha-backend/internal/token"
+
+// CostOfDraws finds the minimum-cost plan for the tokens of draws paid pulls.
+// Feed it paid draws from a simulation that reconverts duplicate refunds
+// (e.g., a mean or P90) so the plan prices the effective cost, not the nominal one.
+func CostOfDraws(cat Catalog, tok token.Token, draws int, first FirstTimeState) Plan {
+	return MinCostAtLeastTokens(cat, tok.TokensForDraws(draws), first)
+}
@@ -0,0 +1,131 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrInvalidKey means a key cannot name a file: its player or game is empty,
+// or a part of it is "." or "..".
+var ErrInvalidKey = errors.New("invalid state key")
+
+// FileStore keeps one JSON file per key under a base directory:
+// <dir>/<game>/<family>/<player>.json (path segments are escaped).
+// It is meant for local use by a single process; writes go through a temp file + rename
+// so a crash never leaves a half-written state behind.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a store rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path escapes separators, so every key maps to a file directly under its
+// game and family directories.
+func (f *FileStore) path(key Key) (string, error) {
+	family := key.Family
+	if family == "" {
+		family = "$game" // game-level draws without a pool
+	}
+	if key.Player == "" || key.Game == "" {
+		return "", ErrInvalidKey
+	}
+	for _, part := range []string{key.Game, key.Family, key.Player} {
+		if part == "." || part == ".." {
+			return "", fmt.Errorf("%q: %w", part, ErrInvalidKey)
+		}
+	}
+	return filepath.Join(f.dir,
+		url.PathEscape(key.Game),
+		url.PathEscape(family),
+		url.PathEscape(key.Player)+".json"), nil
+}
+
+func (f *FileStore) Load(ctx context.Context, key Key) (PlayerState, error) {
+	if err := ctx.Err(); err != nil {
+		return PlayerState{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.read(key)
+}
+
+func (f *FileStore) Update(ctx context.Context, key Key, fn func(*PlayerState) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	st, err := f.read(key)
+	if err != nil {
+		return err
+	}
+	if err := fn(&st); err != nil {
+		return err
+	}
+	return f.write(key, st)
+}
+
+// read loads a state file; a missing file is the zero state.
+func (f *FileStore) read(key Key) (PlayerState, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return PlayerState{}, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return PlayerState{}, nil
+		}
+		return PlayerState{}, err
+	}
+	var st PlayerState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return PlayerState{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// write saves a state file atomically.
+func (f *FileStore) write(key Key, st PlayerState) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+// Key identifies one player's pity state for a family of pools of a game.
+// Pools in the same family read and write the same state.
+type Key struct {
+	Player string
+	Game   string
+	Family string
+}
+
+// TierState is the carried-over state of one rarity tier.
+type TierState struct {
+	Count          int  `json:"count"`                 // draws since last hit of this rarity
+	OffStreak      int  `json:"off_streak"`            // consecutive off-banner hits
+	GuaranteedNext bool `json:"guaranteed_next"`       // next hit of this rarity is UP
+	FatePoints     int  `json:"fate_points,omitempty"` // epitomized path points
+	Radiance       int  `json:"radiance,omitempty"`    // hidden counter of the radiance off policy
+}
+
+// PlayerState is everything persisted between draw calls.
+type PlayerState struct {
+	Tiers       map[int]TierState `json:"tiers"`                  // keyed by rarity
+	SparkPoints int               `json:"spark_points,omitempty"` // exchange points of the top tier
+	Selected    map[int]string    `json:"selected,omitempty"`     // chosen featured item per rarity (selector banners)
+	Fair        *FairSeed         `json:"fair,omitempty"`         // committed seed of the next provably fair batch
+	Pool        string            `json:"pool"`                   // pool of the last draw; detects banner rotation in a pity group
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// FairSeed is the hidden server seed of a player's next provably fair batch.
+// Only its commitment is shown until the batch reveals it.
+type FairSeed struct {
+	ServerSeed string `json:"server_seed"`
+	Nonce      uint64 `json:"nonce"` // batches drawn so far; numbers the next one
+}
+
+// Commit returns the fair seed of the next batch, creating one if needed.
+func (s *PlayerState) Commit() (FairSeed, error) {
+	if s.Fair == nil {
+		seed, err := gacha.NewServerSeed()
+		if err != nil {
+			return FairSeed{}, err
+		}
+		s.Fair = &FairSeed{ServerSeed: seed}
+		s.UpdatedAt = time.Now().UTC()
+	}
+	return *s.Fair, nil
+}
+
+// Reveal retires the committed seed after a batch used it: a fresh seed is
+// committed for the next batch and the nonce advances.
+func (s *PlayerState) Reveal() error {
+	seed, err := gacha.NewServerSeed()
+	if err != nil {
+		return err
+	}
+	var nonce uint64
+	if s.Fair != nil {
+		nonce = s.Fair.Nonce + 1
+	}
+	s.Fair = &FairSeed{ServerSeed: seed, Nonce: nonce}
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// Store loads and saves player state.
+type Store interface {
+	// Load returns the state for key; a missing key yields the zero state and no error.
+	Load(ctx context.Context, key Key) (PlayerState, error)
+	// Update loads the state for key, lets fn mutate it and saves the result.
+	// Updates of the same key never interleave. If fn fails nothing is saved.
+	Update(ctx context.Context, key Key, fn func(*PlayerState) error) error
+}
+
+// Rotate moves the state to pool. When it differs from the pool of the last draw
+// (the group's banner rotated), GuaranteedNext and OffStreak are reset unless kept.
+// Count and the radiance counter are always carried over; FatePoints never are,
+// as they belong to the old featured items, and neither are selections.
+// SparkPoints are reset unless kept.
+func (s *PlayerState) Rotate(pool string, keepGuarantee, keepOffStreak, keepSpark bool) {
+	if s.Pool != "" && s.Pool != pool {
+		if !keepSpark {
+			s.SparkPoints = 0
+		}
+		s.Selected = nil
+		for r, t := range s.Tiers {
+			if !keepGuarantee {
+				t.GuaranteedNext = false
+			}
+			if !keepOffStreak {
+				t.OffStreak = 0
+			}
+			t.FatePoints = 0
+			s.Tiers[r] = t
+		}
+	}
+	s.Pool = pool
+}
+
+// Apply copies the stored counters into a freshly built tiered system.
+// Tiers without stored state are left as constructed.
+func (s PlayerState) Apply(ts *gacha.TieredSystem) {
+	for _, t := range ts.Tiers {
+		st, ok := s.Tiers[t.Rarity]
+		if !ok {
+			continue
+		}
+		t.Soft.Count = st.Count
+		if t.Banner != nil {
+			t.Banner.OffStreak = st.OffStreak
+			t.Banner.GuaranteedNext = st.GuaranteedNext
+			if rp, ok := t.Banner.Policy.(*gacha.RadiancePolicy); ok {
+				rp.Counter = st.Radiance
+			}
+		}
+		if t.Path != nil {
+			t.Path.FatePoints = min(st.FatePoints, t.Path.MaxFate)
+		}
+	}
+}
+
+// Capture records the counters of ts after a draw.
+func (s *PlayerState) Capture(ts *gacha.TieredSystem) {
+	if s.Tiers == nil {
+		s.Tiers = make(map[int]TierState, len(ts.Tiers))
+	}
+	for _, t := range ts.Tiers {
+		st := TierState{Count: t.Soft.Count}
+		if t.Banner != nil {
+			st.OffStreak = t.Banner.OffStreak
+			st.GuaranteedNext = t.Banner.GuaranteedNext
+			if rp, ok := t.Banner.Policy.(*gacha.RadiancePolicy); ok {
+				st.Radiance = rp.Counter
+			}
+		}
+		if t.Path != nil {
+			st.FatePoints = t.Path.FatePoints
+		}
+		s.Tiers[t.Rarity] = st
+	}
+	s.UpdatedAt = time.Now().UTC()
+}
+
+// Select makes id the chosen featured item of rarity and reports whether it
+// replaced a different choice. On such a switch Count is reset unless keepPity,
+// and GuaranteedNext, OffStreak and FatePoints unless keepFate.
+// A first choice never resets anything.
+func (s *PlayerState) Select(rarity int, id string, keepPity, keepFate bool) bool {
+	prev := s.Selected[rarity]
+	if s.Selected == nil {
+		s.Selected = make(map[int]string)
+	}
+	s.Selected[rarity] = id
+	if prev == "" || prev == id {
+		return false
+	}
+	if t, ok := s.Tiers[rarity]; ok {
+		if !keepPity {
+			t.Count = 0
+		}
+		if !keepFate {
+			t.GuaranteedNext = false
+			t.OffStreak = 0
+			t.FatePoints = 0
+		}
+		s.Tiers[rarity] = t
+	}
+	s.UpdatedAt = time.Now().UTC()
+	return true
+}
+
+// clone deep-copies a state so callers never share maps with the store.
+func (s PlayerState) clone() PlayerState {
+	out := s
+	if s.Tiers != nil {
+		out.Tiers = make(map[int]TierState, len(s.Tiers))
+		for r, t := range s.Tiers {
+			out.Tiers[r] = t
+		}
+	}
+	if s.Selected != nil {
+		out.Selected = make(map[int]string, len(s.Selected))
+		for r, id := range s.Selected {
+			out.Selected[r] = id
+		}
+	}
+	if s.Fair != nil {
+		f := *s.Fair
+		out.Fair = &f
+	}
+	return out
+}
+
+// MemoryStore keeps state in process memory; it is lost on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state map[Key]PlayerState
+}
+
+// NewMemoryStore creates an empty in-process store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{state: make(map[Key]PlayerState)}
+}
+
+func (m *MemoryStore) Load(ctx context.Context, key Key) (PlayerState, error) {
+	if err := ctx.Err(); err != nil {
+		return PlayerState{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state[key].clone(), nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, key Key, fn func(*PlayerState) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.state[key].clone()
+	if err := fn(&st); err != nil {
+		return err
+	}
+	m.state[key] = st
+	return nil
+}
@@ -18,7 +18,7 @@ func (t Token) TokensForDraws(n int) int {
 	if t.PerTenDraw > 0 && n >= 10 && t.N <= 1 {
 		tens := n / 10
 		remTens := n % 10
-		return tens & t.PerTenDraw + remTens * t.PerDraw
+		return tens * t.PerTenDraw + remTens * t.PerDraw
 	}
 	if t.PerNDraw > 0 && n >= t.N && t.N > 1 {
 		ns := n / t.N
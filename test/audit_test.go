@@ -0,0 +1,146 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/audit"
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestAuditStatistics(t *testing.T) {
+	cases := []struct {
+		name      string
+		got, want float64
+	}{
+		{"binomial at the mode", audit.BinomialTest(5, 10, 0.5), 1},
+		{"binomial in the tail", audit.BinomialTest(0, 10, 0.5), 2.0 / 1024},
+		{"chi-square 1 df", audit.ChiSquareSF(3.841459, 1), 0.05},
+		{"chi-square 10 df", audit.ChiSquareSF(18.307038, 10), 0.05},
+		{"chi-square 2 df", audit.ChiSquareSF(2, 2), math.Exp(-1)},
+	}
+	for _, c := range cases {
+		if math.Abs(c.got-c.want) > 1e-6 {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+// auditPulls draws n pulls under p and returns them as top tier observations.
+func auditPulls(t *testing.T, p gacha.SimParams, seed uint64, n int) []audit.Observation {
+	t.Helper()
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evs []eventlog.Event
+	ts.Trace = func(tr gacha.PullTrace) { evs = append(evs, eventlog.FromTrace(tr, nil)) }
+	if _, err := ts.DrawBatch(n, 1); err != nil {
+		t.Fatal(err)
+	}
+	return audit.Observations(evs, ts.Tiers[0].Rarity)
+}
+
+func auditParams() gacha.SimParams {
+	start, target := 40, 0.5
+	return gacha.SimParams{
+		PBase: 0.02, Pity: 60, StartAt: &start, TargetProb: &target,
+		OffProbs: []float64{0.5}, MaxOff: 1,
+	}
+}
+
+func TestAuditHonestRates(t *testing.T) {
+	p := auditParams()
+	obs := auditPulls(t, p, 3, 100_000)
+	rep, err := audit.Audit(p, obs, audit.Options{BinWidth: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Pity.PValue < 0.001 || rep.Off.PValue < 0.001 {
+		t.Fatalf("honest pulls flagged: pity p=%v off p=%v", rep.Pity.PValue, rep.Off.PValue)
+	}
+	if len(rep.Pity.Bins) != 12 || rep.Pity.Bins[11].From != 55 || rep.Pity.Bins[11].To != 59 {
+		t.Fatalf("unexpected bins: %+v", rep.Pity.Bins)
+	}
+	if rep.Guaranteed == 0 || rep.GuaranteeBroken != 0 {
+		t.Fatalf("guarantees: %d, broken %d", rep.Guaranteed, rep.GuaranteeBroken)
+	}
+}
+
+func TestAuditDetectsDrift(t *testing.T) {
+	disclosed := auditParams()
+
+	lowBase := auditParams()
+	lowBase.PBase = 0.015
+	rep, err := audit.Audit(disclosed, auditPulls(t, lowBase, 4, 100_000), audit.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Pity.PValue > 1e-6 {
+		t.Fatalf("lowered base rate not detected: p=%v", rep.Pity.PValue)
+	}
+
+	rigged := auditParams()
+	rigged.OffProbs = []float64{0.6}
+	rep, err = audit.Audit(disclosed, auditPulls(t, rigged, 5, 100_000), audit.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Off.PValue > 1e-6 {
+		t.Fatalf("rigged 50/50 not detected: p=%v", rep.Off.PValue)
+	}
+
+	// a miss at hard pity is impossible
+	broken := []audit.Observation{{Count: disclosed.Pity - 1}}
+	rep, err = audit.Audit(disclosed, broken, audit.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Pity.PValue != 0 {
+		t.Fatalf("hard pity miss should fail outright, p=%v", rep.Pity.PValue)
+	}
+	if _, err := audit.Audit(disclosed, nil, audit.Options{Rarity: 4}); err == nil {
+		t.Fatal("auditing a missing tier should fail")
+	}
+}
+
+func TestAuditTieredPool(t *testing.T) {
+	// a 5★ rolled while the 4★ is at hard pity is common here; neither
+	// tier's audit may count it against the rates
+	p := auditParams()
+	p.PBase = 0.05
+	p.Tiers = []gacha.SimParams{{Rarity: 4, PBase: 0.1, Pity: 10}}
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evs []eventlog.Event
+	ts.Trace = func(tr gacha.PullTrace) { evs = append(evs, eventlog.FromTrace(tr, nil)) }
+	if _, err := ts.DrawBatch(100_000, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, rarity := range []int{5, 4} {
+		obs := audit.Observations(evs, rarity)
+		if len(obs) != len(evs) {
+			t.Fatalf("%d★: %d observations of %d pulls", rarity, len(obs), len(evs))
+		}
+		rep, err := audit.Audit(p, obs, audit.Options{Rarity: rarity})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rep.Pity.PValue < 0.001 {
+			t.Fatalf("%d★: honest pulls flagged, p=%v bins %+v", rarity, rep.Pity.PValue, rep.Pity.Bins)
+		}
+	}
+
+	// with the 5★ at hard pity the 4★ cannot win, whatever its own pity says
+	obs := []audit.Observation{{Count: 9, Others: []gacha.TierSnapshot{{Rarity: 5, Count: p.Pity - 1}}}}
+	rep, err := audit.Audit(p, obs, audit.Options{Rarity: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := rep.Pity.Bins[0]; b.Expected != 0 || rep.Pity.PValue != 1 {
+		t.Fatalf("pre-empted 4★ hard pity: %+v, p=%v", b, rep.Pity.PValue)
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func bootstrapParams() gacha.SimParams {
+	startPct, target := 0.8, 0.6
+	return gacha.SimParams{PBase: 0.01, Pity: 50, StartPct: &startPct, TargetProb: &target, OffProbs: []float64{0.5}, MaxOff: 1}
+}
+
+func TestBootstrapIntervals(t *testing.T) {
+	p := bootstrapParams()
+	d, err := gacha.RunExact(p, gacha.GoalFirstUP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := gacha.RunMonteCarloParallel(context.Background(), p, gacha.GoalFirstUP, 20000, nil, gacha.MCOptions{Seed: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := st.Bootstrap(400, 0.99, 1)
+	if u.Level != 0.99 || u.Resamples != 400 {
+		t.Fatalf("level %v resamples %d", u.Level, u.Resamples)
+	}
+	// the mean's bootstrap error matches the analytic one
+	se := st.StdDev / math.Sqrt(20000)
+	if math.Abs(u.Mean.StdErr-se) > 0.2*se {
+		t.Fatalf("mean std err %v, analytic %v", u.Mean.StdErr, se)
+	}
+	for name, c := range map[string]struct {
+		iv    gacha.Interval
+		point float64
+		truth float64
+	}{
+		"mean": {u.Mean, st.Mean, d.Mean},
+		"std":  {u.StdDev, st.StdDev, d.StdDev},
+		"p90":  {u.P90, st.P90, d.P90},
+	} {
+		if c.iv.Low > c.point || c.iv.High < c.point || c.iv.StdErr <= 0 {
+			t.Fatalf("%s: interval %+v misses the estimate %v", name, c.iv, c.point)
+		}
+		// widened by one unit for quantiles, whose exact value is an integer
+		if c.truth < c.iv.Low-1 || c.truth > c.iv.High+1 {
+			t.Fatalf("%s: interval %+v misses the exact value %v", name, c.iv, c.truth)
+		}
+	}
+	if again := st.Bootstrap(400, 0.99, 1); !reflect.DeepEqual(again, u) {
+		t.Fatal("bootstrap is not reproducible")
+	}
+	if (gacha.Stats{}).Bootstrap(0, 0, 1) != (gacha.Uncertainty{}) {
+		t.Fatal("no samples should give no uncertainty")
+	}
+}
+
+func TestAdaptiveSampling(t *testing.T) {
+	p := bootstrapParams()
+	ctx := context.Background()
+	base, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 1000, nil, gacha.MCOptions{Seed: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ad := &gacha.Adaptive{Quantity: gacha.QuantityMean, Tolerance: 0.2, MaxTrials: 200000}
+	st, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 1000, nil, gacha.MCOptions{Seed: 4, Adaptive: ad})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(st.Samples)
+	if n <= 1000 || n >= ad.MaxTrials {
+		t.Fatalf("ran %d trials", n)
+	}
+	// the first round is the plain run
+	if !reflect.DeepEqual(st.Samples[:1000], base.Samples) {
+		t.Fatal("adaptive run does not start with the requested trials")
+	}
+	if hw := st.Bootstrap(0, 0, 4).Mean.HalfWidth(); hw > ad.Tolerance {
+		t.Fatalf("half-width %v above tolerance after %d trials", hw, n)
+	}
+	again, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 1000, nil, gacha.MCOptions{Seed: 4, Adaptive: ad, Workers: 1})
+	if err != nil || !reflect.DeepEqual(again.Samples, st.Samples) {
+		t.Fatalf("adaptive run is not reproducible: %v", err)
+	}
+
+	// an unreachable tolerance stops at the cap
+	ad = &gacha.Adaptive{Quantity: gacha.QuantityP90, Tolerance: 1e-9, MaxTrials: 5000}
+	st, err = gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 1000, nil, gacha.MCOptions{Seed: 4, Adaptive: ad})
+	if err != nil || len(st.Samples) != 5000 {
+		t.Fatalf("ran %d trials, err %v", len(st.Samples), err)
+	}
+
+	ad = &gacha.Adaptive{Quantity: "p42", Tolerance: 1, MaxTrials: 5000}
+	if _, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 1000, nil, gacha.MCOptions{Adaptive: ad}); !errors.Is(err, gacha.ErrAdaptive) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestNthUPExactMatchesMonteCarlo(t *testing.T) {
+	startPct, target := 0.9, 0.8
+	p := gacha.SimParams{
+		PBase: 0.006, Pity: 90,
+		StartPct: &startPct, TargetProb: &target,
+		OffProbs: []float64{0.5}, MaxOff: 1,
+	}
+	budget := &gacha.SimBudget{Copies: 3}
+	d, err := gacha.RunExact(p, gacha.GoalNthUP, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, err := gacha.RunExact(p, gacha.GoalFirstUP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// copies after the first start from a fresh state, same as the first here
+	if math.Abs(d.Mean-3*one.Mean) > 1e-6 {
+		t.Fatalf("3 copies mean %.4f, want %.4f", d.Mean, 3*one.Mean)
+	}
+	mc, err := gacha.RunMonteCarlo(p, gacha.GoalNthUP, 20000, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tol := 5 * d.StdDev / math.Sqrt(20000)
+	if math.Abs(mc.Mean-d.Mean) > tol {
+		t.Fatalf("exact mean %.3f vs MC %.3f (tol %.3f)", d.Mean, mc.Mean, tol)
+	}
+}
+
+func TestCombinedGoal(t *testing.T) {
+	char := gacha.SimParams{PBase: 0.006, Pity: 90, OffProbs: []float64{0.5}, MaxOff: 1}
+	weapon := gacha.SimParams{PBase: 0.007, Pity: 80, OffProbs: []float64{0.75}, MaxOff: 1}
+	legs := []gacha.GoalLeg{{Params: char, Copies: 2}, {Params: weapon, Copies: 1}}
+
+	ctx := context.Background()
+	opt := gacha.MCOptions{Seed: 7}
+	st, err := gacha.RunMonteCarloCombined(ctx, legs, 20000, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := gacha.RunExact(char, gacha.GoalNthUP, &gacha.SimBudget{Copies: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gacha.RunExact(weapon, gacha.GoalFirstUP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := a.Mean + b.Mean
+	tol := 5 * math.Sqrt(a.Var+b.Var) / math.Sqrt(20000)
+	if math.Abs(st.Mean-want) > tol {
+		t.Fatalf("combined mean %.3f, want %.3f (tol %.3f)", st.Mean, want, tol)
+	}
+
+	again, err := gacha.RunMonteCarloCombined(ctx, legs, 20000, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Mean != st.Mean || again.P99 != st.P99 {
+		t.Fatalf("same seed should reproduce the result")
+	}
+
+	if _, err := gacha.RunMonteCarloCombined(ctx, nil, 10, opt); !errors.Is(err, gacha.ErrNoLegs) {
+		t.Fatalf("want ErrNoLegs, got %v", err)
+	}
+}
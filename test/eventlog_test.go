@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/eventlog"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// logBatch draws n pulls in multis of batch the way the server logs them.
+func logBatch(t *testing.T, p gacha.SimParams, seed uint64, n, batch int) []eventlog.Event {
+	t.Helper()
+	rec := gacha.NewRecordingRNG(gacha.NewSeededRNG(seed))
+	ts, err := gacha.NewTieredFromParams(p, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evs []eventlog.Event
+	ts.Trace = func(tr gacha.PullTrace) {
+		ev := eventlog.FromTrace(tr, rec.Take())
+		ev.Player, ev.Game = "p1", "g"
+		evs = append(evs, ev)
+	}
+	if _, err := ts.DrawBatch(n, batch); err != nil {
+		t.Fatal(err)
+	}
+	return evs
+}
+
+func TestEventLogReplayMatches(t *testing.T) {
+	p := multiParams()
+	p.Items = &gacha.ItemPool{
+		Featured: []gacha.WeightedItem{{ID: "acheron"}},
+		Standard: []gacha.WeightedItem{{ID: "bronya"}, {ID: "welt"}},
+	}
+	evs := logBatch(t, p, 5, 300, 10)
+
+	path := filepath.Join(t.TempDir(), "draws.jsonl")
+	l, err := eventlog.OpenFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := l.Append(ctx, evs[:100]...); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	// reopening resumes the numbering
+	if l, err = eventlog.OpenFileLog(path); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := l.Append(ctx, evs[100:]...); err != nil {
+		t.Fatal(err)
+	}
+
+	params := func(eventlog.Event) (gacha.SimParams, error) { return p, nil }
+	divs, err := eventlog.Replay(ctx, l, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(divs) > 0 {
+		t.Fatalf("faithful log should replay cleanly, first divergence: %v", divs[0])
+	}
+	seq := uint64(0)
+	floors := 0
+	if err := l.Scan(ctx, func(ev eventlog.Event) error {
+		if ev.Seq != seq+1 {
+			t.Fatalf("seq %d after %d", ev.Seq, seq)
+		}
+		seq = ev.Seq
+		if ev.Floor > 0 {
+			floors++
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if seq != 300 || floors == 0 {
+		t.Fatalf("seq=%d floors=%d", seq, floors)
+	}
+}
+
+func TestEventLogReplayReportsDivergence(t *testing.T) {
+	p := gacha.SimParams{PBase: 0.3, Pity: 10, OffProbs: []float64{0.5}}
+	evs := logBatch(t, p, 9, 50, 1)
+	l := eventlog.NewMemoryLog()
+	ctx := context.Background()
+
+	// find a hit that went to the 50/50 and claim the other side won
+	forged := -1
+	for i, ev := range evs[1:] {
+		if ev.Outcome.Hit && ev.OffProbs[0] > 0 {
+			forged = i + 1
+			break
+		}
+	}
+	if forged < 0 {
+		t.Fatal("no 50/50 in the batch")
+	}
+	evs[forged].Outcome.IsUp = !evs[forged].Outcome.IsUp
+	evs[0].Pre[0].Count = 9 // hard pity: the replay consumes no random value
+	if err := l.Append(ctx, evs...); err != nil {
+		t.Fatal(err)
+	}
+
+	divs, err := eventlog.Replay(ctx, l, func(eventlog.Event) (gacha.SimParams, error) { return p, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[uint64][]string)
+	for _, d := range divs {
+		fields[d.Seq] = append(fields[d.Seq], d.Field)
+	}
+	if got := fields[uint64(forged)+1]; len(got) != 1 || got[0] != "outcome" {
+		t.Fatalf("forged 50/50: divergences %v", got)
+	}
+	if got := fields[1]; len(got) == 0 || got[0] != "probs" {
+		t.Fatalf("edited pre-state: divergences %v", got)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("only the two edited events should diverge: %v", divs)
+	}
+}
+
+func TestEventLogResolverVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+version: "2"
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+`)
+	params := eventlog.ResolverParams(game.NewResolver(game.NewLoader(dir)))
+	if _, err := params(eventlog.Event{Game: "g", Version: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := params(eventlog.Event{Game: "g", Version: "1"}); err == nil {
+		t.Fatal("a changed config version should not replay")
+	}
+}
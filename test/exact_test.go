@@ -0,0 +1,66 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestExactMatchesMonteCarlo(t *testing.T) {
+	startPct, target := 0.9, 0.8
+	p := gacha.SimParams{
+		PBase: 0.006, Pity: 90,
+		StartPct: &startPct, TargetProb: &target, Easing: "easeOutQuad",
+		OffProbs: []float64{0.5}, MaxOff: 1,
+	}
+	cases := []struct {
+		goal   gacha.TrialGoal
+		budget *gacha.SimBudget
+	}{
+		{gacha.GoalFirstHit, nil},
+		{gacha.GoalFirstUP, nil},
+		{gacha.GoalFixedBudget, &gacha.SimBudget{NumDraws: 120}},
+	}
+	for _, c := range cases {
+		d, err := gacha.RunExact(p, c.goal, c.budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total := d.CDF[len(d.CDF)-1]; math.Abs(total-1) > 1e-9 {
+			t.Fatalf("%s: PMF sums to %v", c.goal, total)
+		}
+		mc, err := gacha.RunMonteCarlo(p, c.goal, 20000, c.budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 5 standard errors of the Monte Carlo mean
+		tol := 5 * d.StdDev / math.Sqrt(20000)
+		if math.Abs(mc.Mean-d.Mean) > tol {
+			t.Fatalf("%s: exact mean %.3f vs MC %.3f (tol %.3f)", c.goal, d.Mean, mc.Mean, tol)
+		}
+	}
+}
+
+func TestExactHardPityOnly(t *testing.T) {
+	// p=0, pity 10: the first hit is always draw 10.
+	d, err := gacha.RunExact(gacha.SimParams{PBase: 0, Pity: 10}, gacha.GoalFirstHit, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Mean != 10 || d.Var != 0 || d.P99 != 10 || d.PMF[10] != 1 {
+		t.Fatalf("unexpected distribution: %+v", d.Stats)
+	}
+
+	if _, err := gacha.RunExact(gacha.SimParams{PBase: 0.1, Pity: 10, Tiers: []gacha.SimParams{{Rarity: 4, Pity: 10}}}, gacha.GoalFirstHit, nil); err == nil {
+		t.Fatalf("tiers must be rejected")
+	}
+	// the chain only measures the top tier, with or without lower tiers configured
+	if _, err := gacha.RunExact(gacha.SimParams{PBase: 0.1, Pity: 10, TargetRarity: 4}, gacha.GoalFirstHit, nil); !errors.Is(err, gacha.ErrTierConfig) {
+		t.Fatalf("lower target: err = %v", err)
+	}
+	if _, err := gacha.RunExact(gacha.SimParams{PBase: 0.1, Pity: 10, TargetRarity: 5}, gacha.GoalFirstHit, nil); err != nil {
+		t.Fatalf("top target: err = %v", err)
+	}
+}
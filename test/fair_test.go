@@ -0,0 +1,58 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+func TestFairRNGDeterministic(t *testing.T) {
+	a := gacha.NewFairRNG("server", "client", 3)
+	b := gacha.NewFairRNG("server", "client", 3)
+	c := gacha.NewFairRNG("server", "client", 4)
+	same := 0
+	for i := 0; i < 1000; i++ {
+		x, y, z := a.Float64(), b.Float64(), c.Float64()
+		if x != y {
+			t.Fatalf("value %d: same seeds must agree", i)
+		}
+		if x < 0 || x >= 1 {
+			t.Fatalf("value %d out of [0,1): %v", i, x)
+		}
+		if x == z {
+			same++
+		}
+	}
+	if same > 0 {
+		t.Fatalf("another nonce should give another stream, %d values equal", same)
+	}
+	if a.Cursor() != 1000 {
+		t.Fatalf("cursor %d, want 1000", a.Cursor())
+	}
+
+	seed, err := gacha.NewServerSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gacha.CheckCommitment(seed, gacha.Commitment(seed)) || gacha.CheckCommitment(seed+"x", gacha.Commitment(seed)) {
+		t.Fatal("commitment check is wrong")
+	}
+}
+
+func TestStateFairSeedRotation(t *testing.T) {
+	var st state.PlayerState
+	first, err := st.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := st.Commit(); again != first {
+		t.Fatal("commit must be stable until revealed")
+	}
+	if err := st.Reveal(); err != nil {
+		t.Fatal(err)
+	}
+	if st.Fair.ServerSeed == first.ServerSeed || st.Fair.Nonce != first.Nonce+1 {
+		t.Fatalf("reveal should commit a new seed and advance the nonce: %+v", st.Fair)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/fit"
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// fitHistories draws fresh histories of n pulls each under p.
+func fitHistories(t *testing.T, p gacha.SimParams, players, n int) []fit.History {
+	t.Helper()
+	hs := make([]fit.History, players)
+	for i := range hs {
+		ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(uint64(i)+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs[i].Fresh = true
+		for j := 0; j < n; j++ {
+			out, err := ts.Draw()
+			if err != nil {
+				t.Fatal(err)
+			}
+			hs[i].Pulls = append(hs[i].Pulls, fit.Pull{Hit: out.Hit, IsUp: out.IsUp})
+		}
+	}
+	return hs
+}
+
+func within(e fit.Estimate, v float64) bool { return e.Low <= v && v <= e.High }
+
+func TestFitRecoversHiddenCurve(t *testing.T) {
+	start, target := 30, 0.4
+	truth := gacha.SimParams{
+		PBase: 0.02, Pity: 40, StartAt: &start, TargetProb: &target, Easing: string(gacha.EaseLinear),
+		OffProbs: []float64{0.5}, MaxOff: 1,
+	}
+	res, err := fit.Fit(fitHistories(t, truth, 400, 300), fit.Options{PitySpan: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pity.Value != 40 || !res.Soft {
+		t.Fatalf("pity %v soft %v", res.Pity, res.Soft)
+	}
+	if !within(res.PBase, 0.02) || !within(res.StartAt, 30) || !within(res.Target, 0.4) {
+		t.Fatalf("truth outside the intervals: p_base %+v start_at %+v target %+v", res.PBase, res.StartAt, res.Target)
+	}
+	if res.MaxOff != 1 || len(res.OffProbs) == 0 || !within(res.OffProbs[0], 0.5) {
+		t.Fatalf("off layer: max_off %d off_probs %+v", res.MaxOff, res.OffProbs)
+	}
+
+	// the emitted pool loads with the strict parser and the loader
+	text, err := res.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := game.ParseRaw(text); err != nil {
+		t.Fatalf("emitted YAML does not parse strictly: %v\n%s", err, text)
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), "draw:\n  pity: 90\n  p_base: 0.006\n")
+	if err := os.MkdirAll(filepath.Join(dir, "games", "g", "pools"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "games", "g", "pools", "fitted.yaml"), text, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, ep, err := game.NewResolver(game.NewLoader(dir)).Resolve("g", "fitted", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Pity != 40 || ep.StartAt == nil || *ep.StartAt != int(res.StartAt.Value) || ep.Easing != string(res.Easing) {
+		t.Fatalf("resolved fit: %+v", ep)
+	}
+}
+
+func TestFitFlatRateAndCensoring(t *testing.T) {
+	flat := gacha.SimParams{PBase: 0.1, Pity: 20, OffProbs: []float64{0.5}, MaxOff: 1}
+	hs := fitHistories(t, flat, 200, 150)
+	for i := range hs {
+		hs[i].Fresh = false // state before the first hit unknown
+	}
+	res, err := fit.Fit(hs, fit.Options{PitySpan: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Soft || !within(res.PBase, 0.1) {
+		t.Fatalf("flat curve misfit: soft %v p_base %+v", res.Soft, res.PBase)
+	}
+	if _, err := fit.Fit([]fit.History{{Pulls: []fit.Pull{{}, {}}}}, fit.Options{}); !errors.Is(err, fit.ErrNoData) {
+		t.Fatalf("censored-only data should fail, got %v", err)
+	}
+}
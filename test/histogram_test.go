@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestHistogramAndQuantiles(t *testing.T) {
+	startPct, target := 0.8, 0.6
+	p := gacha.SimParams{PBase: 0.01, Pity: 50, StartPct: &startPct, TargetProb: &target, OffProbs: []float64{0.5}, MaxOff: 1}
+	d, err := gacha.RunExact(p, gacha.GoalFirstUP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist := d.Histogram(10)
+	var total float64
+	for i, b := range hist {
+		if b.From != 10*i || b.To < b.From || b.To > b.From+9 {
+			t.Fatalf("bucket %d: %+v", i, b)
+		}
+		total += b.Prob
+		if math.Abs(b.Cum-total) > 1e-9 {
+			t.Fatalf("bucket %d: cum %v, running total %v", i, b.Cum, total)
+		}
+	}
+	// the worst case is hard pity twice over
+	if last := hist[len(hist)-1]; last.To != 150 || math.Abs(last.Cum-1) > 1e-9 {
+		t.Fatalf("last bucket %+v", last)
+	}
+	if d.Quantile(0.5) != d.P50 || d.Quantile(0.99) != d.P99 || d.Quantile(1) != 150 {
+		t.Fatalf("quantiles %v %v %v vs %+v", d.Quantile(0.5), d.Quantile(0.99), d.Quantile(1), d.Stats)
+	}
+	if within := d.CDF[100]; within <= d.CDF[50] || within >= 1 {
+		t.Fatalf("P(UP within 100) = %v", within)
+	}
+
+	st, err := gacha.RunMonteCarloParallel(context.Background(), p, gacha.GoalFirstUP, 20000, nil, gacha.MCOptions{Seed: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := st.Quantiles(0.5, 0.9, 0.25)
+	if qs[0] != st.P50 || qs[1] != st.P90 || qs[2] > qs[0] {
+		t.Fatalf("sample quantiles %v vs %+v", qs, st)
+	}
+	emp := st.Empirical()
+	if math.Abs(emp.CDF[len(emp.CDF)-1]-1) > 1e-9 || emp.P50 != st.P50 {
+		t.Fatalf("empirical distribution: %+v", emp.Stats)
+	}
+	// the sampled CDF tracks the exact one
+	for _, k := range []int{20, 50, 100} {
+		if diff := math.Abs(emp.CDF[k] - d.CDF[k]); diff > 0.02 {
+			t.Fatalf("CDF[%d]: sampled %v, exact %v", k, emp.CDF[k], d.CDF[k])
+		}
+	}
+}
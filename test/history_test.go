@@ -0,0 +1,144 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/history"
+)
+
+func historyResolver(t *testing.T) game.Resolver {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 20
+  p_base: 0.05
+banner:
+  off_probs: [0.5]
+  max_off: 1
+tiers:
+  - rarity: 4
+    p_base: 0.15
+    pity: 5
+    banner:
+      off_probs: [0.5]
+      max_off: 1
+items:
+  5:
+    featured: [{id: furina}]
+    standard: [{id: diluc}, {id: qiqi}]
+  4:
+    featured: [{id: xingqiu}]
+    standard: [{id: sucrose}]
+  3:
+    standard: [{id: slingshot}]
+`)
+	return game.NewResolver(game.NewLoader(dir))
+}
+
+func TestHistoryImportRebuildsState(t *testing.T) {
+	r := historyResolver(t)
+	_, ep, err := r.Resolve("genshin", "char", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), gacha.NewSeededRNG(21))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pres [][]gacha.TierSnapshot
+	ts.Trace = func(tr gacha.PullTrace) { pres = append(pres, tr.Pre) }
+	outs, err := ts.DrawBatch(300, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// export newest first, ten-pulls sharing a timestamp, plus a banner we don't map
+	var csv strings.Builder
+	csv.WriteString("id,uid,gacha_type,item_type,name,rank_type,time\n")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := len(outs) - 1; i >= 0; i-- {
+		at := start.Add(time.Duration(i/10) * time.Minute).Format("2006-01-02 15:04:05")
+		fmt.Fprintf(&csv, "%d,1,301,Character,%s,%d,%s\n", 1000+i, outs[i].Item, outs[i].Rarity, at)
+	}
+	csv.WriteString("9999,1,200,Weapon,slingshot,3,2024-01-01 00:00:00\n")
+	recs, err := history.ReadCSV(strings.NewReader(csv.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pulls, rep, err := history.Import(r, recs, history.Options{Player: "p1", Game: "genshin", Pools: map[string]string{"301": "char"}, Fresh: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Imported != len(outs) || rep.Skipped["200"] != 1 || rep.Inconsistent != 0 || len(rep.Unmapped) != 0 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	for i, p := range pulls {
+		if p.Item != outs[i].Item || p.IsUp != outs[i].IsUp {
+			t.Fatalf("pull %d: imported %s up=%v, drawn %s up=%v", i, p.Item, p.IsUp, outs[i].Item, outs[i].IsUp)
+		}
+		if !reflect.DeepEqual(p.Pre, pres[i]) {
+			t.Fatalf("pull %d: rebuilt %+v, engine had %+v", i, p.Pre, pres[i])
+		}
+	}
+
+	// normalized pulls survive a round trip
+	var buf bytes.Buffer
+	if err := history.WriteJSONL(&buf, pulls); err != nil {
+		t.Fatal(err)
+	}
+	back, err := history.ReadJSONL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, pulls) {
+		t.Fatal("JSONL round trip changed the pulls")
+	}
+	hs := history.FitHistories(pulls, 5)
+	if len(hs) != 1 || !hs[0].Fresh || len(hs[0].Pulls) != len(outs) {
+		t.Fatalf("fit histories: %d", len(hs))
+	}
+	if obs := history.Observations(pulls, 5); len(obs) != len(outs) {
+		t.Fatalf("observations: %d, want %d", len(obs), len(outs))
+	}
+}
+
+func TestHistoryImportJSONAndInconsistencies(t *testing.T) {
+	doc := `{"list": [
+		{"time": 1704067200, "gacha_type": "301", "name": "diluc", "rank_type": "5"},
+		{"time": 1704067260, "gacha_type": "301", "name": "qiqi", "rank_type": "5"},
+		{"time": 1704067320, "gacha_type": "301", "name": "qiqi", "rank_type": 5},
+		{"time": 1704067380, "gacha_type": "301", "name": "mystery", "rank_type": 4}
+	]}`
+	recs, err := history.ReadJSON(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recs[0].Time != time.Unix(1704067200, 0).UTC() || recs[2].Rarity != 5 {
+		t.Fatalf("unexpected record: %+v", recs[0])
+	}
+	pulls, rep, err := history.Import(historyResolver(t), recs, history.Options{Game: "genshin", Pools: map[string]string{"301": "char"}, Fresh: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// max_off 1: the second consecutive off sets the guarantee, so the third is impossible
+	if rep.Inconsistent != 1 || rep.Unmapped["mystery"] != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if !pulls[2].Pre[0].GuaranteedNext {
+		t.Fatalf("guarantee not rebuilt: %+v", pulls[2].Pre[0])
+	}
+
+	if _, err := history.ReadCSV(strings.NewReader("name,rank_type\nfurina,5\n")); err == nil {
+		t.Fatal("a CSV without time should fail")
+	}
+}
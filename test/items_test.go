@@ -0,0 +1,81 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func TestItemPoolPick(t *testing.T) {
+	pool := &gacha.ItemPool{
+		Featured: []gacha.WeightedItem{{ID: "furina"}},
+		Standard: []gacha.WeightedItem{{ID: "diluc", Weight: 3}, {ID: "jean", Weight: 1}},
+	}
+	rng := gacha.NewSeededRNG(3)
+	if got := pool.Pick(true, rng); got != "furina" {
+		t.Fatalf("UP pick=%q", got)
+	}
+	counts := map[string]int{}
+	for i := 0; i < 40000; i++ {
+		counts[pool.Pick(false, rng)]++
+	}
+	if r := float64(counts["diluc"]) / float64(counts["jean"]); r < 2.8 || r > 3.2 {
+		t.Fatalf("weights not respected: %v", counts)
+	}
+	if got := (*gacha.ItemPool)(nil).Pick(true, rng); got != "" {
+		t.Fatalf("nil pool should pick nothing, got %q", got)
+	}
+}
+
+func TestDrawReturnsItemsFromRoster(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+tiers:
+  - rarity: 4
+    p_base: 0.051
+    pity: 10
+    banner:
+      off_probs: [0.5]
+items:
+  5:
+    featured: [{id: furina}]
+    standard: [{id: diluc}, {id: jean}]
+  4:
+    featured: [{id: xingqiu}, {id: bennett}]
+    standard: [{id: sucrose}]
+  3:
+    standard: [{id: slingshot}]
+`)
+	_, ep, err := game.NewResolver(game.NewLoader(dir)).Resolve("genshin", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), gacha.NewSeededRNG(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := map[int]map[string]bool{
+		5: {"furina": true, "diluc": true, "jean": true},
+		4: {"xingqiu": true, "bennett": true, "sucrose": true},
+		3: {"slingshot": true},
+	}
+	for i := 0; i < 500; i++ {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !valid[out.Rarity][out.Item] {
+			t.Fatalf("draw %d: item %q not in %d★ roster", i, out.Item, out.Rarity)
+		}
+		if out.Rarity == 5 && out.IsUp != (out.Item == "furina") {
+			t.Fatalf("UP flag and item disagree: %+v", out)
+		}
+	}
+}
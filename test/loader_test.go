@@ -0,0 +1,59 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func TestPathsDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), "version: 1\n")
+	writeFile(t, filepath.Join(dir, "games", "hsr.yaml"), "version: 2\n")
+	writeFile(t, filepath.Join(dir, "games", "hsr", "pools", "char.yaml"), "version: 3\n")
+	writeFile(t, filepath.Join(dir, "games", "hsr", "pools", "cone.yaml"), "version: 3\n")
+	writeFile(t, filepath.Join(dir, "games", "fgo", "pools", "fp.yaml"), "version: 3\n")
+
+	paths := game.Paths{BaseDir: dir}
+	games, err := paths.Games()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(games, []string{"fgo", "hsr"}) {
+		t.Fatalf("games=%v", games)
+	}
+	pools, err := paths.Pools("hsr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pools, []string{"char", "cone"}) {
+		t.Fatalf("pools=%v", pools)
+	}
+}
+
+func TestValidateRawListsEveryError(t *testing.T) {
+	cfg, err := game.ParseRaw([]byte(`
+draw:
+  pity: 0
+  p_base: 2
+banner:
+  off_probs: [0.5, 1.5]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verr *game.ValidationError
+	if err := game.ValidateRaw(cfg); !errors.As(err, &verr) {
+		t.Fatalf("want *ValidationError, got %v", err)
+	}
+	if len(verr.Errors) != 3 {
+		t.Fatalf("want 3 separate errors, got %q", verr.Errors)
+	}
+
+	if _, err := game.ParseRaw([]byte("draw:\n  pitty: 90\n")); err == nil {
+		t.Fatalf("unknown keys must be rejected")
+	}
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/history"
+	"github.com/xtding233/gacha-backend/internal/luck"
+)
+
+func TestWaitFromStartState(t *testing.T) {
+	target := 0.4
+	start := 14
+	p := gacha.SimParams{PBase: 0.05, Pity: 20, StartAt: &start, TargetProb: &target, OffProbs: []float64{0.5}, MaxOff: 1}
+	ctx := context.Background()
+
+	// one draw short of hard pity, the next draw always hits
+	d, exact, err := gacha.WaitFrom(ctx, p, gacha.GoalFirstHit, []gacha.TierSnapshot{{Rarity: 5, Count: 19}}, 0, gacha.MCOptions{})
+	if err != nil || !exact {
+		t.Fatalf("exact=%v err=%v", exact, err)
+	}
+	if better, worse := d.Rank(1); d.Mean != 1 || better != 0 || worse != 0 {
+		t.Fatalf("mean %v, rank %v/%v", d.Mean, better, worse)
+	}
+
+	// a guarantee turns the UP wait into the hit wait
+	pre := []gacha.TierSnapshot{{Rarity: 5, Count: 5, GuaranteedNext: true}}
+	up, _, err := gacha.WaitFrom(ctx, p, gacha.GoalFirstUP, pre, 0, gacha.MCOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, _, err := gacha.WaitFrom(ctx, p, gacha.GoalFirstHit, pre, 0, gacha.MCOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(up.Mean-hit.Mean) > 1e-9 {
+		t.Fatalf("guaranteed UP wait %v, hit wait %v", up.Mean, hit.Mean)
+	}
+
+	// sampling the tiered system agrees with the chain
+	p.Tiers = []gacha.SimParams{{Rarity: 4, PBase: 0.1, Pity: 10}}
+	p.TargetRarity = 4
+	if _, exact, _ := gacha.WaitFrom(ctx, p, gacha.GoalFirstUP, nil, 100, gacha.MCOptions{Seed: 1}); exact {
+		t.Fatal("lower tiers cannot be solved exactly")
+	}
+	p.Tiers, p.TargetRarity = nil, 0
+	pre = []gacha.TierSnapshot{{Rarity: 5, Count: 8}}
+	ex, _, err := gacha.WaitFrom(ctx, p, gacha.GoalFirstUP, pre, 0, gacha.MCOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Path = &gacha.PathParams{Featured: 1, Target: -1}
+	mc, exact, err := gacha.WaitFrom(ctx, p, gacha.GoalFirstUP, pre, 40000, gacha.MCOptions{Seed: 3})
+	if err != nil || exact {
+		t.Fatalf("exact=%v err=%v", exact, err)
+	}
+	if math.Abs(mc.Mean-ex.Mean) > 4*ex.StdDev/math.Sqrt(40000) {
+		t.Fatalf("sampled mean %v, exact %v", mc.Mean, ex.Mean)
+	}
+
+	// the sum of two point masses is a point mass
+	one := gacha.Sum(d, d)
+	if one.Mean != 2 || one.StdDev != 0 {
+		t.Fatalf("sum: %+v", one.Stats)
+	}
+}
+
+func TestLuckRanksHistories(t *testing.T) {
+	r := historyResolver(t)
+	_, ep, err := r.Resolve("genshin", "char", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []history.Record
+	for player := 0; player < 40; player++ {
+		ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), gacha.NewSeededRNG(uint64(100+player)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		outs, err := ts.DrawBatch(200, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recs = recs[:0]
+		for _, o := range outs {
+			recs = append(recs, history.Record{Banner: "char", Item: o.Item, Rarity: o.Rarity})
+		}
+		pulls, _, err := history.Import(r, recs, history.Options{Game: "genshin", Pools: map[string]string{"char": "char"}, Fresh: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep, err := luck.Rank(context.Background(), r, pulls, luck.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rep.Completed == 0 || rep.Luck <= 0 || rep.Luck >= 1 {
+			t.Fatalf("player %d: %+v", player, rep)
+		}
+		draws := 0
+		for _, w := range rep.Waits {
+			if !w.Exact || w.Better+w.Worse > 1+1e-9 {
+				t.Fatalf("player %d: wait %+v", player, w)
+			}
+			draws += w.Draws
+		}
+		if draws != len(outs) || rep.Waits[len(rep.Waits)-1].End != len(outs)-1 {
+			t.Fatalf("player %d: waits cover %d of %d pulls", player, draws, len(outs))
+		}
+		if player > 0 {
+			continue
+		}
+		// 4★ waits are sampled; a budget of one trial leaves one per distinct start
+		rep, err = luck.Rank(context.Background(), r, pulls, luck.Options{Rarity: 4, Goal: gacha.GoalFirstHit, Budget: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range rep.Waits {
+			if w.Exact || w.Better+w.Worse != 1 && w.Better+w.Worse != 0 {
+				t.Fatalf("4★ wait from one trial: %+v", w)
+			}
+		}
+	}
+
+	// an UP on the very first pull beats almost everyone; one at hard pity
+	// after losing the 50/50 twice (max_off 1) is the worst case
+	pull := func(item string, rarity int) history.Record {
+		return history.Record{Banner: "char", Item: item, Rarity: rarity}
+	}
+	recs = []history.Record{pull("furina", 5)}
+	for i := 0; i < 19; i++ {
+		recs = append(recs, pull("slingshot", 3))
+	}
+	for _, off := range []string{"diluc", "qiqi"} {
+		recs = append(recs, pull(off, 5))
+		for i := 0; i < 19; i++ {
+			recs = append(recs, pull("slingshot", 3))
+		}
+	}
+	recs = append(recs, pull("furina", 5))
+	pulls, _, err := history.Import(r, recs, history.Options{Game: "genshin", Pools: map[string]string{"char": "char"}, Fresh: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := luck.Rank(context.Background(), r, pulls, luck.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Waits) != 2 || rep.Waits[0].Draws != 1 || rep.Waits[1].Draws != 60 {
+		t.Fatalf("waits: %+v", rep.Waits)
+	}
+	if rep.Waits[0].Better < 0.95 || rep.Waits[1].Better != 0 || rep.Waits[1].Luck > 0.01 {
+		t.Fatalf("ranks: %+v", rep.Waits)
+	}
+	if rep.Draws != 61 || math.Abs(rep.Expected-2*rep.Waits[0].Mean) > 1e-9 {
+		t.Fatalf("totals: %+v", rep)
+	}
+
+	// without an exact start the wait before the first UP is dropped
+	for i := range pulls {
+		pulls[i].Exact = false
+	}
+	if rep, err = luck.Rank(context.Background(), r, pulls, luck.Options{}); err != nil || len(rep.Waits) != 1 {
+		t.Fatalf("waits %+v, err %v", rep.Waits, err)
+	}
+}
+
+func TestLuckNeedsRoster(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 20
+  p_base: 0.05
+banner:
+  off_probs: [0.5]
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	pulls := []history.Pull{{Game: "g", Pool: "p", Family: "p", Rarity: 5, Exact: true, Pre: []gacha.TierSnapshot{{Rarity: 5}}}}
+	if _, err := luck.Rank(context.Background(), r, pulls, luck.Options{}); !errors.Is(err, luck.ErrNoRoster) {
+		t.Fatalf("err = %v", err)
+	}
+	rep, err := luck.Rank(context.Background(), r, pulls, luck.Options{Goal: gacha.GoalFirstHit})
+	if err != nil || rep.Completed != 1 {
+		t.Fatalf("rep %+v, err %v", rep, err)
+	}
+}
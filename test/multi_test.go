@@ -0,0 +1,113 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+// multiParams has no effective 4★ pity, so only the multi floor guarantees 4★.
+func multiParams() gacha.SimParams {
+	return gacha.SimParams{
+		PBase: 0.006, Pity: 90, OffProbs: []float64{0.5},
+		Tiers: []gacha.SimParams{{Rarity: 4, PBase: 0.051, Pity: 1000}},
+		Multi: &gacha.MultiParams{Size: 10, MinRarity: 4},
+	}
+}
+
+func TestDrawMultiGuaranteesFloor(t *testing.T) {
+	ts, err := gacha.NewTieredFromParams(multiParams(), gacha.NewSeededRNG(21))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fives, pulls := 0, 0
+	for m := 0; m < 5000; m++ {
+		outs, err := ts.DrawMulti(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := 0
+		for _, o := range outs {
+			best = max(best, o.Rarity)
+			if o.Rarity == 5 {
+				fives++
+			}
+			pulls++
+		}
+		if best < 4 {
+			t.Fatalf("multi %d has no 4★ or better: %+v", m, outs)
+		}
+	}
+	// the floor only upgrades 3★ results, so the 5★ rate stays near its base+pity rate
+	if rate := float64(fives) / float64(pulls); rate < 0.010 || rate > 0.022 {
+		t.Fatalf("5★ rate %.4f", rate)
+	}
+
+	ts.MultiFloor = 2
+	if _, err := ts.DrawMulti(10); !errors.Is(err, gacha.ErrTierConfig) {
+		t.Fatalf("a floor without a tier should fail, got %v", err)
+	}
+}
+
+func TestMonteCarloPullsInMultis(t *testing.T) {
+	p := multiParams()
+	p.TargetRarity = 4
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstHit, 2000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only a multi whose floor was met by a 5★ can lack a 4★
+	first := 0
+	for _, v := range st.Samples {
+		if v == 10 {
+			first++
+		}
+	}
+	if frac := float64(first) / float64(len(st.Samples)); frac < 0.9 {
+		t.Fatalf("only %.3f of first 4★ land in the first multi", frac)
+	}
+	p.TargetRarity = 0
+	st, err = gacha.RunMonteCarlo(p, gacha.GoalFirstUP, 2000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range st.Samples {
+		if v%10 != 0 {
+			t.Fatalf("draws should be whole multis, got %d", v)
+		}
+	}
+}
+
+func TestMultiFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+tiers:
+  - rarity: 4
+    p_base: 0.051
+    pity: 10
+multi:
+  min_rarity: 4
+`)
+	writeFile(t, filepath.Join(dir, "games", "g", "pools", "bad.yaml"), `
+multi:
+  min_rarity: 3
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	_, ep, err := r.Resolve("g", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp := game.ToSimParams(ep).Multi; sp == nil || *sp != (gacha.MultiParams{Size: 10, MinRarity: 4}) {
+		t.Fatalf("unexpected multi: %+v", sp)
+	}
+	var verr *game.ValidationError
+	if _, _, err := r.Resolve("g", "bad", game.Overrides{}); !errors.As(err, &verr) {
+		t.Fatalf("base rarity floor should fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestParallelMonteCarloDeterministic(t *testing.T) {
+	startPct, target := 0.9, 0.8
+	p := gacha.SimParams{
+		PBase: 0.006, Pity: 90,
+		StartPct: &startPct, TargetProb: &target,
+		OffProbs: []float64{0.5},
+	}
+	ctx := context.Background()
+	// 5000 trials span several blocks with a partial last block.
+	base, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 5000, nil, gacha.MCOptions{Seed: 42, Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []int{2, 3, 8} {
+		st, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 5000, nil, gacha.MCOptions{Seed: 42, Workers: w})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(st, base) {
+			t.Fatalf("workers=%d changed the result: %+v vs %+v", w, st.Mean, base.Mean)
+		}
+	}
+	other, err := gacha.RunMonteCarloParallel(ctx, p, gacha.GoalFirstUP, 5000, nil, gacha.MCOptions{Seed: 43})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(other.Samples, base.Samples) {
+		t.Fatalf("different seeds should give different samples")
+	}
+}
+
+func TestParallelMonteCarloCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := gacha.RunMonteCarloParallel(ctx, gacha.SimParams{PBase: 0.01, Pity: 90}, gacha.GoalFirstHit, 100000, nil, gacha.MCOptions{Seed: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func TestPathFatePointGuarantee(t *testing.T) {
+	// pity 1: every draw hits, so every draw resolves the path
+	soft, err := gacha.NewSoftPitySystem(1, nil, gacha.NewSeededRNG(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := gacha.NewPathSystem(gacha.NewBannerSystem(soft, []float64{0.25}, 1), 2, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missed := false
+	for i := 0; i < 2000; i++ {
+		out, err := ps.Draw(0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if missed && !out.OnTarget {
+			t.Fatalf("draw %d: a full fate point must give the target", i)
+		}
+		if out.OnTarget != (out.Featured == 1) {
+			t.Fatalf("draw %d: OnTarget and Featured disagree: %+v", i, out)
+		}
+		missed = !out.OnTarget
+		want := 1
+		if out.OnTarget {
+			want = 0
+		}
+		if out.FatePoints != want {
+			t.Fatalf("draw %d: fate points %d, want %d", i, out.FatePoints, want)
+		}
+	}
+
+	if _, err := gacha.NewPathSystem(gacha.NewBannerSystem(soft, nil, 0), 2, 2, 1); !errors.Is(err, gacha.ErrPathConfig) {
+		t.Fatalf("target out of range should fail, got %v", err)
+	}
+}
+
+func TestPathMonteCarlo(t *testing.T) {
+	// every draw hits: target first with 0.75 * 1/2, otherwise on the second draw
+	p := gacha.SimParams{
+		PBase: 0.5, Pity: 1,
+		OffProbs: []float64{0.25}, MaxOff: 1,
+		Path: &gacha.PathParams{Featured: 2, MaxFate: 1},
+	}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstUP, 20000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1.625; math.Abs(st.Mean-want) > 0.02 {
+		t.Fatalf("mean %.3f, want %.3f", st.Mean, want)
+	}
+	if st.P99 > 2 {
+		t.Fatalf("target must arrive within 2 hits, P99=%v", st.P99)
+	}
+	if _, err := gacha.RunExact(p, gacha.GoalFirstUP, nil); !errors.Is(err, gacha.ErrExactUnsupported) {
+		t.Fatalf("exact solver should reject paths, got %v", err)
+	}
+}
+
+func TestPathFromPoolYAML(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 80
+  p_base: 0.007
+banner:
+  off_probs: [0.25]
+  max_off: 1
+`)
+	writeFile(t, filepath.Join(dir, "games", "genshin", "pools", "weapon.yaml"), `
+banner:
+  path:
+    target: jade_cutter
+items:
+  5:
+    featured: [{id: mistsplitter}, {id: jade_cutter}]
+    standard: [{id: wolfs_gravestone}]
+`)
+	writeFile(t, filepath.Join(dir, "games", "genshin", "pools", "bad.yaml"), `
+banner:
+  path:
+    target: nope
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	_, ep, err := r.Resolve("genshin", "weapon", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Path == nil || *ep.Path != (game.PathParams{Featured: 2, Target: 1, MaxFate: 1}) {
+		t.Fatalf("unexpected path: %+v", ep.Path)
+	}
+	ts, err := gacha.NewTieredFromParams(game.ToSimParams(ep), gacha.NewSeededRNG(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2000; i++ {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Hit && out.OnTarget != (out.Item == "jade_cutter") {
+			t.Fatalf("target flag and item disagree: %+v", out)
+		}
+	}
+
+	var verr *game.ValidationError
+	if _, _, err := r.Resolve("genshin", "bad", game.Overrides{}); !errors.As(err, &verr) {
+		t.Fatalf("unknown target should fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func TestStreakPolicyMatchesDefault(t *testing.T) {
+	run := func(policy gacha.OffPolicy) []gacha.BannerOutcome {
+		soft, err := gacha.NewSoftPitySystem(10, nil, gacha.NewSeededRNG(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := gacha.NewBannerSystem(soft, []float64{0.5, 0.3}, 2)
+		b.Policy = policy
+		var outs []gacha.BannerOutcome
+		for i := 0; i < 500; i++ {
+			out, err := b.Draw(0.1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outs = append(outs, out)
+		}
+		return outs
+	}
+	if !reflect.DeepEqual(run(nil), run(gacha.StreakPolicy{OffProbs: []float64{0.5, 0.3}})) {
+		t.Fatalf("explicit StreakPolicy should match the default behavior")
+	}
+}
+
+func TestRadiancePolicyForcesWin(t *testing.T) {
+	// pity 1: every draw hits
+	soft, err := gacha.NewSoftPitySystem(1, nil, gacha.NewSeededRNG(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := gacha.NewBannerSystem(soft, []float64{0.5}, 1)
+	rp := &gacha.RadiancePolicy{Base: 0.5, Threshold: 2}
+	b.Policy = rp
+	triggered := 0
+	for i := 0; i < 5000; i++ {
+		guaranteed, counter := b.GuaranteedNext, rp.Counter
+		out, err := b.Draw(0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if guaranteed {
+			if rp.Counter != counter {
+				t.Fatalf("draw %d: guaranteed hits must not move the counter", i)
+			}
+			continue
+		}
+		if counter >= rp.Threshold {
+			triggered++
+			if !out.IsUp || rp.Counter != 0 {
+				t.Fatalf("draw %d: counter %d should force a win and reset, got %+v counter=%d", i, counter, out, rp.Counter)
+			}
+		}
+	}
+	if triggered == 0 {
+		t.Fatalf("radiance never triggered")
+	}
+}
+
+func TestRadianceFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+  max_off: 1
+  off_policy:
+    kind: radiance
+    threshold: 1
+`)
+	writeFile(t, filepath.Join(dir, "games", "genshin", "pools", "bad.yaml"), `
+banner:
+  off_policy:
+    kind: coinflip
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	_, ep, err := r.Resolve("genshin", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	radiance := game.ToSimParams(ep)
+	if radiance.OffPolicy != "radiance" || radiance.RadianceThreshold != 1 {
+		t.Fatalf("policy not resolved: %q/%d", radiance.OffPolicy, radiance.RadianceThreshold)
+	}
+	streak := radiance
+	streak.OffPolicy = ""
+
+	// a forced win after every lost 50/50 yields more UPs per budget
+	budget := &gacha.SimBudget{NumDraws: 500}
+	a, err := gacha.RunMonteCarlo(radiance, gacha.GoalFixedBudget, 1000, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gacha.RunMonteCarlo(streak, gacha.GoalFixedBudget, 1000, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Mean <= b.Mean {
+		t.Fatalf("radiance UPs %.3f should exceed streak UPs %.3f", a.Mean, b.Mean)
+	}
+	if _, err := gacha.RunExact(radiance, gacha.GoalFirstUP, nil); !errors.Is(err, gacha.ErrExactUnsupported) {
+		t.Fatalf("exact solver should reject radiance, got %v", err)
+	}
+
+	var verr *game.ValidationError
+	if _, _, err := r.Resolve("genshin", "bad", game.Overrides{}); !errors.As(err, &verr) {
+		t.Fatalf("unknown policy should fail validation, got %v", err)
+	}
+}
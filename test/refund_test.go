@@ -0,0 +1,122 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/pricing"
+	"github.com/xtding233/gacha-backend/internal/token"
+)
+
+func TestWalletRefundsDuplicates(t *testing.T) {
+	w, err := gacha.NewWallet(map[int][]int{5: {10, 10, 25}, 4: {2}}, 5, map[string]int{"diluc": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		rarity int
+		item   string
+		want   int
+	}{
+		{5, "furina", 0},  // first copy
+		{5, "furina", 10}, // 1st duplicate
+		{5, "diluc", 10},  // owned before the trial
+		{5, "furina", 10},
+		{5, "furina", 25},
+		{5, "furina", 25}, // last value repeats
+		{4, "bennett", 0},
+		{4, "bennett", 2},
+		{3, "slingshot", 0},
+		{3, "slingshot", 0}, // no refund configured for 3★
+		{5, "", 0},          // unnamed items cannot be duplicates
+	}
+	for i, s := range steps {
+		if got := w.Record(s.rarity, s.item); got != s.want {
+			t.Fatalf("step %d: refund %d, want %d", i, got, s.want)
+		}
+	}
+	if w.Balance != 82 {
+		t.Fatalf("balance %d, want 82", w.Balance)
+	}
+	bought := 0
+	for w.BuyPull() {
+		bought++
+	}
+	if bought != 16 || w.Balance != 2 {
+		t.Fatalf("bought %d draws, balance %d", bought, w.Balance)
+	}
+	if _, err := gacha.NewWallet(map[int][]int{5: {-1}}, 5, nil); err == nil {
+		t.Fatalf("negative refunds must be rejected")
+	}
+}
+
+func TestReconvertLowersPaidDraws(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+  soft:
+    mode: target_ramp
+    start_pct: 0.82
+    target: 0.8
+banner:
+  off_probs: [0.5]
+tiers:
+  - rarity: 4
+    p_base: 0.051
+    pity: 10
+    banner:
+      off_probs: [0.5]
+items:
+  5:
+    featured: [{id: furina}]
+    standard: [{id: diluc}, {id: jean}]
+  4:
+    featured: [{id: xingqiu}, {id: bennett}, {id: xiangling}]
+    standard: [{id: sucrose}]
+  3:
+    standard: [{id: slingshot}]
+refunds:
+  currency: starglitter
+  per_draw: 5
+  amounts:
+    5: [10, 10, 10, 10, 10, 10, 25]
+    4: [2, 2, 2, 2, 2, 2, 5]
+`)
+	_, ep, err := game.NewResolver(game.NewLoader(dir)).Resolve("genshin", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := game.ToSimParams(ep)
+	if sim.Refund == nil || sim.Refund.PerDraw != 5 || len(sim.Refund.Amounts[4]) != 7 {
+		t.Fatalf("refunds not resolved: %+v", sim.Refund)
+	}
+	budget := &gacha.SimBudget{Copies: 7}
+	nominal, err := gacha.RunMonteCarlo(sim, gacha.GoalNthUP, 1000, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Refund.Reconvert = true
+	paid, err := gacha.RunMonteCarlo(sim, gacha.GoalNthUP, 1000, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4★ duplicates alone refund roughly 2 per 10 draws, i.e. a few percent of all draws
+	if ratio := paid.Mean / nominal.Mean; ratio > 0.97 || ratio < 0.8 {
+		t.Fatalf("paid/nominal draws %.3f (%.1f vs %.1f)", ratio, paid.Mean, nominal.Mean)
+	}
+
+	tok := token.Token{PerDraw: 160, PerTenDraw: 1600}
+	if got := tok.TokensForDraws(25); got != 2*1600+5*160 {
+		t.Fatalf("TokensForDraws(25)=%d", got)
+	}
+	cat := pricing.Catalog{Currency: "USD", Packs: []pricing.Pack{{ID: "6480", Tokens: 6480, BonusTokens: 1600, PriceCents: 9999}}}
+	full := pricing.CostOfDraws(cat, tok, int(nominal.Mean), nil)
+	eff := pricing.CostOfDraws(cat, tok, int(paid.Mean), nil)
+	if eff.TotalCents > full.TotalCents || full.TotalTokens < 160*int(nominal.Mean) {
+		t.Fatalf("effective plan %d¢ should not exceed nominal %d¢", eff.TotalCents, full.TotalCents)
+	}
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func writeFile(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolverSoftLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+  soft:
+    mode: target_ramp
+    start_at: 70
+    target: 0.5
+    easing: easeOutQuad
+`)
+	writeFile(t, filepath.Join(dir, "games", "pct.yaml"), `
+draw:
+  soft:
+    start_pct: 0.5
+`)
+	writeFile(t, filepath.Join(dir, "games", "inc.yaml"), `
+draw:
+  soft:
+    mode: per_draw_increment
+    start_at: 60
+    increment: 0.05
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+
+	// a later start_pct replaces an inherited start_at
+	_, ep, err := r.Resolve("pct", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.StartAt == nil || *ep.StartAt != 45 || ep.Target == nil || *ep.Target != 0.5 {
+		t.Fatalf("start_pct over start_at: %+v", ep)
+	}
+
+	// switching mode drops the parent curve's target and easing
+	_, ep, err = r.Resolve("inc", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.SoftMode != "per_draw_increment" || ep.Target != nil || ep.Easing != "" || *ep.StartAt != 60 || *ep.Increment != 0.05 {
+		t.Fatalf("mode switch kept the parent curve: %+v", ep)
+	}
+}
+
+func TestResolverMergeOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+version: 1
+draw:
+  pity: 90
+  p_base: 0.006
+  soft:
+    mode: target_ramp
+    start_pct: 0.9
+    target: 0.8
+banner:
+  off_probs: [0.5]
+`)
+	writeFile(t, filepath.Join(dir, "games", "ark.yaml"), `
+version: 2
+draw:
+  pity: 99
+  p_base: 0.02
+  soft:
+    mode: per_draw_increment
+    start_at: 50
+    increment: 0.02
+`)
+	writeFile(t, filepath.Join(dir, "games", "ark", "pools", "limited.yaml"), `
+version: 3
+banner:
+  off_probs: [0.3, 0.2]
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+
+	cushion := 10
+	_, ep, err := r.Resolve("ark", "limited", game.Overrides{Cushion: &cushion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.PBase != 0.02 || ep.Pity != 99 || ep.SoftMode != "per_draw_increment" {
+		t.Fatalf("game file should override default: %+v", ep)
+	}
+	if ep.StartAt == nil || *ep.StartAt != 50 || ep.Increment == nil || *ep.Increment != 0.02 {
+		t.Fatalf("soft fields not merged: %+v", ep)
+	}
+	if len(ep.OffProbs) != 2 || ep.MaxOff != 2 {
+		t.Fatalf("pool banner should override and default max_off: %+v", ep)
+	}
+	if ep.Version != "3" || ep.Cushion != 10 {
+		t.Fatalf("version/cushion: %+v", ep)
+	}
+
+	sp := game.ToSimParams(ep)
+	if sp.Increment == nil || sp.TargetProb != nil || sp.SoftMode != "per_draw_increment" {
+		t.Fatalf("sim params should only carry increment fields: %+v", sp)
+	}
+
+	// default-only game: start_pct resolves into start_at and easing defaults to linear
+	_, ep, err = r.Resolve("other", "", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.StartAt == nil || *ep.StartAt != 81 || ep.Easing != "linear" {
+		t.Fatalf("start_pct/easing defaults: %+v", ep)
+	}
+
+	bad := 1.5
+	if _, _, err := r.Resolve("ark", "limited", game.Overrides{PBase: &bad}); err == nil {
+		t.Fatalf("invalid p_base override must fail validation")
+	}
+
+	// ids never reach outside the config tree
+	for _, ref := range [][2]string{{"..", ""}, {".", "limited"}, {"ark", "../ark"}, {"ark/pools", "limited"}, {"ark", `..\x`}} {
+		if _, _, err := r.Resolve(ref[0], ref[1], game.Overrides{}); !errors.Is(err, game.ErrInvalidID) {
+			t.Fatalf("%q/%q: err = %v", ref[0], ref[1], err)
+		}
+	}
+
+	// a config file that exists but does not parse is an error, not defaults
+	writeFile(t, filepath.Join(dir, "games", "ark", "pools", "broken.yaml"), "banner: [\n")
+	if _, _, err := r.Resolve("ark", "broken", game.Overrides{}); err == nil {
+		t.Fatal("malformed pool file must fail")
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+func TestTieredSelect(t *testing.T) {
+	featured := []gacha.WeightedItem{{ID: "acheron"}, {ID: "kafka"}, {ID: "blade"}}
+	p := gacha.SimParams{
+		PBase: 0.5, Pity: 90, OffProbs: []float64{0.5},
+		Items: &gacha.ItemPool{Featured: featured, Standard: []gacha.WeightedItem{{ID: "bronya"}}},
+	}
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Select(5, "kafka"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 500; i++ {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.IsUp && out.Item != "kafka" {
+			t.Fatalf("UP should be the selection, got %q", out.Item)
+		}
+	}
+	if err := ts.Select(5, "bronya"); !errors.Is(err, gacha.ErrSelection) {
+		t.Fatalf("standard items are not selectable, got %v", err)
+	}
+
+	// with an epitomized path the selection charts the path
+	p.Path = &gacha.PathParams{Featured: 3}
+	ts, err = gacha.NewTieredFromParams(p, gacha.NewSeededRNG(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Select(5, "blade"); err != nil {
+		t.Fatal(err)
+	}
+	if ts.Tiers[0].Path.Target != 2 || len(ts.Tiers[0].Items.Featured) != 3 {
+		t.Fatalf("path not charted: target=%d", ts.Tiers[0].Path.Target)
+	}
+}
+
+func TestStateSelectSwitchRules(t *testing.T) {
+	base := state.PlayerState{Tiers: map[int]state.TierState{5: {Count: 50, OffStreak: 1, GuaranteedNext: true, FatePoints: 1}}}
+	cases := []struct {
+		keepPity, keepFate bool
+		want               state.TierState
+	}{
+		{true, false, state.TierState{Count: 50}},
+		{false, true, state.TierState{OffStreak: 1, GuaranteedNext: true, FatePoints: 1}},
+		{true, true, base.Tiers[5]},
+	}
+	for _, c := range cases {
+		st := state.PlayerState{Tiers: map[int]state.TierState{5: base.Tiers[5]}}
+		if st.Select(5, "kafka", c.keepPity, c.keepFate) {
+			t.Fatalf("a first choice is not a switch")
+		}
+		if st.Select(5, "kafka", c.keepPity, c.keepFate) {
+			t.Fatalf("re-selecting the same item is not a switch")
+		}
+		if !reflect.DeepEqual(st.Tiers[5], base.Tiers[5]) {
+			t.Fatalf("no switch must keep state: %+v", st.Tiers[5])
+		}
+		if !st.Select(5, "acheron", c.keepPity, c.keepFate) {
+			t.Fatalf("changing the item is a switch")
+		}
+		if st.Tiers[5] != c.want || st.Selected[5] != "acheron" {
+			t.Fatalf("keepPity=%v keepFate=%v: got %+v", c.keepPity, c.keepFate, st.Tiers[5])
+		}
+	}
+
+	st := state.PlayerState{Pool: "a", Selected: map[int]string{5: "kafka"}}
+	st.Rotate("b", true, true, false)
+	if len(st.Selected) != 0 {
+		t.Fatalf("rotation must clear selections: %v", st.Selected)
+	}
+}
+
+func TestSelectorFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+`)
+	writeFile(t, filepath.Join(dir, "games", "hsr", "pools", "pick.yaml"), `
+items:
+  5:
+    featured: [{id: acheron}, {id: kafka}]
+    standard: [{id: bronya}]
+selector:
+  default: kafka
+  keep_fate: true
+`)
+	writeFile(t, filepath.Join(dir, "games", "hsr", "pools", "bad.yaml"), `
+items:
+  5:
+    featured: [{id: acheron}]
+selector:
+  default: bronya
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	_, ep, err := r.Resolve("hsr", "pick", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &game.SelectorParams{Rarity: 5, Choices: []string{"acheron", "kafka"}, Default: "kafka", KeepPity: true, KeepFate: true}
+	if !reflect.DeepEqual(ep.Selector, want) {
+		t.Fatalf("selector %+v, want %+v", ep.Selector, want)
+	}
+	var verr *game.ValidationError
+	if _, _, err := r.Resolve("hsr", "bad", game.Overrides{}); !errors.As(err, &verr) {
+		t.Fatalf("unknown default should fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestSoftPityPerDrawIncrement(t *testing.T) {
+	// p_base=0 so only the increment can produce a hit:
+	// draws 1..5 (Count 0..4) use p=0, Count=5 uses 0.25, Count=8 reaches ~1.
+	cfg := &gacha.SoftPityConfig{
+		Mode:      gacha.SoftPerDrawIncrement,
+		StartAt:   5,
+		Increment: 0.25,
+	}
+	for seed := uint64(1); seed <= 200; seed++ {
+		sp, err := gacha.NewSoftPitySystem(90, cfg, gacha.NewSeededRNG(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		draws := 0
+		for {
+			draws++
+			hit, err := sp.Draw(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit {
+				break
+			}
+		}
+		if draws < 6 || draws > 9 {
+			t.Fatalf("seed=%d: hit at draw %d, want 6..9", seed, draws)
+		}
+	}
+}
+
+func TestSoftPityPerDrawIncrementInvalid(t *testing.T) {
+	cfg := &gacha.SoftPityConfig{Mode: gacha.SoftPerDrawIncrement, StartAt: 5}
+	if _, err := gacha.NewSoftPitySystem(90, cfg, nil); err == nil {
+		t.Fatalf("missing increment must error")
+	}
+}
+
+func TestMonteCarloPerDrawIncrement(t *testing.T) {
+	startAt, inc := 50, 0.02
+	p := gacha.SimParams{
+		PBase:     0.02,
+		Pity:      99,
+		StartAt:   &startAt,
+		SoftMode:  string(gacha.SoftPerDrawIncrement),
+		Increment: &inc,
+	}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstHit, 20000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Arknights-style curve averages ~34.6 draws per 6★; hard pity alone would be ~43.
+	if st.Mean < 32 || st.Mean > 37 {
+		t.Fatalf("mean=%f, want ~34.6", st.Mean)
+	}
+	if st.P99 > 70 {
+		t.Fatalf("p99=%f, increment ramp should cap draws well before pity", st.P99)
+	}
+}
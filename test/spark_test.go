@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+)
+
+func TestSparkCapsFirstUP(t *testing.T) {
+	// no effective pity: only the spark bounds the wait
+	p := gacha.SimParams{PBase: 0.01, Pity: 1000, Spark: &gacha.SparkParams{Threshold: 200}}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstUP, 20000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range st.Samples {
+		if v > 200 {
+			t.Fatalf("spark should cap the wait at 200 draws, got %d", v)
+		}
+	}
+	// E[min(Geometric(p), 200)] = (1 - (1-p)^200) / p
+	want := (1 - math.Pow(0.99, 200)) / 0.01
+	if tol := 5 * st.StdDev / math.Sqrt(20000); math.Abs(st.Mean-want) > tol {
+		t.Fatalf("mean %.2f, want %.2f (tol %.2f)", st.Mean, want, tol)
+	}
+}
+
+func TestSparkCountsAsUP(t *testing.T) {
+	// a negligible rate leaves the spark as the only source of UPs
+	p := gacha.SimParams{PBase: 1e-12, Pity: 10000, Spark: &gacha.SparkParams{Threshold: 200, PerDraw: 2}}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalNthUP, 10, &gacha.SimBudget{Copies: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mean != 300 || st.Var != 0 {
+		t.Fatalf("3 sparks at 2 points per draw need 300 draws, got %+v", st)
+	}
+	st, err = gacha.RunMonteCarlo(p, gacha.GoalFixedBudget, 10, &gacha.SimBudget{NumDraws: 450})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mean != 4 {
+		t.Fatalf("900 points buy 4 UPs, got %v", st.Mean)
+	}
+	if _, err := gacha.RunExact(p, gacha.GoalFirstUP, nil); !errors.Is(err, gacha.ErrExactUnsupported) {
+		t.Fatalf("exact solver should reject spark, got %v", err)
+	}
+}
+
+func TestSparkCarryAcrossLegs(t *testing.T) {
+	leg := func(points int, carry bool) gacha.GoalLeg {
+		return gacha.GoalLeg{Params: gacha.SimParams{
+			PBase: 1e-12, Pity: 10000,
+			Spark: &gacha.SparkParams{Threshold: 200, Points: points, Carry: carry},
+		}}
+	}
+	ctx := context.Background()
+	// first leg sparks on draw 1 and keeps 151 points
+	for _, c := range []struct {
+		carry bool
+		want  float64
+	}{{true, 1 + 49}, {false, 1 + 200}} {
+		st, err := gacha.RunMonteCarloCombined(ctx, []gacha.GoalLeg{leg(350, c.carry), leg(0, c.carry)}, 10, gacha.MCOptions{Seed: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mean != c.want {
+			t.Fatalf("carry=%v: %v draws, want %v", c.carry, st.Mean, c.want)
+		}
+	}
+}
+
+func TestSparkFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 1000
+  p_base: 0.01
+spark:
+  threshold: 200
+  per_draw: 1
+  carry: true
+`)
+	writeFile(t, filepath.Join(dir, "games", "bandori", "pools", "free.yaml"), `
+spark:
+  threshold: 0
+`)
+	writeFile(t, filepath.Join(dir, "games", "bandori", "pools", "bad.yaml"), `
+spark:
+  per_draw: 0
+`)
+	r := game.NewResolver(game.NewLoader(dir))
+	points := 120
+	_, ep, err := r.Resolve("bandori", "", game.Overrides{SparkPoints: &points})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.Spark == nil || *ep.Spark != (game.SparkParams{Threshold: 200, PerDraw: 1, Carry: true, Points: 120}) {
+		t.Fatalf("unexpected spark: %+v", ep.Spark)
+	}
+	if sp := game.ToSimParams(ep).Spark; sp == nil || sp.Points != 120 {
+		t.Fatalf("spark not forwarded: %+v", sp)
+	}
+	if _, ep, err := r.Resolve("bandori", "free", game.Overrides{}); err != nil || ep.Spark != nil {
+		t.Fatalf("threshold 0 should disable spark: %+v, %v", ep.Spark, err)
+	}
+	var verr *game.ValidationError
+	if _, _, err := r.Resolve("bandori", "bad", game.Overrides{}); !errors.As(err, &verr) {
+		t.Fatalf("per_draw 0 should fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+	"github.com/xtding233/gacha-backend/internal/game"
+	"github.com/xtding233/gacha-backend/internal/state"
+)
+
+func TestStateCarriesPityAcrossCalls(t *testing.T) {
+	stores := map[string]state.Store{"memory": state.NewMemoryStore()}
+	fs, err := state.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores["file"] = fs
+
+	ctx := context.Background()
+	key := state.Key{Player: "p1", Game: "hsr", Family: "char"}
+	p := gacha.SimParams{PBase: 0, Pity: 10, OffProbs: []float64{0.5}}
+
+	for name, store := range stores {
+		// 4 + 5 draws in separate calls with p=0: no hit yet, count must be 9,
+		// so the first draw of the third call is the hard pity hit.
+		for call, n := range []int{4, 5, 1} {
+			err := store.Update(ctx, key, func(st *state.PlayerState) error {
+				ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(1))
+				if err != nil {
+					return err
+				}
+				st.Apply(ts)
+				for i := 0; i < n; i++ {
+					out, err := ts.Draw()
+					if err != nil {
+						return err
+					}
+					if hit := out.Rarity == 5; hit != (call == 2) {
+						t.Fatalf("%s: call %d draw %d hit=%v", name, call, i, hit)
+					}
+				}
+				st.Capture(ts)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		st, err := store.Load(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Tiers[5].Count != 0 {
+			t.Fatalf("%s: count after pity hit=%d", name, st.Tiers[5].Count)
+		}
+		if other, _ := store.Load(ctx, state.Key{Player: "p2", Game: "hsr", Family: "char"}); len(other.Tiers) != 0 {
+			t.Fatalf("%s: state leaked across players", name)
+		}
+	}
+}
+
+func TestStateUpdateIsAtomic(t *testing.T) {
+	fs, err := state.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := state.Key{Player: "p/1", Game: "hsr"}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = fs.Update(ctx, key, func(st *state.PlayerState) error {
+				if st.Tiers == nil {
+					st.Tiers = map[int]state.TierState{}
+				}
+				ts := st.Tiers[5]
+				ts.Count++
+				st.Tiers[5] = ts
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	st, err := fs.Load(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Tiers[5].Count != 50 {
+		t.Fatalf("lost updates: count=%d", st.Tiers[5].Count)
+	}
+}
+
+func TestFileStoreRejectsUnsafeKeys(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := state.NewFileStore(filepath.Join(dir, "state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, key := range []state.Key{
+		{Game: "hsr", Family: "char"},
+		{Player: "p1", Family: "char"},
+		{Player: "..", Game: "hsr", Family: "char"},
+		{Player: "p1", Game: "..", Family: "char"},
+		{Player: "p1", Game: "hsr", Family: "."},
+	} {
+		if _, err := fs.Load(ctx, key); !errors.Is(err, state.ErrInvalidKey) {
+			t.Fatalf("load %+v: err = %v", key, err)
+		}
+		if err := fs.Update(ctx, key, func(*state.PlayerState) error { return nil }); !errors.Is(err, state.ErrInvalidKey) {
+			t.Fatalf("update %+v: err = %v", key, err)
+		}
+	}
+	// separators are escaped, so no key leaves its game's directory
+	if err := fs.Update(ctx, state.Key{Player: "../../x", Game: "hsr", Family: "char"}, func(*state.PlayerState) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("files outside the store: %v", entries)
+	}
+}
+
+func TestPityGroupRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "games", "default.yaml"), `
+draw:
+  pity: 90
+  p_base: 0.006
+banner:
+  off_probs: [0.5]
+`)
+	writeFile(t, filepath.Join(dir, "games", "hsr.yaml"), `
+pity_groups:
+  limited_char:
+    carry_off_streak: false
+`)
+	writeFile(t, filepath.Join(dir, "games", "hsr", "pools", "seele.yaml"), "pity_group: limited_char\n")
+	r := game.NewResolver(game.NewLoader(dir))
+	_, ep, err := r.Resolve("hsr", "seele", game.Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.PityGroup != "limited_char" || !ep.CarryGuarantee || ep.CarryOffStreak {
+		t.Fatalf("group rules not resolved: %+v", ep)
+	}
+
+	st := state.PlayerState{Tiers: map[int]state.TierState{5: {Count: 40, OffStreak: 1, GuaranteedNext: true}}}
+	st.Rotate("seele", ep.CarryGuarantee, ep.CarryOffStreak, false)
+	if st.Tiers[5].OffStreak != 1 {
+		t.Fatalf("first pool in group must not reset state: %+v", st)
+	}
+	st.Rotate("acheron", ep.CarryGuarantee, ep.CarryOffStreak, false)
+	if got := st.Tiers[5]; got.Count != 40 || !got.GuaranteedNext || got.OffStreak != 0 || st.Pool != "acheron" {
+		t.Fatalf("rotation rules not applied: %+v", st)
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xtding233/gacha-backend/internal/gacha"
+)
+
+func TestTieredPreemptsLowerTier(t *testing.T) {
+	// Both tiers reach hard pity on draw 10: 5★ wins, 4★ guarantee moves to draw 11.
+	p := gacha.SimParams{
+		PBase: 0, Pity: 10,
+		Tiers: []gacha.SimParams{{Rarity: 4, PBase: 0, Pity: 10}},
+	}
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 11; i++ {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 3
+		switch i {
+		case 10:
+			want = 5
+		case 11:
+			want = 4
+		}
+		if out.Rarity != want {
+			t.Fatalf("draw %d: rarity=%d, want %d", i, out.Rarity, want)
+		}
+	}
+	if c := ts.Tier(4).Soft.Count; c != 0 {
+		t.Fatalf("4★ count should reset after its hit; got %d", c)
+	}
+}
+
+func TestTieredHigherTierOverridesHardPity(t *testing.T) {
+	// 4★ at hard pity, 5★ rolled by probability: the 5★ wins and the 4★
+	// guarantee moves to the next draw.
+	p := gacha.SimParams{
+		PBase: 0.5, Pity: 90,
+		Tiers: []gacha.SimParams{{Rarity: 4, PBase: 0.051, Pity: 10}},
+	}
+	rng := &gacha.ReplayRNG{Values: []float64{0.1, 0.9}}
+	ts, err := gacha.NewTieredFromParams(p, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Restore([]gacha.TierSnapshot{{Rarity: 4, Count: 9}})
+	// the hard pity tier only gets the mass the 5★ leaves
+	if hit, _ := ts.Probs(); hit[0] != 0.5 || hit[1] != 0.5 {
+		t.Fatalf("win probabilities %v, want [0.5 0.5]", hit)
+	}
+	for i, want := range []int{5, 4} {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Rarity != want {
+			t.Fatalf("draw %d: rarity=%d, want %d", i+1, out.Rarity, want)
+		}
+	}
+
+	// so a 4★ tier leaves the 5★ odds alone
+	startAt, target := 73, 0.3
+	p = gacha.SimParams{
+		PBase: 0.006, Pity: 90, StartAt: &startAt, TargetProb: &target,
+		Tiers: []gacha.SimParams{{Rarity: 4, PBase: 0.051, Pity: 10}},
+	}
+	top := p
+	top.Tiers = nil
+	d, err := gacha.RunExact(top, gacha.GoalFirstHit, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstHit, 40000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tol := 5 * d.StdDev / math.Sqrt(40000); math.Abs(st.Mean-d.Mean) > tol {
+		t.Fatalf("tiered mean %.3f, top tier alone %.3f (tol %.3f)", st.Mean, d.Mean, tol)
+	}
+}
+
+func TestTieredBannerPerTier(t *testing.T) {
+	p := gacha.SimParams{
+		PBase: 0.006, Pity: 90,
+		OffProbs: []float64{0.5}, MaxOff: 1,
+		Tiers: []gacha.SimParams{{Rarity: 4, PBase: 0.051, Pity: 10, OffProbs: []float64{0.5}, MaxOff: 1}},
+	}
+	ts, err := gacha.NewTieredFromParams(p, gacha.NewSeededRNG(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[int]int{}
+	ups := 0
+	for i := 0; i < 10000; i++ {
+		out, err := ts.Draw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[out.Rarity]++
+		if out.Rarity == 4 && out.IsUp {
+			ups++
+		}
+		if out.Rarity == 3 && out.IsUp {
+			t.Fatalf("base rarity cannot be UP")
+		}
+	}
+	if counts[4] < 1000 || counts[4] > 1600 {
+		t.Fatalf("4★ count=%d out of expected range (~1300)", counts[4])
+	}
+	if ups < counts[4]/2 {
+		t.Fatalf("4★ UP share too low: %d of %d", ups, counts[4])
+	}
+}
+
+func TestMonteCarloTargetRarity(t *testing.T) {
+	p := gacha.SimParams{
+		PBase: 0.006, Pity: 90,
+		Tiers:        []gacha.SimParams{{Rarity: 4, PBase: 0.051, Pity: 10}},
+		TargetRarity: 4,
+	}
+	st, err := gacha.RunMonteCarlo(p, gacha.GoalFirstHit, 5000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mean < 6 || st.Mean > 9 || st.P99 > 11 {
+		t.Fatalf("4★ first hit stats off: %+v", st)
+	}
+
+	p.TargetRarity = 2
+	if _, err := gacha.RunMonteCarlo(p, gacha.GoalFirstHit, 1, nil); err == nil {
+		t.Fatalf("unknown target rarity must error")
+	}
+}